@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"review-manager/internal/config"
+	"review-manager/internal/repository"
+	"review-manager/internal/roster"
+	"review-manager/internal/service"
+	"review-manager/internal/validation"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// review-manager import -file roster.csv [-format csv|yaml] [-dry-run]
+// Импортирует команды и пользователей из файла напрямую в БД по DSN из окружения
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("file", "", "путь к файлу ростера (CSV или YAML)")
+	format := fs.String("format", "", "формат файла: csv или yaml, по умолчанию по расширению")
+	dryRun := fs.Bool("dry-run", false, "только показать, что изменится")
+	_ = fs.Parse(args)
+
+	if *file == "" {
+		log.Fatal("нужно указать -file")
+	}
+	if *format == "" {
+		*format = roster.FormatFromName(*file)
+	}
+
+	dsn := os.Getenv("DSN")
+	if dsn == "" {
+		log.Fatal("DSN не задано в окружении")
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Не получилось открыть файл: %v", err)
+	}
+	defer f.Close()
+
+	entries, err := roster.Parse(f, *format)
+	if err != nil {
+		log.Fatalf("Ошибки в файле: %v", err)
+	}
+	if err := validation.ValidateRoster(entries); err != nil {
+		log.Fatalf("Ошибки в файле: %v", err)
+	}
+
+	ctx := context.Background()
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		log.Fatalf("Не получилось создать pgxpool: %v", err)
+	}
+	defer pool.Close()
+
+	teamSvc := service.NewTeamService(
+		repository.NewPgTeamRepo(pool),
+		repository.NewPgUserRepo(pool, nil),
+		repository.NewPgCodeownersRepo(pool),
+		repository.NewPgWebhookRepo(pool),
+		repository.NewPgTxManager(pool, config.Default().DB.TxMaxRetries),
+	)
+
+	resp, err := teamSvc.ImportRoster(ctx, entries, *dryRun)
+	if err != nil {
+		log.Fatalf("Импорт не удался: %v", err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(resp)
+}
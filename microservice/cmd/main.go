@@ -3,83 +3,268 @@ package main
 import (
 	"context"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"review-manager/internal/config"
+	"review-manager/internal/events"
+	"review-manager/internal/grpcapi"
 	"review-manager/internal/httpapi"
+	"review-manager/internal/metrics"
+	"review-manager/internal/notify"
 	"review-manager/internal/repository"
 	"review-manager/internal/service"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"google.golang.org/grpc"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		runImport(os.Args[2:])
+		return
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	dsn := os.Getenv("DSN")
-	if dsn == "" {
-		log.Fatal("DSN не задано в окружении")
-	}
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		log.Fatal("PORT нужно задать в окружении")
+	// Настройки из файла CONFIG_FILE (если задан) и окружения, переменные окружения важнее файла
+	cfgPath := os.Getenv("CONFIG_FILE")
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		log.Fatalf("Некорректная конфигурация:\n%v", err)
 	}
 
-	admToken := os.Getenv("ADMIN_TOKEN")
-	if admToken == "" {
-		log.Fatalf("ADMIN_TOKEN надо задать в окружении")
-	}
+	// уровень логов меняется на лету. Ошибки пишутся на уровне error, поэтому видны при любом log_level
+	var logLevel slog.LevelVar
+	level, _ := config.ParseLogLevel(cfg.LogLevel)
+	logLevel.Set(level)
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: &logLevel})))
 
-	pool, err := pgxpool.New(ctx, dsn)
+	// Пул ждет БД до db.connect_wait, чтобы сервис не падал, если стартовал раньше базы
+	pool, err := repository.NewPool(ctx, cfg.DSN, repository.PoolOptions{
+		MaxConns:         cfg.DB.MaxConns,
+		MinConns:         cfg.DB.MinConns,
+		MaxConnLifetime:  cfg.DB.MaxConnLifetime,
+		MaxConnIdleTime:  cfg.DB.MaxConnIdleTime,
+		StatementTimeout: cfg.DB.StatementTimeout,
+		ConnectWait:      cfg.DB.ConnectWait,
+	})
 	if err != nil {
-		log.Fatalf("Не получилось создать pgxpool: %v", err)
+		fatal("Не получилось подключиться к БД", err)
 	}
 	defer pool.Close()
 
+	// Реплики для списков, статистики и выгрузок. Заранее к ним не подключаемся:
+	// пока реплика недоступна или отстает больше db.replica_max_lag, эти запросы идут на основную БД
+	var replicaPools []*pgxpool.Pool
+	for _, dsn := range cfg.DB.ReplicaDSNs {
+		replicaPool, err := repository.NewPool(ctx, dsn, repository.PoolOptions{
+			MaxConns:         cfg.DB.MaxConns,
+			MaxConnLifetime:  cfg.DB.MaxConnLifetime,
+			MaxConnIdleTime:  cfg.DB.MaxConnIdleTime,
+			StatementTimeout: cfg.DB.StatementTimeout,
+			ConnectWait:      -1,
+		})
+		if err != nil {
+			fatal("Не получилось создать пул реплики", err)
+		}
+		defer replicaPool.Close()
+		replicaPools = append(replicaPools, replicaPool)
+	}
+	reads := repository.NewReadRouter(pool, replicaPools, cfg.DB.ReplicaMaxLag)
+	if len(replicaPools) > 0 {
+		go reads.Run(ctx, cfg.DB.ReplicaCheckInterval)
+	}
+
 	// Репозитории
-	teamRepo := repository.NewPgTeamRepo(pool)
-	userRepo := repository.NewPgUserRepo(pool)
-	prRepo := repository.NewPgPrRepo(pool)
+	var (
+		teamRepo repository.TeamRepo = repository.NewPgTeamRepo(pool)
+		userRepo repository.UserRepo = repository.NewPgUserRepo(pool, reads)
+		prRepo   repository.PrRepo   = repository.NewPgPrRepo(pool, reads)
+	)
+	exportRepo := repository.NewPgExportRepo(pool, reads, cfg.DB.ExportStatementTimeout)
+	absenceRepo := repository.NewPgAbsenceRepo(pool)
+	ownersRepo := repository.NewPgCodeownersRepo(pool)
+	idemRepo := repository.NewPgIdempotencyRepo(pool)
+	webhookRepo := repository.NewPgWebhookRepo(pool)
+	staleRepo := repository.NewPgStaleReviewRepo(pool)
+	var archiveRepo repository.ArchiveRepo = repository.NewPgArchiveRepo(pool)
+	reconcileRepo := repository.NewPgReconcileRepo(pool)
 
-	// Менеджер транзакций
-	txMgr := repository.NewPgTxManager(pool)
+	// Кэш чтений для /team/get и /stats/reviewers на cache.ttl, сбрасывается после каждой записи в команды,
+	// пользователей и ревьюверов PR, а также после переноса PR в архив
+	if cfg.Cache.TTL > 0 {
+		cache := repository.NewReadCache(cfg.Cache.TTL)
+		teamRepo = repository.NewCachedTeamRepo(teamRepo, cache)
+		userRepo = repository.NewCachedUserRepo(userRepo, cache)
+		prRepo = repository.NewCachedPrRepo(prRepo, cache)
+		archiveRepo = repository.NewCachedArchiveRepo(archiveRepo, cache)
+	}
+
+	// Менеджер транзакций (временные ошибки повторяет до db.tx_max_retries раз) и advisory-блокировки для фоновых задач
+	txMgr := repository.NewPgTxManager(pool, cfg.DB.TxMaxRetries)
+	locker := repository.NewPgLocker(pool)
+
+	// Последние события для /events/stream
+	broker := events.NewBroker(cfg.Events.BufferSize)
 
 	// Cервисы
-	teamSvc := service.NewTeamService(teamRepo, userRepo, txMgr)
-	userSvc := service.NewUserService(userRepo)
-	prSvc := service.NewPRService(prRepo, userRepo, txMgr)
+	teamSvc := service.NewTeamService(teamRepo, userRepo, ownersRepo, webhookRepo, txMgr)
+	prSvc := service.NewPRService(prRepo, userRepo, teamRepo, ownersRepo, txMgr, broker)
+	userSvc := service.NewUserService(userRepo, absenceRepo, prSvc, broker)
+	exportSvc := service.NewExportService(exportRepo)
+
+	// Уведомления в чаты команд по событиям сервисов
+	notifier := notify.NewNotifier(broker, webhookRepo, &http.Client{Timeout: cfg.Notify.Timeout})
+	notifier.SetFallback(cfg.Notify.FallbackWebhookURL, cfg.Notify.FallbackWebhookKind)
+	go notifier.Run(ctx)
+
+	// Фоновое переназначение ревью отсутствующих пользователей, включается через reviews.absence_interval
+	if cfg.Reviews.AbsenceInterval > 0 {
+		absenceJob := service.NewAbsenceJob(absenceRepo, userRepo, locker, prSvc)
+		go absenceJob.Run(ctx, cfg.Reviews.AbsenceInterval)
+	}
+
+	// SLA ревью по умолчанию: напоминание через reviews.stale_sla, переназначение или эскалация через reviews.stale_escalate_after.
+	// Периодический прогон включается через reviews.stale_interval, вручную - /admin/runStaleReviews
+	staleJob := service.NewStaleReviewJob(staleRepo, locker, prSvc, cfg.Reviews.StaleSLA, cfg.Reviews.StaleEscalateAfter)
+	if cfg.Reviews.StaleInterval > 0 {
+		go staleJob.Run(ctx, cfg.Reviews.StaleInterval)
+	}
+
+	// Ремонт открытых PR с выключенными или ушедшими из команды ревьюверами и с нехваткой ревьюверов.
+	// Периодический прогон включается через reviews.reconcile_interval, вручную - /admin/reconcile
+	reconcileJob := service.NewReconcileJob(reconcileRepo, locker, prSvc)
+	if cfg.Reviews.ReconcileInterval > 0 {
+		go reconcileJob.Run(ctx, cfg.Reviews.ReconcileInterval)
+	}
+
+	// Архивация смерженных PR старше reviews.archive_after_days дней, проверка раз в reviews.archive_interval
+	if cfg.Reviews.ArchiveAfterDays > 0 {
+		archiveJob := service.NewArchiveJob(archiveRepo, locker, txMgr, time.Duration(cfg.Reviews.ArchiveAfterDays)*24*time.Hour)
+		go archiveJob.Run(ctx, cfg.Reviews.ArchiveInterval)
+	}
+
+	// Сколько хранится ответ по Idempotency-Key
+	idem := httpapi.NewIdempotency(idemRepo, cfg.Idempotency.TTL)
+	go idem.RunCleanup(ctx, time.Hour)
 
 	// HTTP API
-	h := httpapi.NewHandler(teamSvc, userSvc, prSvc, admToken)
-	handler := httpapi.NewMux(h)
+	h := httpapi.NewHandler(teamSvc, userSvc, prSvc, exportSvc, staleJob, reconcileJob, broker, reads, cfg.Auth.AdminToken)
+
+	// Лимиты частоты запросов и размера тела, счетчики отказов и сами лимиты видны на /metrics
+	reg := metrics.NewRegistry()
+	limiter := httpapi.NewRateLimit(httpapi.RateLimitConfig{
+		IPRate:       cfg.RateLimit.IPRPS,
+		IPBurst:      cfg.RateLimit.IPBurst,
+		TokenRate:    cfg.RateLimit.TokenRPS,
+		TokenBurst:   cfg.RateLimit.TokenBurst,
+		MaxBodyBytes: cfg.HTTP.MaxBodyBytes,
+	}, reg)
+	go limiter.RunCleanup(ctx, time.Minute)
+
+	handler := http.NewServeMux()
+	handler.Handle("/metrics", reg)
+	handler.Handle("/", limiter.Wrap(idem.Wrap(httpapi.NewMux(h))))
 
 	srv := &http.Server{
-		Addr:         ":" + port,
+		Addr:         ":" + cfg.Port,
 		Handler:      handler,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 5 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
+	}
+
+	// gRPC API на отдельном порту, включается через grpc_port
+	var (
+		grpcSrv *grpc.Server
+		grpcAPI *grpcapi.Server
+	)
+	if cfg.GRPCPort != "" {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			fatal("Не получилось открыть порт для gRPC", err)
+		}
+		grpcAPI = grpcapi.NewServer(teamSvc, userSvc, prSvc, cfg.Auth.AdminToken)
+		grpcSrv = grpcapi.NewGRPCServer(grpcAPI, limiter, cfg.HTTP.MaxBodyBytes)
+
+		go func() {
+			slog.Info("gRPC сервер работает", "addr", lis.Addr().String())
+			if err := grpcSrv.Serve(lis); err != nil {
+				fatal("Ошибка gRPC сервера", err)
+			}
+		}()
 	}
 
 	go func() {
-		log.Printf("HTTP сервер работает по адресу %s", srv.Addr)
+		slog.Info("HTTP сервер работает", "addr", srv.Addr)
 		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Ошибка сервера: %v", err)
+			fatal("Ошибка сервера", err)
+		}
+	}()
+
+	// SIGHUP перечитывает конфигурацию: то, что можно, применяем на лету, не трогая соединения,
+	// об остальных изменениях пишем в лог - они вступят в силу после перезапуска
+	shutdownTimeout := cfg.HTTP.ShutdownTimeout
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+			}
+
+			next, err := config.Load(cfgPath)
+			if err != nil {
+				slog.Error("SIGHUP: конфигурация не применена", "err", err)
+				continue
+			}
+			if changed := config.StructuralChanges(cfg, next); len(changed) > 0 {
+				slog.Warn("SIGHUP: нужен перезапуск, чтобы применить изменения", "fields", strings.Join(changed, ", "))
+			}
+
+			level, _ := config.ParseLogLevel(next.LogLevel)
+			logLevel.Set(level)
+			h.SetAdminToken(next.Auth.AdminToken)
+			if grpcAPI != nil {
+				grpcAPI.SetAdminToken(next.Auth.AdminToken)
+			}
+			staleJob.SetDefaults(next.Reviews.StaleSLA, next.Reviews.StaleEscalateAfter)
+			notifier.SetFallback(next.Notify.FallbackWebhookURL, next.Notify.FallbackWebhookKind)
+
+			cfg = cfg.WithReloadable(next)
+			slog.Info("SIGHUP: конфигурация перечитана")
 		}
 	}()
 
 	<-ctx.Done()
-	log.Println("Выключаемся...")
+	slog.Info("Выключаемся...")
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer cancel()
 
 	if err := srv.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Ошибка при отключении сервера: %v", err)
+		slog.Error("Ошибка при отключении сервера", "err", err)
+	}
+
+	if grpcSrv != nil {
+		grpcSrv.GracefulStop()
 	}
 }
+
+// fatal пишет ошибку на уровне error, который виден при любом log_level, и завершает процесс, как log.Fatalf
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
@@ -0,0 +1,18 @@
+// reviewctl - консольный клиент к HTTP API review-manager для дежурных.
+//
+//	reviewctl [-url URL] [-token TOKEN] [-o table|json] [-config FILE] <группа> <действие> [флаги]
+//
+// Настройки берутся из флагов, затем из REVIEWCTL_URL / REVIEWCTL_TOKEN (или ADMIN_TOKEN) /
+// REVIEWCTL_OUTPUT, затем из YAML-файла (-config, REVIEWCTL_CONFIG или ~/.config/reviewctl.yaml).
+// Код выхода зависит от кода ошибки в ответе сервера, см. reviewctl.Exit*
+package main
+
+import (
+	"os"
+
+	"review-manager/internal/reviewctl"
+)
+
+func main() {
+	os.Exit(reviewctl.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,148 @@
+package codeowners
+
+import (
+	"bufio"
+	"io"
+	"path"
+	"strings"
+
+	"review-manager/internal/validation"
+)
+
+// префикс владельца-команды: @team:backend, остальные @... - это user_id
+const teamOwnerPrefix = "team:"
+
+// Rule - одна строка файла: glob и владельцы
+type Rule struct {
+	Line    int
+	Pattern string
+	Owners  []string
+}
+
+// Пользователи и команды из списка владельцев правила
+func (r Rule) Split() (userIDs, teamNames []string) {
+	for _, o := range r.Owners {
+		o = strings.TrimPrefix(o, "@")
+		if name, ok := strings.CutPrefix(o, teamOwnerPrefix); ok {
+			teamNames = append(teamNames, name)
+			continue
+		}
+		userIDs = append(userIDs, o)
+	}
+	return userIDs, teamNames
+}
+
+func (r Rule) String() string {
+	return r.Pattern + " " + strings.Join(r.Owners, " ")
+}
+
+// Parse разбирает файл в формате CODEOWNERS:
+//
+//	# комментарий
+//	/api/**     @u1 @u2
+//	*.sql       @team:dba
+//
+// Возвращает все ошибки файла сразу с номерами строк
+func Parse(r io.Reader) ([]Rule, error) {
+	var (
+		rules []Rule
+		errs  validation.LineErrors
+	)
+
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+
+		text := sc.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) == 1 {
+			errs = append(errs, validation.LineError{Line: line, Msg: "rule " + fields[0] + " has no owners"})
+			continue
+		}
+		if _, err := path.Match(strings.Trim(fields[0], "/"), ""); err != nil {
+			errs = append(errs, validation.LineError{Line: line, Msg: "bad pattern " + fields[0]})
+			continue
+		}
+
+		ok := true
+		for _, o := range fields[1:] {
+			name := strings.TrimPrefix(o, "@")
+			if !strings.HasPrefix(o, "@") || name == "" || name == teamOwnerPrefix {
+				errs = append(errs, validation.LineError{Line: line, Msg: "owner " + o + " must look like @user_id or @team:name"})
+				ok = false
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		rules = append(rules, Rule{Line: line, Pattern: fields[0], Owners: fields[1:]})
+	}
+	if err := sc.Err(); err != nil {
+		errs = append(errs, validation.LineError{Line: line + 1, Msg: err.Error()})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return rules, nil
+}
+
+// MatchFile возвращает правило для файла. Как в CODEOWNERS, выигрывает последнее подходящее правило
+func MatchFile(rules []Rule, file string) (Rule, bool) {
+	for i := len(rules) - 1; i >= 0; i-- {
+		if Match(rules[i].Pattern, file) {
+			return rules[i], true
+		}
+	}
+	return Rule{}, false
+}
+
+// Match проверяет путь на соответствие шаблону:
+// "/x" привязан к корню, шаблон без "/" ищется в любой директории,
+// "dir/" и совпадение с директорией захватывают все внутри, "**" - любое количество директорий
+func Match(pattern, file string) bool {
+	file = strings.Trim(file, "/")
+	anchored := strings.HasPrefix(pattern, "/")
+	pattern = strings.Trim(pattern, "/")
+	if pattern == "" || file == "" {
+		return false
+	}
+
+	if !anchored && !strings.Contains(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+
+	p := strings.Split(pattern, "/")
+	f := strings.Split(file, "/")
+	return matchSegments(p, f) || matchSegments(append(p[:len(p):len(p)], "**"), f)
+}
+
+func matchSegments(p, f []string) bool {
+	for len(p) > 0 {
+		if p[0] == "**" {
+			for i := 0; i <= len(f); i++ {
+				if matchSegments(p[1:], f[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(f) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(p[0], f[0]); !ok {
+			return false
+		}
+		p, f = p[1:], f[1:]
+	}
+	return len(f) == 0
+}
@@ -0,0 +1,328 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"review-manager/internal/dto"
+)
+
+// Config - все настройки сервиса. Значения берутся из умолчаний, затем из YAML-файла, затем из окружения:
+// у каждого поля с тегом env переменная окружения перекрывает файл
+type Config struct {
+	DSN      string `yaml:"dsn" env:"DSN"`
+	Port     string `yaml:"port" env:"PORT"`
+	GRPCPort string `yaml:"grpc_port" env:"GRPC_PORT"`
+
+	// debug, info, warn или error; применяется на лету по SIGHUP
+	LogLevel string `yaml:"log_level" env:"LOG_LEVEL"`
+
+	HTTP        HTTP        `yaml:"http"`
+	DB          DB          `yaml:"db"`
+	Auth        Auth        `yaml:"auth"`
+	Reviews     Reviews     `yaml:"reviews"`
+	Notify      Notify      `yaml:"notify"`
+	Events      Events      `yaml:"events"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Cache       Cache       `yaml:"cache"`
+	RateLimit   RateLimit   `yaml:"rate_limit"`
+}
+
+type HTTP struct {
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+
+	// предел размера тела запроса в байтах, 0 - без предела
+	MaxBodyBytes int64 `yaml:"max_body_bytes" env:"HTTP_MAX_BODY_BYTES"`
+}
+
+// Пул соединений с БД (0 в размерах - значение pgxpool по умолчанию) и устойчивость к ее сбоям:
+// ожидание БД при старте, лимиты времени запросов (0 - без лимита) и повторы транзакций
+type DB struct {
+	MaxConns        int32         `yaml:"max_conns" env:"DB_MAX_CONNS"`
+	MinConns        int32         `yaml:"min_conns" env:"DB_MIN_CONNS"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+
+	ConnectWait            time.Duration `yaml:"connect_wait" env:"DB_CONNECT_WAIT"`
+	StatementTimeout       time.Duration `yaml:"statement_timeout" env:"DB_STATEMENT_TIMEOUT"`
+	ExportStatementTimeout time.Duration `yaml:"export_statement_timeout" env:"DB_EXPORT_STATEMENT_TIMEOUT"`
+	TxMaxRetries           int           `yaml:"tx_max_retries" env:"DB_TX_MAX_RETRIES"`
+
+	// реплики для списков, статистики и выгрузок; в окружении DSN через запятую.
+	// Реплика, отставшая больше replica_max_lag (0 - без предела), выводится из ротации
+	ReplicaDSNs          []string      `yaml:"replica_dsns" env:"DB_REPLICA_DSNS"`
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag" env:"DB_REPLICA_MAX_LAG"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL"`
+}
+
+// admin-токен применяется на лету по SIGHUP
+type Auth struct {
+	AdminToken string `yaml:"admin_token" env:"ADMIN_TOKEN"`
+}
+
+// Политика ревью. Пороги SLA применяются на лету, интервалы фоновых задач - только после перезапуска;
+// нулевой интервал (и ArchiveAfterDays) выключает задачу
+type Reviews struct {
+	StaleSLA           time.Duration `yaml:"stale_sla" env:"STALE_REVIEW_SLA"`
+	StaleEscalateAfter time.Duration `yaml:"stale_escalate_after" env:"STALE_REVIEW_ESCALATE_AFTER"`
+	StaleInterval      time.Duration `yaml:"stale_interval" env:"STALE_REVIEW_INTERVAL"`
+	AbsenceInterval    time.Duration `yaml:"absence_interval" env:"ABSENCE_REASSIGN_INTERVAL"`
+	ReconcileInterval  time.Duration `yaml:"reconcile_interval" env:"RECONCILE_INTERVAL"`
+	ArchiveAfterDays   int           `yaml:"archive_after_days" env:"ARCHIVE_AFTER_DAYS"`
+	ArchiveInterval    time.Duration `yaml:"archive_interval" env:"ARCHIVE_INTERVAL"`
+}
+
+// Уведомления в чаты. Запасной webhook получает события команд без своего webhook,
+// пустой URL - такие события не отправляются; применяется на лету
+type Notify struct {
+	Timeout             time.Duration `yaml:"timeout" env:"NOTIFY_TIMEOUT"`
+	FallbackWebhookURL  string        `yaml:"fallback_webhook_url" env:"NOTIFY_FALLBACK_WEBHOOK_URL"`
+	FallbackWebhookKind string        `yaml:"fallback_webhook_kind" env:"NOTIFY_FALLBACK_WEBHOOK_KIND"`
+}
+
+type Events struct {
+	BufferSize int `yaml:"buffer_size" env:"EVENTS_BUFFER_SIZE"`
+}
+
+type Idempotency struct {
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL"`
+}
+
+// Token bucket на HTTP-запросы: rps - скорость восполнения (0 - лимит выключен), burst - сколько запросов можно подряд.
+// Лимит IP действует на все запросы, лимит токена - дополнительно на запросы с Authorization
+type RateLimit struct {
+	IPRPS      float64 `yaml:"ip_rps" env:"RATE_LIMIT_IP_RPS"`
+	IPBurst    int     `yaml:"ip_burst" env:"RATE_LIMIT_IP_BURST"`
+	TokenRPS   float64 `yaml:"token_rps" env:"RATE_LIMIT_TOKEN_RPS"`
+	TokenBurst int     `yaml:"token_burst" env:"RATE_LIMIT_TOKEN_BURST"`
+}
+
+// кэш чтений для /team/get и /stats/reviewers, 0 - без кэша
+type Cache struct {
+	TTL time.Duration `yaml:"ttl" env:"CACHE_TTL"`
+}
+
+// Default - значения по умолчанию, те же, что были зашиты в main.go
+func Default() Config {
+	return Config{
+		LogLevel: "info",
+		HTTP: HTTP{
+			ReadTimeout:     5 * time.Second,
+			WriteTimeout:    5 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 10 * time.Second,
+			MaxBodyBytes:    1 << 20,
+		},
+		DB: DB{
+			ConnectWait:            time.Minute,
+			StatementTimeout:       30 * time.Second,
+			ExportStatementTimeout: 10 * time.Minute,
+			TxMaxRetries:           3,
+			ReplicaMaxLag:          10 * time.Second,
+			ReplicaCheckInterval:   5 * time.Second,
+		},
+		Reviews: Reviews{
+			StaleSLA:           48 * time.Hour,
+			StaleEscalateAfter: 96 * time.Hour,
+			ArchiveInterval:    time.Hour,
+		},
+		Notify: Notify{
+			Timeout:             5 * time.Second,
+			FallbackWebhookKind: dto.WebhookKindSlack,
+		},
+		Events:      Events{BufferSize: 1000},
+		Idempotency: Idempotency{TTL: 24 * time.Hour},
+		Cache:       Cache{TTL: 10 * time.Second},
+		RateLimit: RateLimit{
+			IPRPS:      20,
+			IPBurst:    40,
+			TokenRPS:   10,
+			TokenBurst: 20,
+		},
+	}
+}
+
+// Load собирает конфигурацию из умолчаний, файла path (пустой - без файла) и окружения и проверяет ее
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+		if err := decodeYAML(data, &cfg); err != nil {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	// ошибки окружения и проверки отдаем вместе, чтобы чинить все за один запуск
+	envErr := applyEnv(reflect.ValueOf(&cfg).Elem(), os.LookupEnv)
+	if err := errors.Join(envErr, cfg.Validate()); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// неизвестные ключи - ошибка, чтобы опечатка в файле не превращалась в молча проигнорированную настройку
+func decodeYAML(data []byte, cfg *Config) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// проходим по полям с тегом env и перекрываем их заданными переменными окружения
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	var errs []error
+
+	for i := 0; i < v.NumField(); i++ {
+		field, sf := v.Field(i), v.Type().Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, lookup); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		name := sf.Tag.Get("env")
+		if name == "" {
+			continue
+		}
+		raw, ok := lookup(name)
+		if !ok || raw == "" {
+			continue
+		}
+
+		if err := setField(field, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch {
+	case field.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q, use e.g. 5s or 1h", raw)
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Type() == reflect.TypeOf([]string(nil)):
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	case field.Kind() == reflect.Int || field.Kind() == reflect.Int32 || field.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetInt(n)
+	case field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
+
+// Validate проверяет все настройки разом и возвращает все найденные ошибки
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, msg string) {
+		if !ok {
+			errs = append(errs, errors.New(msg))
+		}
+	}
+
+	check(c.DSN != "", "dsn is required")
+	check(c.Port != "", "port is required")
+	check(c.Auth.AdminToken != "", "auth.admin_token is required")
+
+	if _, err := ParseLogLevel(c.LogLevel); err != nil {
+		errs = append(errs, err)
+	}
+
+	check(c.HTTP.ReadTimeout > 0, "http.read_timeout must be positive")
+	check(c.HTTP.WriteTimeout > 0, "http.write_timeout must be positive")
+	check(c.HTTP.IdleTimeout > 0, "http.idle_timeout must be positive")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout must be positive")
+	check(c.HTTP.MaxBodyBytes >= 0, "http.max_body_bytes must be >= 0")
+
+	check(c.DB.MaxConns >= 0 && c.DB.MinConns >= 0, "db.max_conns and db.min_conns must be >= 0")
+	check(c.DB.MaxConns == 0 || c.DB.MinConns <= c.DB.MaxConns, "db.min_conns must not exceed db.max_conns")
+	check(c.DB.MaxConnLifetime >= 0 && c.DB.MaxConnIdleTime >= 0, "db connection lifetimes must be >= 0")
+	check(c.DB.ConnectWait >= 0, "db.connect_wait must be >= 0")
+	check(c.DB.StatementTimeout >= 0 && c.DB.ExportStatementTimeout >= 0, "db statement timeouts must be >= 0")
+	check(c.DB.TxMaxRetries >= 0 && c.DB.TxMaxRetries <= 10, "db.tx_max_retries must be between 0 and 10")
+	check(c.DB.ReplicaMaxLag >= 0, "db.replica_max_lag must be >= 0")
+	check(len(c.DB.ReplicaDSNs) == 0 || c.DB.ReplicaCheckInterval > 0, "db.replica_check_interval must be positive")
+	for i, dsn := range c.DB.ReplicaDSNs {
+		check(dsn != "", fmt.Sprintf("db.replica_dsns[%d] is empty", i))
+	}
+
+	check(c.Reviews.StaleSLA > 0, "reviews.stale_sla must be positive")
+	check(c.Reviews.StaleEscalateAfter > c.Reviews.StaleSLA, "reviews.stale_escalate_after must be greater than reviews.stale_sla")
+	check(c.Reviews.StaleInterval >= 0 && c.Reviews.AbsenceInterval >= 0 && c.Reviews.ReconcileInterval >= 0,
+		"reviews job intervals must be >= 0")
+	check(c.Reviews.ArchiveAfterDays >= 0, "reviews.archive_after_days must be >= 0")
+	check(c.Reviews.ArchiveAfterDays == 0 || c.Reviews.ArchiveInterval > 0, "reviews.archive_interval must be positive")
+
+	check(c.Notify.Timeout > 0, "notify.timeout must be positive")
+	switch c.Notify.FallbackWebhookKind {
+	case dto.WebhookKindSlack, dto.WebhookKindMattermost:
+	default:
+		errs = append(errs, errors.New("notify.fallback_webhook_kind must be slack or mattermost"))
+	}
+	if c.Notify.FallbackWebhookURL != "" {
+		check(strings.HasPrefix(c.Notify.FallbackWebhookURL, "http://") || strings.HasPrefix(c.Notify.FallbackWebhookURL, "https://"),
+			"notify.fallback_webhook_url must be an http(s) URL")
+	}
+
+	check(c.Events.BufferSize >= 0, "events.buffer_size must be >= 0")
+	check(c.Idempotency.TTL > 0, "idempotency.ttl must be positive")
+	check(c.Cache.TTL >= 0, "cache.ttl must be >= 0")
+	check(c.RateLimit.IPRPS >= 0 && c.RateLimit.TokenRPS >= 0, "rate_limit rps must be >= 0")
+	check(c.RateLimit.IPRPS == 0 || c.RateLimit.IPBurst >= 1, "rate_limit.ip_burst must be at least 1")
+	check(c.RateLimit.TokenRPS == 0 || c.RateLimit.TokenBurst >= 1, "rate_limit.token_burst must be at least 1")
+
+	return errors.Join(errs...)
+}
+
+// ParseLogLevel переводит log_level в уровень slog
+func ParseLogLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("log_level must be debug, info, warn or error: %q", s)
+	}
+	return l, nil
+}
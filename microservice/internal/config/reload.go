@@ -0,0 +1,39 @@
+package config
+
+import "reflect"
+
+// WithReloadable - текущая конфигурация, в которой настройки, применяемые на лету, взяты из next:
+// уровень логов, admin-токен, пороги SLA ревью и запасной webhook уведомлений
+func (c Config) WithReloadable(next Config) Config {
+	c.LogLevel = next.LogLevel
+	c.Auth = next.Auth
+	c.Reviews.StaleSLA = next.Reviews.StaleSLA
+	c.Reviews.StaleEscalateAfter = next.Reviews.StaleEscalateAfter
+	c.Notify.FallbackWebhookURL = next.Notify.FallbackWebhookURL
+	c.Notify.FallbackWebhookKind = next.Notify.FallbackWebhookKind
+	return c
+}
+
+// StructuralChanges - пути (как в YAML) изменившихся настроек, которые применяются только после перезапуска
+func StructuralChanges(cur, next Config) []string {
+	return diff(reflect.ValueOf(cur.WithReloadable(next)), reflect.ValueOf(next), "")
+}
+
+func diff(a, b reflect.Value, prefix string) []string {
+	var changed []string
+
+	for i := 0; i < a.NumField(); i++ {
+		sf := a.Type().Field(i)
+		path := prefix + sf.Tag.Get("yaml")
+
+		fa, fb := a.Field(i), b.Field(i)
+		if fa.Kind() == reflect.Struct {
+			changed = append(changed, diff(fa, fb, path+".")...)
+			continue
+		}
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			changed = append(changed, path)
+		}
+	}
+	return changed
+}
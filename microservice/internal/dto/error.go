@@ -1,7 +1,15 @@
 package dto
 
 type ErrorBody struct {
-	Code    string `json:"code"`
+	Code    string        `json:"code"`
+	Message string        `json:"message"`
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail - ошибка конкретного поля запроса или строки загружаемого файла
+type ErrorDetail struct {
+	Field   string `json:"field,omitempty"`
+	Line    int    `json:"line,omitempty"`
 	Message string `json:"message"`
 }
 
@@ -16,5 +24,25 @@ const (
 	ErrorCodePRMerged    = "PR_MERGED"
 	ErrorCodeNotAssigned = "NOT_ASSIGNED"
 	ErrorCodeNoCandidate = "NO_CANDIDATE"
+	ErrorCodeAtCapacity  = "AT_CAPACITY"
 	ErrorCodeNotFound    = "NOT_FOUND"
+	ErrorCodeTeamCycle   = "TEAM_CYCLE"
+	ErrorCodeUserDeleted = "USER_DELETED"
+
+	ErrorCodeValidationFailed = "VALIDATION_FAILED"
+	ErrorCodeUnauthorized     = "UNAUTHORIZED"
+	ErrorCodeInternal         = "INTERNAL"
+
+	ErrorCodeAlreadyAssigned  = "ALREADY_ASSIGNED"
+	ErrorCodeReviewerIsAuthor = "REVIEWER_IS_AUTHOR"
+	ErrorCodeUserInactive     = "USER_INACTIVE"
+	ErrorCodeUserAbsent       = "USER_ABSENT"
+
+	ErrorCodeVersionConflict = "VERSION_CONFLICT"
+
+	ErrorCodeIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	ErrorCodeIdempotencyInProgress = "IDEMPOTENCY_IN_PROGRESS"
+
+	ErrorCodeRateLimited  = "RATE_LIMITED"
+	ErrorCodeBodyTooLarge = "BODY_TOO_LARGE"
 )
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+// Типы событий /events/stream
+const (
+	EventPrCreated        = "pr.created"
+	EventPrMerged         = "pr.merged"
+	EventReviewerAssigned = "reviewer.assigned"
+	EventReviewerReplaced = "reviewer.replaced"
+	EventReviewerRemoved  = "reviewer.removed"
+	EventUserDeactivated  = "user.deactivated"
+	EventUserDeleted      = "user.deleted"
+	EventReviewReminder   = "review.reminder"
+	EventReviewEscalated  = "review.escalated"
+)
+
+// Event - событие об изменении назначений, уходит в SSE как data
+type Event struct {
+	ID         uint64    `json:"id"`
+	Type       string    `json:"type"`
+	OccurredAt time.Time `json:"occurred_at"`
+
+	// команда автора PR или деактивированного (удаленного) пользователя
+	TeamName string `json:"team_name,omitempty"`
+
+	PullRequestID string `json:"pull_request_id,omitempty"`
+	AuthorID      string `json:"author_id,omitempty"`
+
+	// ревьювер (назначенный, снятый, новый при замене, зависший) или деактивированный пользователь
+	UserID string `json:"user_id,omitempty"`
+	// заменяемый ревьювер в reviewer.replaced
+	OldUserID string `json:"old_user_id,omitempty"`
+	// лид, которому эскалировано зависшее ревью
+	EscalatedTo string `json:"escalated_to,omitempty"`
+
+	// состояние PR после изменения
+	PR *PullRequest `json:"pr,omitempty"`
+}
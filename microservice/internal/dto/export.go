@@ -0,0 +1,29 @@
+package dto
+
+/* /export/* */
+
+// фильтры выгрузки PR, пустые поля не фильтруют
+type ExportPrFilter struct {
+	Status     string
+	TeamName   string
+	AuthorID   string
+	ReviewerID string
+
+	IncludeArchived bool
+}
+
+type PullRequestExportRow struct {
+	PullRequest
+	TeamName string `json:"team_name"`
+}
+
+type TeamMemberExportRow struct {
+	TeamName string `json:"team_name"`
+	TeamMember
+}
+
+type ReviewerStatExportRow struct {
+	UserID       string `json:"user_id"`
+	Username     string `json:"username"`
+	ReviewsCount int    `json:"reviews_count"`
+}
@@ -0,0 +1,23 @@
+package dto
+
+/* /health/ready */
+
+// ok - все в порядке, degraded - часть реплик вне ротации (чтения идут на основную БД),
+// unavailable - основная БД не отвечает
+const (
+	ReadinessOK          = "ok"
+	ReadinessDegraded    = "degraded"
+	ReadinessUnavailable = "unavailable"
+)
+
+// lag_seconds - отставание на момент последней проверки, in_rotation - идут ли на реплику чтения
+type ReplicaHealth struct {
+	Name       string  `json:"name"`
+	InRotation bool    `json:"in_rotation"`
+	LagSeconds float64 `json:"lag_seconds"`
+}
+
+type ReadinessResponse struct {
+	Status   string          `json:"status"`
+	Replicas []ReplicaHealth `json:"replicas"`
+}
@@ -15,6 +15,10 @@ type PullRequest struct {
 	AuthorID          string            `json:"author_id"`
 	Status            PullRequestStatus `json:"status"`
 	AssignedReviewers []string          `json:"assigned_reviewers"`
+	NeedMoreReviewers bool              `json:"need_more_reviewers"`
+
+	// растет при каждом изменении PR, отдается также в заголовке ETag
+	Version int64 `json:"version"`
 
 	CreatedAt *time.Time `json:"createdAt,omitempty"`
 	MergedAt  *time.Time `json:"mergedAt,omitempty"`
@@ -25,6 +29,10 @@ type PullRequestShort struct {
 	PullRequestName string            `json:"pull_request_name"`
 	AuthorID        string            `json:"author_id"`
 	Status          PullRequestStatus `json:"status"`
+
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// остальные назначенные ревьюверы PR
+	OtherReviewers []string `json:"other_reviewers"`
 }
 
 /* /pullRequest/create */
@@ -33,16 +41,39 @@ type CreatePrRequest struct {
 	PullRequestID   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
 	AuthorID        string `json:"author_id"`
+
+	// измененные файлы, по ним ревьюверы сначала ищутся среди владельцев кода
+	ChangedFiles []string `json:"changed_files,omitempty"`
+}
+
+// Источники выбора ревьювера
+const (
+	ReviewerSourceCodeowners = "codeowners"
+	ReviewerSourceTeam       = "team"
+	ReviewerSourceLead       = "team_lead"
+)
+
+// почему выбран ревьювер: по правилу CODEOWNERS, случайно из команды автора или лид как последний кандидат
+type ReviewerReason struct {
+	UserID string `json:"user_id"`
+	Source string `json:"source"`
+	Rule   string `json:"rule,omitempty"`
 }
 
 type CreatePrResponse struct {
-	PR PullRequest `json:"pr"`
+	PR              PullRequest      `json:"pr"`
+	ReviewerReasons []ReviewerReason `json:"reviewer_reasons"`
+	// ревьюверов меньше двух, потому что остальные кандидаты уже набрали лимит открытых ревью
+	LimitedByCapacity bool `json:"limited_by_capacity"`
 }
 
 /* /pullRequest/merge */
 
 type MergePrRequest struct {
 	PullRequestID string `json:"pull_request_id"`
+
+	// версия из If-Match, nil - без проверки
+	ExpectedVersion *int64 `json:"-"`
 }
 
 type MergePullRequestResponse struct {
@@ -54,9 +85,33 @@ type MergePullRequestResponse struct {
 type ReassignPrRequest struct {
 	PullRequestID string `json:"pull_request_id"`
 	OldUserID     string `json:"old_user_id"`
+
+	// если задан - назначаем именно этого пользователя вместо случайного
+	NewUserID string `json:"new_user_id,omitempty"`
+
+	// версия из If-Match, nil - без проверки
+	ExpectedVersion *int64 `json:"-"`
 }
 
 type ReassignPrResponse struct {
 	PR         PullRequest `json:"pr"`
 	ReplacedBy string      `json:"replaced_by"`
 }
+
+/* /pullRequest/addReviewer, /pullRequest/removeReviewer */
+
+type ReviewerChangeRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+
+	// версия из If-Match, nil - без проверки
+	ExpectedVersion *int64 `json:"-"`
+}
+
+type AddReviewerRequest = ReviewerChangeRequest
+
+type RemoveReviewerRequest = ReviewerChangeRequest
+
+type ReviewerChangeResponse struct {
+	PR PullRequest `json:"pr"`
+}
@@ -0,0 +1,40 @@
+package dto
+
+/* /admin/reconcile */
+
+// Что не так с открытым PR
+const (
+	ReconcileIssueInactive     = "inactive_reviewer"
+	ReconcileIssueMovedTeam    = "reviewer_moved_team"
+	ReconcileIssueUnderstaffed = "understaffed"
+)
+
+// Что сделано (или при dry_run было бы сделано) с ревьюверами PR
+const (
+	ReconcileActionReassigned = "reassigned"
+	ReconcileActionRemoved    = "removed"
+	ReconcileActionAdded      = "added"
+)
+
+// при dry_run у added нет reviewer_id, а у reassigned - replaced_by: кандидаты выбираются случайно в момент ремонта
+type ReconcileAction struct {
+	Action     string `json:"action"`
+	ReviewerID string `json:"reviewer_id,omitempty"`
+	ReplacedBy string `json:"replaced_by,omitempty"`
+}
+
+// still_understaffed - после ремонта ревьюверов все равно меньше нужного, кандидатов не нашлось
+type ReconcilePr struct {
+	PullRequestID     string            `json:"pull_request_id"`
+	TeamName          string            `json:"team_name"`
+	Issues            []string          `json:"issues"`
+	Actions           []ReconcileAction `json:"actions"`
+	StillUnderstaffed bool              `json:"still_understaffed"`
+}
+
+// Skipped - прогон не выполнялся, потому что его уже выполняет другая реплика
+type ReconcileReport struct {
+	DryRun       bool          `json:"dry_run"`
+	Skipped      bool          `json:"skipped"`
+	PullRequests []ReconcilePr `json:"pull_requests"`
+}
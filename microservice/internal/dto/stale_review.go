@@ -0,0 +1,30 @@
+package dto
+
+import "time"
+
+/* /admin/runStaleReviews */
+
+// Что задача сделала с зависшим ревью
+const (
+	StaleActionReminded   = "reminded"
+	StaleActionReassigned = "reassigned"
+	StaleActionEscalated  = "escalated"
+)
+
+type StaleReviewAction struct {
+	PullRequestID string    `json:"pull_request_id"`
+	ReviewerID    string    `json:"reviewer_id"`
+	TeamName      string    `json:"team_name"`
+	AssignedAt    time.Time `json:"assigned_at"`
+	Action        string    `json:"action"`
+	// новый ревьювер при reassigned
+	ReplacedBy string `json:"replaced_by,omitempty"`
+	// лид, которому ушла эскалация
+	EscalatedTo string `json:"escalated_to,omitempty"`
+}
+
+// Skipped - прогон не выполнялся, потому что его уже выполняет другая реплика
+type StaleReviewReport struct {
+	Skipped bool                `json:"skipped"`
+	Actions []StaleReviewAction `json:"actions"`
+}
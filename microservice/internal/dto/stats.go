@@ -9,3 +9,39 @@ type ReviewerStat struct {
 type ReviewerStatsResponse struct {
 	Stats []ReviewerStat `json:"stats"`
 }
+
+type TeamPrStat struct {
+	TeamName    string `json:"team_name"`
+	OpenCount   int    `json:"open_count"`
+	MergedCount int    `json:"merged_count"`
+}
+
+type AuthorPrStat struct {
+	AuthorID    string `json:"author_id"`
+	Username    string `json:"username"`
+	OpenCount   int    `json:"open_count"`
+	MergedCount int    `json:"merged_count"`
+}
+
+// время от created_at до merged_at в секундах, null если смерженных PR еще нет
+type MergeTimeStat struct {
+	MedianSeconds *float64 `json:"median_seconds"`
+	P90Seconds    *float64 `json:"p90_seconds"`
+}
+
+type PrReassignStat struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReassignCount int    `json:"reassign_count"`
+}
+
+/* /stats/prs */
+type PrStatsResponse struct {
+	ByTeam        []TeamPrStat     `json:"by_team"`
+	ByAuthor      []AuthorPrStat   `json:"by_author"`
+	MergeTime     MergeTimeStat    `json:"merge_time"`
+	Reassignments []PrReassignStat `json:"reassignments"`
+
+	TotalPrs int `json:"total_prs"`
+	// доля PR, созданных меньше чем с двумя ревьюверами
+	UnderstaffedShare float64 `json:"understaffed_share"`
+}
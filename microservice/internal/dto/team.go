@@ -4,11 +4,25 @@ type TeamMember struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
+
+	// лимит открытых ревью пользователя, если не задан - действует лимит команды
+	MaxOpenReviews *int `json:"max_open_reviews,omitempty"`
+	// текущая загрузка, заполняется только в /team/get
+	OpenReviews *int `json:"open_reviews,omitempty"`
 }
 
 type Team struct {
-	TeamName string       `json:"team_name"`
-	Members  []TeamMember `json:"members"`
+	TeamName              string       `json:"team_name"`
+	DefaultMaxOpenReviews *int         `json:"default_max_open_reviews,omitempty"`
+	ReviewSlaHours        *int         `json:"review_sla_hours,omitempty"`
+	EscalationHours       *int         `json:"escalation_hours,omitempty"`
+	LeadUserID            string       `json:"lead_user_id,omitempty"`
+	ParentTeam            string       `json:"parent_team,omitempty"`
+	Members               []TeamMember `json:"members"`
+
+	// родительские команды от ближайшей к корню и дочерние команды, заполняются только в /team/get
+	Ancestors  []string `json:"ancestors,omitempty"`
+	ChildTeams []string `json:"child_teams,omitempty"`
 }
 
 /* /team/add */
@@ -21,3 +35,94 @@ type TeamAddResponse struct {
 
 /* /team/get */
 type TeamGetResponse = Team
+
+/* /team/setDefaultCapacity */
+type SetTeamCapacityRequest struct {
+	TeamName              string `json:"team_name"`
+	DefaultMaxOpenReviews *int   `json:"default_max_open_reviews"`
+}
+
+/* /team/setReviewSla */
+
+// пороги зависшего ревью в часах, nil - значения по умолчанию сервиса
+type SetTeamReviewSlaRequest struct {
+	TeamName        string `json:"team_name"`
+	ReviewSlaHours  *int   `json:"review_sla_hours"`
+	EscalationHours *int   `json:"escalation_hours"`
+}
+
+/* /team/setLead */
+
+// пустой user_id снимает лида команды
+type SetTeamLeadRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+}
+
+// LeadToken выдается один раз при назначении лида, прежний токен команды перестает действовать
+type SetTeamLeadResponse struct {
+	Team      Team   `json:"team"`
+	LeadToken string `json:"lead_token,omitempty"`
+}
+
+/* /team/setParent */
+
+// пустой parent_team делает команду корневой
+type SetTeamParentRequest struct {
+	TeamName   string `json:"team_name"`
+	ParentTeam string `json:"parent_team"`
+}
+
+/* /team/import */
+
+// строка ростера, Line - номер строки в исходном файле для сообщений об ошибках
+type RosterEntry struct {
+	Line     int
+	TeamName string
+	UserID   string
+	Username string
+	IsActive bool
+}
+
+type ImportRosterResponse struct {
+	DryRun         bool     `json:"dry_run"`
+	TeamsCreated   []string `json:"teams_created"`
+	TeamsUpdated   []string `json:"teams_updated"`
+	UsersCreated   []string `json:"users_created"`
+	UsersUpdated   []string `json:"users_updated"`
+	UsersUnchanged int      `json:"users_unchanged"`
+}
+
+/* /team/setCodeowners, /team/getCodeowners */
+
+type CodeownersRule struct {
+	Line    int      `json:"line"`
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+type CodeownersResponse struct {
+	TeamName string           `json:"team_name"`
+	Rules    []CodeownersRule `json:"rules"`
+}
+
+/* /team/setWebhook */
+
+// Типы webhook'ов чата
+const (
+	WebhookKindSlack      = "slack"
+	WebhookKindMattermost = "mattermost"
+)
+
+// пустой url снимает webhook команды
+type SetTeamWebhookRequest struct {
+	TeamName string `json:"team_name"`
+	URL      string `json:"url"`
+	Kind     string `json:"kind,omitempty"`
+}
+
+type TeamWebhook struct {
+	TeamName string `json:"team_name"`
+	URL      string `json:"url,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+}
@@ -1,10 +1,13 @@
 package dto
 
+import "time"
+
 type User struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	UserID         string `json:"user_id"`
+	Username       string `json:"username"`
+	TeamName       string `json:"team_name"`
+	IsActive       bool   `json:"is_active"`
+	MaxOpenReviews *int   `json:"max_open_reviews,omitempty"`
 }
 
 /* /users/setIsActive */
@@ -17,8 +20,110 @@ type SetUserIsActiveResponse struct {
 	User User `json:"user"`
 }
 
+/* /users/setCapacity */
+type SetUserCapacityRequest struct {
+	UserID         string `json:"user_id"`
+	MaxOpenReviews *int   `json:"max_open_reviews"`
+}
+
+type SetUserCapacityResponse struct {
+	User User `json:"user"`
+}
+
+/* /users/delete */
+type DeleteUserRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// открытые ревью удаленного пользователя: переназначенные и те, где заменить было некем
+type DeleteUserResponse struct {
+	UserID         string    `json:"user_id"`
+	DeletedAt      time.Time `json:"deleted_at"`
+	ReassignedPrs  []string  `json:"reassigned_prs"`
+	RemovedFromPrs []string  `json:"removed_from_prs"`
+}
+
+/* /users/restore */
+type RestoreUserRequest struct {
+	UserID string `json:"user_id"`
+}
+
+type RestoreUserResponse struct {
+	User User `json:"user"`
+}
+
 /* /users/getReview */
+
+// максимальный размер страницы /users/getReview; без limit отдаются все PR, как до пагинации
+const MaxReviewPageSize = 200
+
+// Statuses пустые - любые статусы, Limit 0 - все PR одной страницей,
+// Cursor - next_cursor из предыдущего ответа
+type UserGetReviewRequest struct {
+	UserID          string
+	Statuses        []PullRequestStatus
+	IncludeArchived bool
+	Limit           int
+	Cursor          string
+}
+
+// Total - сколько всего PR под фильтр, next_cursor пустой на последней странице
 type UserGetReviewResponse struct {
-	UserID       string             `json:"user_id"`
-	PullRequests []PullRequestShort `json:"pull_requests"`
+	UserID           string             `json:"user_id"`
+	PullRequests     []PullRequestShort `json:"pull_requests"`
+	Total            int                `json:"total"`
+	NextCursor       string             `json:"next_cursor,omitempty"`
+	UpcomingAbsences []Absence          `json:"upcoming_absences"`
+}
+
+/* /users/getAuthored */
+
+// ревьювер PR глазами автора
+type AuthoredReviewer struct {
+	UserID     string    `json:"user_id"`
+	Username   string    `json:"username"`
+	TeamName   string    `json:"team_name"`
+	IsActive   bool      `json:"is_active"`
+	IsAbsent   bool      `json:"is_absent"`
+	MovedTeam  bool      `json:"moved_team"`
+	AssignedAt time.Time `json:"assigned_at"`
+}
+
+// needs_reassign - на открытом PR есть выключенный или сменивший команду ревьювер,
+// автору стоит запросить переназначение
+type AuthoredPullRequest struct {
+	PR            PullRequest        `json:"pr"`
+	Reviewers     []AuthoredReviewer `json:"reviewers"`
+	NeedsReassign bool               `json:"needs_reassign"`
+}
+
+type UserGetAuthoredResponse struct {
+	UserID             string                `json:"user_id"`
+	PullRequests       []AuthoredPullRequest `json:"pull_requests"`
+	NeedsReassignCount int                   `json:"needs_reassign_count"`
+}
+
+type Absence struct {
+	AbsenceID int       `json:"absence_id"`
+	UserID    string    `json:"user_id"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+	Reason    string    `json:"reason"`
+}
+
+/* /users/addAbsence */
+type AddAbsenceRequest struct {
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+type AddAbsenceResponse struct {
+	Absence Absence `json:"absence"`
+}
+
+/* /users/removeAbsence */
+type RemoveAbsenceRequest struct {
+	AbsenceID int `json:"absence_id"`
 }
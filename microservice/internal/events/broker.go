@@ -0,0 +1,147 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"review-manager/internal/dto"
+)
+
+// Publisher - то, во что сервисы отдают события после коммита
+type Publisher interface {
+	Publish(e dto.Event)
+}
+
+// Filter - подписка только на события команды и/или пользователя, пустые поля не фильтруют
+type Filter struct {
+	TeamName string
+	UserID   string
+}
+
+func (f Filter) Match(e dto.Event) bool {
+	if f.TeamName != "" && e.TeamName != f.TeamName {
+		return false
+	}
+	if f.UserID == "" {
+		return true
+	}
+	if e.UserID == f.UserID || e.OldUserID == f.UserID || e.AuthorID == f.UserID {
+		return true
+	}
+	if e.PR != nil {
+		for _, id := range e.PR.AssignedReviewers {
+			if id == f.UserID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// размер канала подписчика: кто не успевает читать, того отключаем,
+// он переподключится с Last-Event-ID и дочитает из буфера
+const subscriberBuffer = 64
+
+// Broker хранит последние события в кольцевом буфере в памяти и раздает их подписчикам
+type Broker struct {
+	mu     sync.Mutex
+	buf    []dto.Event
+	start  int
+	size   int
+	nextID uint64
+	subs   map[*Subscription]struct{}
+}
+
+func NewBroker(size int) *Broker {
+	return &Broker{
+		buf: make([]dto.Event, size),
+		// id начинаются с текущего времени, чтобы после рестарта они были больше старых
+		// и клиент с Last-Event-ID из прошлого запуска получил весь новый буфер
+		nextID: uint64(time.Now().UnixMilli()) * 1000,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+type Subscription struct {
+	C      <-chan dto.Event
+	ch     chan dto.Event
+	filter Filter
+	broker *Broker
+}
+
+// Close отписывает и закрывает канал, повторный вызов безопасен
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+	s.broker.remove(s)
+}
+
+func (b *Broker) Publish(e dto.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	e.ID = b.nextID
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now().UTC()
+	}
+
+	if len(b.buf) > 0 {
+		if b.size < len(b.buf) {
+			b.buf[(b.start+b.size)%len(b.buf)] = e
+			b.size++
+		} else {
+			b.buf[b.start] = e
+			b.start = (b.start + 1) % len(b.buf)
+		}
+	}
+
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			b.remove(s)
+		}
+	}
+}
+
+// LastID - id последнего опубликованного события, с него удобно начинать подписку без истории
+func (b *Broker) LastID() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextID
+}
+
+// Subscribe возвращает события из буфера с id > lastID и подписку на новые.
+// Если lastID старше буфера, часть событий уже потеряна - отдаем все, что осталось
+func (b *Broker) Subscribe(lastID uint64, f Filter) ([]dto.Event, *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []dto.Event
+	if lastID > 0 {
+		for i := 0; i < b.size; i++ {
+			e := b.buf[(b.start+i)%len(b.buf)]
+			if e.ID > lastID && f.Match(e) {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	ch := make(chan dto.Event, subscriberBuffer)
+	s := &Subscription{C: ch, ch: ch, filter: f, broker: b}
+	b.subs[s] = struct{}{}
+
+	return backlog, s
+}
+
+func (b *Broker) remove(s *Subscription) {
+	if _, ok := b.subs[s]; !ok {
+		return
+	}
+	delete(b.subs, s)
+	close(s.ch)
+}
@@ -0,0 +1,100 @@
+package grpcapi
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/grpcapi/pb"
+)
+
+// конвертация между pb-сообщениями и dto, чтобы переиспользовать валидацию и сервисы как есть
+
+func int32Ptr(v *int) *int32 {
+	if v == nil {
+		return nil
+	}
+	n := int32(*v)
+	return &n
+}
+
+func intPtr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	n := int(*v)
+	return &n
+}
+
+func timestampPtr(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func teamFromPB(t *pb.Team) dto.Team {
+	if t == nil {
+		return dto.Team{}
+	}
+
+	members := make([]dto.TeamMember, 0, len(t.GetMembers()))
+	for _, m := range t.GetMembers() {
+		members = append(members, dto.TeamMember{
+			UserID:         m.GetUserId(),
+			Username:       m.GetUsername(),
+			IsActive:       m.GetIsActive(),
+			MaxOpenReviews: intPtr(m.MaxOpenReviews),
+		})
+	}
+
+	return dto.Team{
+		TeamName:              t.GetTeamName(),
+		DefaultMaxOpenReviews: intPtr(t.DefaultMaxOpenReviews),
+		Members:               members,
+	}
+}
+
+func teamToPB(t dto.Team) *pb.Team {
+	members := make([]*pb.TeamMember, 0, len(t.Members))
+	for _, m := range t.Members {
+		members = append(members, &pb.TeamMember{
+			UserId:         m.UserID,
+			Username:       m.Username,
+			IsActive:       m.IsActive,
+			MaxOpenReviews: int32Ptr(m.MaxOpenReviews),
+			OpenReviews:    int32Ptr(m.OpenReviews),
+		})
+	}
+
+	return &pb.Team{
+		TeamName:              t.TeamName,
+		DefaultMaxOpenReviews: int32Ptr(t.DefaultMaxOpenReviews),
+		Members:               members,
+	}
+}
+
+func userToPB(u dto.User) *pb.User {
+	return &pb.User{
+		UserId:         u.UserID,
+		Username:       u.Username,
+		TeamName:       u.TeamName,
+		IsActive:       u.IsActive,
+		MaxOpenReviews: int32Ptr(u.MaxOpenReviews),
+	}
+}
+
+func prToPB(pr dto.PullRequest) *pb.PullRequest {
+	return &pb.PullRequest{
+		PullRequestId:     pr.PullRequestID,
+		PullRequestName:   pr.PullRequestName,
+		AuthorId:          pr.AuthorID,
+		Status:            string(pr.Status),
+		AssignedReviewers: pr.AssignedReviewers,
+		NeedMoreReviewers: pr.NeedMoreReviewers,
+		Version:           pr.Version,
+		CreatedAt:         timestampPtr(pr.CreatedAt),
+		MergedAt:          timestampPtr(pr.MergedAt),
+	}
+}
@@ -0,0 +1,96 @@
+package grpcapi
+
+import (
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/repository"
+	"review-manager/internal/validation"
+)
+
+const errorDomain = "review-manager"
+
+// toStatus переводит ошибки сервисов в gRPC-статус по тем же правилам, что и HTTP-хэндлеры:
+// код ответа HTTP -> gRPC code, а dto-код ошибки кладется в ErrorInfo.Reason
+func toStatus(err error) error {
+	var (
+		code    codes.Code
+		errCode string
+	)
+
+	switch {
+	case errors.Is(err, repository.ErrTeamExists):
+		code, errCode = codes.AlreadyExists, dto.ErrorCodeTeamExists
+	case errors.Is(err, repository.ErrPRExists):
+		code, errCode = codes.AlreadyExists, dto.ErrorCodePRExists
+	case errors.Is(err, repository.ErrUserDeleted):
+		code, errCode = codes.FailedPrecondition, dto.ErrorCodeUserDeleted
+
+	case errors.Is(err, repository.ErrTeamNotFound),
+		errors.Is(err, repository.ErrUserNotFound),
+		errors.Is(err, repository.ErrPRNotFound):
+		code, errCode = codes.NotFound, dto.ErrorCodeNotFound
+
+	case errors.Is(err, repository.ErrPRMerged):
+		code, errCode = codes.FailedPrecondition, dto.ErrorCodePRMerged
+	case errors.Is(err, repository.ErrReviewerNotSet):
+		code, errCode = codes.FailedPrecondition, dto.ErrorCodeNotAssigned
+	// ErrAtCapacity оборачивает ErrNoCandidate, поэтому проверяется раньше
+	case errors.Is(err, repository.ErrAtCapacity),
+		errors.Is(err, repository.ErrUserAtCapacity):
+		code, errCode = codes.ResourceExhausted, dto.ErrorCodeAtCapacity
+	case errors.Is(err, repository.ErrNoCandidate):
+		code, errCode = codes.FailedPrecondition, dto.ErrorCodeNoCandidate
+	case errors.Is(err, repository.ErrAlreadyReviewer):
+		code, errCode = codes.FailedPrecondition, dto.ErrorCodeAlreadyAssigned
+	case errors.Is(err, repository.ErrReviewerAuthor):
+		code, errCode = codes.FailedPrecondition, dto.ErrorCodeReviewerIsAuthor
+	case errors.Is(err, repository.ErrUserInactive):
+		code, errCode = codes.FailedPrecondition, dto.ErrorCodeUserInactive
+	case errors.Is(err, repository.ErrUserAbsent):
+		code, errCode = codes.FailedPrecondition, dto.ErrorCodeUserAbsent
+
+	case errors.Is(err, repository.ErrVersionConflict):
+		code, errCode = codes.Aborted, dto.ErrorCodeVersionConflict
+	case errors.Is(err, repository.ErrTeamCycle):
+		code, errCode = codes.FailedPrecondition, dto.ErrorCodeTeamCycle
+
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+
+	return withReason(code, errCode, err.Error())
+}
+
+// ошибка валидации запроса - аналог 400 в HTTP, ошибки полей уходят в BadRequest.FieldViolations
+func invalidArgument(err error) error {
+	st := status.New(codes.InvalidArgument, err.Error())
+
+	var fieldErrs validation.FieldErrors
+	details := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: dto.ErrorCodeValidationFailed, Domain: errorDomain}}
+	if errors.As(err, &fieldErrs) {
+		br := &errdetails.BadRequest{}
+		for _, fe := range fieldErrs {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Msg})
+		}
+		details = append(details, br)
+	}
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+func withReason(code codes.Code, reason, msg string) error {
+	st := status.New(code, msg)
+	if withInfo, err := st.WithDetails(&errdetails.ErrorInfo{Reason: reason, Domain: errorDomain}); err == nil {
+		st = withInfo
+	}
+	return st.Err()
+}
@@ -0,0 +1,126 @@
+package grpcapi
+
+import (
+	"context"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/grpcapi/pb"
+	"review-manager/internal/validation"
+)
+
+/* PullRequestService/Create */
+
+func (s *Server) Create(ctx context.Context, req *pb.CreatePullRequestRequest) (*pb.CreatePullRequestResponse, error) {
+	createReq := dto.CreatePrRequest{
+		PullRequestID:   req.GetPullRequestId(),
+		PullRequestName: req.GetPullRequestName(),
+		AuthorID:        req.GetAuthorId(),
+		ChangedFiles:    req.GetChangedFiles(),
+	}
+	if err := validation.ValidateCreatePR(createReq); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	resp, err := s.PrSvc.Create(ctx, createReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	reasons := make([]*pb.ReviewerReason, 0, len(resp.ReviewerReasons))
+	for _, r := range resp.ReviewerReasons {
+		reasons = append(reasons, &pb.ReviewerReason{
+			UserId: r.UserID,
+			Source: r.Source,
+			Rule:   r.Rule,
+		})
+	}
+
+	return &pb.CreatePullRequestResponse{
+		Pr:                prToPB(resp.PR),
+		ReviewerReasons:   reasons,
+		LimitedByCapacity: resp.LimitedByCapacity,
+	}, nil
+}
+
+/* PullRequestService/Merge */
+
+func (s *Server) Merge(ctx context.Context, req *pb.MergePullRequestRequest) (*pb.PullRequestResponse, error) {
+	mergeReq := dto.MergePrRequest{
+		PullRequestID:   req.GetPullRequestId(),
+		ExpectedVersion: req.ExpectedVersion,
+	}
+	if err := validation.ValidateMergePR(mergeReq); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	resp, err := s.PrSvc.Merge(ctx, mergeReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.PullRequestResponse{Pr: prToPB(resp.PR)}, nil
+}
+
+/* PullRequestService/Reassign */
+
+func (s *Server) Reassign(ctx context.Context, req *pb.ReassignRequest) (*pb.ReassignResponse, error) {
+	reassignReq := dto.ReassignPrRequest{
+		PullRequestID:   req.GetPullRequestId(),
+		OldUserID:       req.GetOldUserId(),
+		NewUserID:       req.GetNewUserId(),
+		ExpectedVersion: req.ExpectedVersion,
+	}
+	if err := validation.ValidateReassignPR(reassignReq); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	resp, err := s.PrSvc.Reassign(ctx, reassignReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.ReassignResponse{
+		Pr:         prToPB(resp.PR),
+		ReplacedBy: resp.ReplacedBy,
+	}, nil
+}
+
+/* PullRequestService/AddReviewer */
+
+func (s *Server) AddReviewer(ctx context.Context, req *pb.ReviewerChangeRequest) (*pb.PullRequestResponse, error) {
+	changeReq := reviewerChangeFromPB(req)
+	if err := validation.ValidateReviewerChange(changeReq); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	resp, err := s.PrSvc.AddReviewer(ctx, changeReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.PullRequestResponse{Pr: prToPB(resp.PR)}, nil
+}
+
+/* PullRequestService/RemoveReviewer */
+
+func (s *Server) RemoveReviewer(ctx context.Context, req *pb.ReviewerChangeRequest) (*pb.PullRequestResponse, error) {
+	changeReq := reviewerChangeFromPB(req)
+	if err := validation.ValidateReviewerChange(changeReq); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	resp, err := s.PrSvc.RemoveReviewer(ctx, changeReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.PullRequestResponse{Pr: prToPB(resp.PR)}, nil
+}
+
+func reviewerChangeFromPB(req *pb.ReviewerChangeRequest) dto.ReviewerChangeRequest {
+	return dto.ReviewerChangeRequest{
+		PullRequestID:   req.GetPullRequestId(),
+		UserID:          req.GetUserId(),
+		ExpectedVersion: req.ExpectedVersion,
+	}
+}
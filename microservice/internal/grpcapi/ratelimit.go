@@ -0,0 +1,66 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"review-manager/internal/dto"
+)
+
+// Limiter - корзины лимитов по IP и токену, общие с HTTP API (httpapi.RateLimit)
+type Limiter interface {
+	Allow(ip, token string) (time.Duration, bool)
+}
+
+// rateLimitInterceptor отказывает с ResourceExhausted и RetryInfo, как HTTP отвечает 429 с Retry-After
+func rateLimitInterceptor(limiter Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		wait, ok := limiter.Allow(peerIP(ctx), bearerToken(ctx))
+		if ok {
+			return handler(ctx, req)
+		}
+
+		st := status.New(codes.ResourceExhausted, "too many requests, retry later")
+		if withDetails, err := st.WithDetails(
+			&errdetails.ErrorInfo{Reason: dto.ErrorCodeRateLimited, Domain: errorDomain},
+			&errdetails.RetryInfo{RetryDelay: durationpb.New(wait)},
+		); err == nil {
+			st = withDetails
+		}
+		return nil, st.Err()
+	}
+}
+
+// IP клиента по адресу соединения, как и в HTTP
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// токен из metadata authorization: Bearer <token>, пустая строка - токена нет
+func bearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,121 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"review-manager/internal/grpcapi/pb"
+	"review-manager/internal/repository"
+	"review-manager/internal/service"
+)
+
+// Server - gRPC-обертка над теми же сервисами, что использует HTTP API
+type Server struct {
+	pb.UnimplementedTeamServiceServer
+	pb.UnimplementedUserServiceServer
+	pb.UnimplementedPullRequestServiceServer
+	pb.UnimplementedStatsServiceServer
+
+	TeamSvc *service.TeamService
+	UserSvc *service.UserService
+	PrSvc   *service.PRService
+
+	// admin-токен меняется на лету при перечитывании конфигурации
+	adminToken atomic.Pointer[string]
+}
+
+func NewServer(
+	team *service.TeamService,
+	user *service.UserService,
+	pr *service.PRService,
+	admToken string,
+) *Server {
+	s := &Server{
+		TeamSvc: team,
+		UserSvc: user,
+		PrSvc:   pr,
+	}
+	s.SetAdminToken(admToken)
+	return s
+}
+
+// SetAdminToken заменяет admin-токен, вызовы в обработке дорабатывают со старым
+func (s *Server) SetAdminToken(token string) {
+	s.adminToken.Store(&token)
+}
+
+// проверка токена лида для конкретного запроса
+type leadCheck func(ctx context.Context, s *Server, req any, token string) (bool, error)
+
+// методы, которым, как и в HTTP, нужен admin-токен или токен лида команды, к которой относится запрос
+var adminMethods = map[string]leadCheck{
+	pb.TeamService_SetDefaultCapacity_FullMethodName: func(ctx context.Context, s *Server, req any, token string) (bool, error) {
+		return s.TeamSvc.IsLeadToken(ctx, req.(*pb.SetDefaultCapacityRequest).GetTeamName(), token)
+	},
+	pb.UserService_SetIsActive_FullMethodName: func(ctx context.Context, s *Server, req any, token string) (bool, error) {
+		return s.TeamSvc.IsUserLeadToken(ctx, req.(*pb.SetIsActiveRequest).GetUserId(), token)
+	},
+	pb.UserService_SetCapacity_FullMethodName: func(ctx context.Context, s *Server, req any, token string) (bool, error) {
+		return s.TeamSvc.IsUserLeadToken(ctx, req.(*pb.SetCapacityRequest).GetUserId(), token)
+	},
+}
+
+// NewGRPCServer собирает grpc.Server со всеми сервисами и проверкой admin-токена.
+// limiter - общие с HTTP лимиты запросов (nil - без лимитов), maxMsgBytes - аналог http.max_body_bytes (0 - умолчание gRPC)
+func NewGRPCServer(s *Server, limiter Limiter, maxMsgBytes int64) *grpc.Server {
+	var interceptors []grpc.UnaryServerInterceptor
+	if limiter != nil {
+		interceptors = append(interceptors, rateLimitInterceptor(limiter))
+	}
+	interceptors = append(interceptors, s.authInterceptor)
+
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(interceptors...)}
+	if maxMsgBytes > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(int(maxMsgBytes)))
+	}
+	gs := grpc.NewServer(opts...)
+
+	pb.RegisterTeamServiceServer(gs, s)
+	pb.RegisterUserServiceServer(gs, s)
+	pb.RegisterPullRequestServiceServer(gs, s)
+	pb.RegisterStatsServiceServer(gs, s)
+
+	return gs
+}
+
+// токен передается в metadata authorization: Bearer <token>, как заголовок в HTTP
+func (s *Server) authInterceptor(
+	ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (any, error) {
+	isLead, ok := adminMethods[info.FullMethod]
+	if !ok {
+		return handler(ctx, req)
+	}
+
+	token := bearerToken(ctx)
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, "admin or team lead token required")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(*s.adminToken.Load())) == 1 {
+		return handler(ctx, req)
+	}
+
+	// для несуществующей команды или пользователя токен лида не подходит
+	lead, err := isLead(ctx, s, req, token)
+	if err != nil && !errors.Is(err, repository.ErrTeamNotFound) && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	if !lead {
+		return nil, status.Error(codes.Unauthenticated, "admin or team lead token required")
+	}
+	return handler(ctx, req)
+}
@@ -0,0 +1,68 @@
+package grpcapi
+
+import (
+	"context"
+
+	"review-manager/internal/grpcapi/pb"
+)
+
+/* StatsService/ReviewerStats */
+
+func (s *Server) ReviewerStats(ctx context.Context, _ *pb.ReviewerStatsRequest) (*pb.ReviewerStatsResponse, error) {
+	resp, err := s.UserSvc.GetReviewerStats(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	out := &pb.ReviewerStatsResponse{Stats: make([]*pb.ReviewerStat, 0, len(resp.Stats))}
+	for _, st := range resp.Stats {
+		out.Stats = append(out.Stats, &pb.ReviewerStat{
+			Username:     st.Username,
+			ReviewsCount: int64(st.ReviewsCount),
+		})
+	}
+
+	return out, nil
+}
+
+/* StatsService/PrStats */
+
+func (s *Server) PrStats(ctx context.Context, _ *pb.PrStatsRequest) (*pb.PrStatsResponse, error) {
+	resp, err := s.PrSvc.GetPrStats(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	out := &pb.PrStatsResponse{
+		ByTeam:             make([]*pb.TeamPrStat, 0, len(resp.ByTeam)),
+		ByAuthor:           make([]*pb.AuthorPrStat, 0, len(resp.ByAuthor)),
+		MergeMedianSeconds: resp.MergeTime.MedianSeconds,
+		MergeP90Seconds:    resp.MergeTime.P90Seconds,
+		Reassignments:      make([]*pb.PrReassignStat, 0, len(resp.Reassignments)),
+		TotalPrs:           int64(resp.TotalPrs),
+		UnderstaffedShare:  resp.UnderstaffedShare,
+	}
+	for _, t := range resp.ByTeam {
+		out.ByTeam = append(out.ByTeam, &pb.TeamPrStat{
+			TeamName:    t.TeamName,
+			OpenCount:   int64(t.OpenCount),
+			MergedCount: int64(t.MergedCount),
+		})
+	}
+	for _, a := range resp.ByAuthor {
+		out.ByAuthor = append(out.ByAuthor, &pb.AuthorPrStat{
+			AuthorId:    a.AuthorID,
+			Username:    a.Username,
+			OpenCount:   int64(a.OpenCount),
+			MergedCount: int64(a.MergedCount),
+		})
+	}
+	for _, p := range resp.Reassignments {
+		out.Reassignments = append(out.Reassignments, &pb.PrReassignStat{
+			PullRequestId: p.PullRequestID,
+			ReassignCount: int64(p.ReassignCount),
+		})
+	}
+
+	return out, nil
+}
@@ -0,0 +1,59 @@
+package grpcapi
+
+import (
+	"context"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/grpcapi/pb"
+	"review-manager/internal/validation"
+)
+
+/* TeamService/AddTeam */
+
+func (s *Server) AddTeam(ctx context.Context, req *pb.AddTeamRequest) (*pb.AddTeamResponse, error) {
+	teamReq := teamFromPB(req.GetTeam())
+	if err := validation.ValidateTeamAdd(teamReq); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	resp, err := s.TeamSvc.TeamAdd(ctx, teamReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.AddTeamResponse{Team: teamToPB(resp.Team)}, nil
+}
+
+/* TeamService/GetTeam */
+
+func (s *Server) GetTeam(ctx context.Context, req *pb.GetTeamRequest) (*pb.Team, error) {
+	if err := validation.ValidateTeamName(req.GetTeamName()); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	team, err := s.TeamSvc.TeamGet(ctx, req.GetTeamName())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return teamToPB(team), nil
+}
+
+/* TeamService/SetDefaultCapacity */
+
+func (s *Server) SetDefaultCapacity(ctx context.Context, req *pb.SetDefaultCapacityRequest) (*pb.Team, error) {
+	capReq := dto.SetTeamCapacityRequest{
+		TeamName:              req.GetTeamName(),
+		DefaultMaxOpenReviews: intPtr(req.DefaultMaxOpenReviews),
+	}
+	if err := validation.ValidateSetTeamCapacity(capReq); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	team, err := s.TeamSvc.SetDefaultCapacity(ctx, capReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return teamToPB(team), nil
+}
@@ -0,0 +1,87 @@
+package grpcapi
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/grpcapi/pb"
+	"review-manager/internal/validation"
+)
+
+/* UserService/SetIsActive */
+
+func (s *Server) SetIsActive(ctx context.Context, req *pb.SetIsActiveRequest) (*pb.UserResponse, error) {
+	activeReq := dto.SetUserIsActiveRequest{
+		UserID:   req.GetUserId(),
+		IsActive: req.GetIsActive(),
+	}
+	if err := validation.ValidateUserSetActive(activeReq); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	user, err := s.UserSvc.SetIsActive(ctx, activeReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.UserResponse{User: userToPB(user)}, nil
+}
+
+/* UserService/SetCapacity */
+
+func (s *Server) SetCapacity(ctx context.Context, req *pb.SetCapacityRequest) (*pb.UserResponse, error) {
+	capReq := dto.SetUserCapacityRequest{
+		UserID:         req.GetUserId(),
+		MaxOpenReviews: intPtr(req.MaxOpenReviews),
+	}
+	if err := validation.ValidateSetUserCapacity(capReq); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	user, err := s.UserSvc.SetCapacity(ctx, capReq)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.UserResponse{User: userToPB(user)}, nil
+}
+
+/* UserService/GetReview */
+
+func (s *Server) GetReview(ctx context.Context, req *pb.GetReviewRequest) (*pb.GetReviewResponse, error) {
+	if err := validation.ValidateUserID(req.GetUserId()); err != nil {
+		return nil, invalidArgument(err)
+	}
+
+	resp, err := s.UserSvc.GetReviewPRs(ctx, dto.UserGetReviewRequest{UserID: req.GetUserId()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	out := &pb.GetReviewResponse{
+		UserId:           resp.UserID,
+		PullRequests:     make([]*pb.PullRequestShort, 0, len(resp.PullRequests)),
+		UpcomingAbsences: make([]*pb.Absence, 0, len(resp.UpcomingAbsences)),
+	}
+	for _, pr := range resp.PullRequests {
+		out.PullRequests = append(out.PullRequests, &pb.PullRequestShort{
+			PullRequestId:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorId:        pr.AuthorID,
+			Status:          string(pr.Status),
+		})
+	}
+	for _, a := range resp.UpcomingAbsences {
+		out.UpcomingAbsences = append(out.UpcomingAbsences, &pb.Absence{
+			AbsenceId: int64(a.AbsenceID),
+			UserId:    a.UserID,
+			StartsAt:  timestamppb.New(a.StartsAt),
+			EndsAt:    timestamppb.New(a.EndsAt),
+			Reason:    a.Reason,
+		})
+	}
+
+	return out, nil
+}
@@ -0,0 +1,73 @@
+package httpapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"review-manager/internal/dto"
+)
+
+// ETag PR - его версия в кавычках, например "3"
+func setPrETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// ifMatchVersion достает ожидаемую версию PR из If-Match.
+// nil - заголовка нет или передан "*", тогда версия не проверяется
+func ifMatchVersion(w http.ResponseWriter, r *http.Request) (*int64, bool) {
+	raw := strings.TrimSpace(r.Header.Get("If-Match"))
+	if raw == "" || raw == "*" {
+		return nil, true
+	}
+
+	raw = strings.TrimPrefix(raw, "W/")
+	raw = strings.Trim(raw, `"`)
+
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || v < 1 {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeValidationFailed, invalidIfMatch)
+		return nil, false
+	}
+	return &v, true
+}
+
+// writeJSONWithETag отдает JSON с ETag по хэшу тела. Если клиент прислал этот ETag в If-None-Match,
+// отвечаем 304 без тела: дашборды, которые опрашивают редко меняющиеся данные, не качают их заново
+func writeJSONWithETag(w http.ResponseWriter, r *http.Request, v any) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		return
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := strconv.Quote(hex.EncodeToString(sum[:16]))
+	w.Header().Set("ETag", etag)
+	// ответ можно хранить, но перед использованием обязательно сверять ETag
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// If-None-Match - список ETag через запятую или "*", слабые W/ сравниваются как сильные
+func etagMatches(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
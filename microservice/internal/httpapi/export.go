@@ -0,0 +1,142 @@
+package httpapi
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"review-manager/internal/dto"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+
+	// раз в сколько строк сбрасываем буфер клиенту
+	exportFlushEvery = 100
+)
+
+// формат берем из ?format=, иначе из Accept, по умолчанию CSV
+func exportFormat(r *http.Request) (string, bool) {
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case exportFormatCSV:
+		return exportFormatCSV, true
+	case exportFormatNDJSON:
+		return exportFormatNDJSON, true
+	case "":
+	default:
+		return "", false
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.Contains(accept, "application/x-ndjson") || strings.Contains(accept, "application/ndjson") {
+		return exportFormatNDJSON, true
+	}
+	return exportFormatCSV, true
+}
+
+// rowWriter пишет строки выгрузки прямо в ответ.
+// Заголовки отправляются при первой строке, чтобы ошибку до начала выгрузки еще можно было вернуть JSON-ом
+type rowWriter struct {
+	w        http.ResponseWriter
+	rc       *http.ResponseController
+	format   string
+	filename string
+	header   []string
+
+	csv     *csv.Writer
+	enc     *json.Encoder
+	started bool
+	rows    int
+}
+
+func newRowWriter(w http.ResponseWriter, format, filename string, header []string) *rowWriter {
+	return &rowWriter{
+		w:        w,
+		rc:       http.NewResponseController(w),
+		format:   format,
+		filename: filename,
+		header:   header,
+	}
+}
+
+func (rw *rowWriter) start() error {
+	rw.started = true
+
+	// длинная выгрузка не должна упираться в WriteTimeout сервера
+	_ = rw.rc.SetWriteDeadline(time.Time{})
+
+	if rw.format == exportFormatNDJSON {
+		rw.w.Header().Set("Content-Type", "application/x-ndjson")
+		rw.w.WriteHeader(http.StatusOK)
+		rw.enc = json.NewEncoder(rw.w)
+		return nil
+	}
+
+	rw.w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	rw.w.Header().Set("Content-Disposition", `attachment; filename="`+rw.filename+`.csv"`)
+	rw.w.WriteHeader(http.StatusOK)
+	rw.csv = csv.NewWriter(rw.w)
+	return rw.csv.Write(rw.header)
+}
+
+func (rw *rowWriter) write(record []string, v any) error {
+	if !rw.started {
+		if err := rw.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if rw.format == exportFormatNDJSON {
+		err = rw.enc.Encode(v)
+	} else {
+		err = rw.csv.Write(record)
+	}
+	if err != nil {
+		return err
+	}
+
+	rw.rows++
+	if rw.rows%exportFlushEvery == 0 {
+		return rw.flush()
+	}
+	return nil
+}
+
+func (rw *rowWriter) flush() error {
+	if rw.csv != nil {
+		rw.csv.Flush()
+		if err := rw.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return rw.rc.Flush()
+}
+
+// finish завершает выгрузку: если ничего еще не отправили, ошибку можно вернуть обычным ответом,
+// иначе остается только залогировать и оборвать поток
+func (rw *rowWriter) finish(err error) {
+	if err != nil && !rw.started {
+		writeError(rw.w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		return
+	}
+	if err != nil {
+		slog.Warn("export interrupted", "file", rw.filename, "rows", rw.rows, "err", err)
+		return
+	}
+
+	if !rw.started {
+		// пустая выгрузка: для CSV все равно отдаем строку заголовков
+		if err := rw.start(); err != nil {
+			slog.Error("export", "file", rw.filename, "err", err)
+			return
+		}
+	}
+	if err := rw.flush(); err != nil {
+		slog.Error("export", "file", rw.filename, "err", err)
+	}
+}
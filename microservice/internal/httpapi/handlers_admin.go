@@ -0,0 +1,42 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"review-manager/internal/dto"
+)
+
+/* POST /admin/runStaleReviews */
+
+func (h *Handler) AdminRunStaleReviews(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	report, err := h.StaleJob.RunOnce(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+/* POST /admin/reconcile?dry_run=true */
+
+func (h *Handler) AdminReconcile(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	report, err := h.ReconcileJob.RunOnce(r.Context(), dryRun)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,98 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/events"
+)
+
+// как часто слать комментарий-пинг, чтобы прокси не закрывали простаивающее соединение
+const sseHeartbeat = 15 * time.Second
+
+/* GET /events/stream?team_name=...&user_id=... */
+
+func (h *Handler) EventsStream(w http.ResponseWriter, r *http.Request) {
+	filter := events.Filter{
+		TeamName: r.URL.Query().Get("team_name"),
+		UserID:   r.URL.Query().Get("user_id"),
+	}
+
+	// браузерный EventSource при переподключении шлет заголовок, остальным удобнее query
+	lastRaw := r.Header.Get("Last-Event-ID")
+	if lastRaw == "" {
+		lastRaw = r.URL.Query().Get("last_event_id")
+	}
+	var lastID uint64
+	if lastRaw != "" {
+		v, err := strconv.ParseUint(lastRaw, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, dto.ErrorCodeValidationFailed, invalidLastEventID)
+			return
+		}
+		lastID = v
+	}
+
+	rc := http.NewResponseController(w)
+	// стрим живет дольше WriteTimeout сервера
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	backlog, sub := h.Events.Subscribe(lastID, filter)
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range backlog {
+		if err := writeSSE(w, e); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(sseHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case e, ok := <-sub.C:
+			// брокер отключил отстающего подписчика, клиент переподключится с Last-Event-ID
+			if !ok {
+				return
+			}
+			if err := writeSSE(w, e); err != nil {
+				return
+			}
+
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, e dto.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
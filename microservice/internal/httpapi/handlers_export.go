@@ -0,0 +1,110 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/validation"
+)
+
+var (
+	prExportHeader     = []string{"pull_request_id", "pull_request_name", "author_id", "team_name", "status", "assigned_reviewers", "created_at", "merged_at"}
+	teamExportHeader   = []string{"team_name", "user_id", "username", "is_active"}
+	statsExportHeader  = []string{"user_id", "username", "reviews_count"}
+	exportReviewersSep = ";"
+)
+
+/* GET /export/pullRequests?status=...&team_name=...&author_id=...&reviewer_id=...&include_archived=true&format=csv|ndjson */
+
+func (h *Handler) ExportPullRequests(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeValidationFailed, unsupportedFormat)
+		return
+	}
+
+	q := r.URL.Query()
+	filter := dto.ExportPrFilter{
+		Status:     q.Get("status"),
+		TeamName:   q.Get("team_name"),
+		AuthorID:   q.Get("author_id"),
+		ReviewerID: q.Get("reviewer_id"),
+	}
+	filter.IncludeArchived, _ = strconv.ParseBool(q.Get("include_archived"))
+	if err := validation.ValidateExportPrFilter(filter); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	rw := newRowWriter(w, format, "pull_requests", prExportHeader)
+	err := h.ExportSvc.ExportPullRequests(r.Context(), filter, func(row dto.PullRequestExportRow) error {
+		mergedAt := ""
+		if row.MergedAt != nil {
+			mergedAt = row.MergedAt.Format(time.RFC3339)
+		}
+		createdAt := ""
+		if row.CreatedAt != nil {
+			createdAt = row.CreatedAt.Format(time.RFC3339)
+		}
+
+		return rw.write([]string{
+			row.PullRequestID,
+			row.PullRequestName,
+			row.AuthorID,
+			row.TeamName,
+			string(row.Status),
+			joinReviewers(row.AssignedReviewers),
+			createdAt,
+			mergedAt,
+		}, row)
+	})
+	rw.finish(err)
+}
+
+/* GET /export/teams?team_name=...&format=csv|ndjson */
+
+func (h *Handler) ExportTeams(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeValidationFailed, unsupportedFormat)
+		return
+	}
+
+	rw := newRowWriter(w, format, "teams", teamExportHeader)
+	err := h.ExportSvc.ExportTeams(r.Context(), r.URL.Query().Get("team_name"), func(row dto.TeamMemberExportRow) error {
+		return rw.write([]string{
+			row.TeamName,
+			row.UserID,
+			row.Username,
+			strconv.FormatBool(row.IsActive),
+		}, row)
+	})
+	rw.finish(err)
+}
+
+/* GET /export/reviewerStats?team_name=...&format=csv|ndjson */
+
+func (h *Handler) ExportReviewerStats(w http.ResponseWriter, r *http.Request) {
+	format, ok := exportFormat(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, dto.ErrorCodeValidationFailed, unsupportedFormat)
+		return
+	}
+
+	rw := newRowWriter(w, format, "reviewer_stats", statsExportHeader)
+	err := h.ExportSvc.ExportReviewerStats(r.Context(), r.URL.Query().Get("team_name"), func(row dto.ReviewerStatExportRow) error {
+		return rw.write([]string{
+			row.UserID,
+			row.Username,
+			strconv.Itoa(row.ReviewsCount),
+		}, row)
+	})
+	rw.finish(err)
+}
+
+func joinReviewers(ids []string) string {
+	return strings.Join(ids, exportReviewersSep)
+}
@@ -0,0 +1,32 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"review-manager/internal/dto"
+)
+
+// GET /health/ready
+// 503 только если не отвечает основная БД: без реплик сервис работает, читая с нее
+func (h *Handler) HealthReady(w http.ResponseWriter, r *http.Request) {
+	resp := dto.ReadinessResponse{Status: dto.ReadinessOK, Replicas: []dto.ReplicaHealth{}}
+
+	for _, rep := range h.Reads.Replicas() {
+		if !rep.Healthy {
+			resp.Status = dto.ReadinessDegraded
+		}
+		resp.Replicas = append(resp.Replicas, dto.ReplicaHealth{
+			Name:       rep.Name,
+			InRotation: rep.Healthy,
+			LagSeconds: rep.Lag.Seconds(),
+		})
+	}
+
+	if err := h.Reads.PingPrimary(r.Context()); err != nil {
+		resp.Status = dto.ReadinessUnavailable
+		writeJSON(w, http.StatusServiceUnavailable, resp)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
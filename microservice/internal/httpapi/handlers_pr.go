@@ -18,7 +18,7 @@ func (h *Handler) PrCreate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := validation.ValidateCreatePR(req); err != nil {
-		writeError(w, http.StatusBadRequest, dto.ErrorCodeNotFound, err.Error())
+		writeValidationError(w, err)
 		return
 	}
 
@@ -31,11 +31,12 @@ func (h *Handler) PrCreate(w http.ResponseWriter, r *http.Request) {
 			errors.Is(err, repository.ErrTeamNotFound):
 			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
 		default:
-			writeError(w, http.StatusInternalServerError, dto.ErrorCodeNotFound, internalError)
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
 		}
 		return
 	}
 
+	setPrETag(w, resp.PR.Version)
 	writeJSON(w, http.StatusCreated, resp)
 }
 
@@ -48,21 +49,30 @@ func (h *Handler) MergePR(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := validation.ValidateMergePR(req); err != nil {
-		writeError(w, http.StatusBadRequest, dto.ErrorCodeNotFound, err.Error())
+		writeValidationError(w, err)
 		return
 	}
 
+	expected, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	req.ExpectedVersion = expected
+
 	resp, err := h.PrSvc.Merge(r.Context(), req)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrPRNotFound):
 			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		case errors.Is(err, repository.ErrVersionConflict):
+			writeError(w, http.StatusConflict, dto.ErrorCodeVersionConflict, err.Error())
 		default:
-			writeError(w, http.StatusInternalServerError, dto.ErrorCodeNotFound, internalError)
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
 		}
 		return
 	}
 
+	setPrETag(w, resp.PR.Version)
 	writeJSON(w, http.StatusOK, resp)
 }
 
@@ -75,9 +85,15 @@ func (h *Handler) PrReassign(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := validation.ValidateReassignPR(req); err != nil {
-		writeError(w, http.StatusBadRequest, dto.ErrorCodeNotFound, err.Error())
+		writeValidationError(w, err)
+		return
+	}
+
+	expected, ok := ifMatchVersion(w, r)
+	if !ok {
 		return
 	}
+	req.ExpectedVersion = expected
 
 	resp, err := h.PrSvc.Reassign(r.Context(), req)
 	if err != nil {
@@ -89,17 +105,139 @@ func (h *Handler) PrReassign(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, repository.ErrPRMerged):
 			writeError(w, http.StatusConflict, dto.ErrorCodePRMerged, err.Error())
 
+		case errors.Is(err, repository.ErrVersionConflict):
+			writeError(w, http.StatusConflict, dto.ErrorCodeVersionConflict, err.Error())
+
 		case errors.Is(err, repository.ErrReviewerNotSet):
 			writeError(w, http.StatusConflict, dto.ErrorCodeNotAssigned, err.Error())
 
+		// ErrAtCapacity оборачивает ErrNoCandidate, поэтому проверяется раньше
+		case errors.Is(err, repository.ErrAtCapacity),
+			errors.Is(err, repository.ErrUserAtCapacity):
+			writeError(w, http.StatusConflict, dto.ErrorCodeAtCapacity, err.Error())
+
 		case errors.Is(err, repository.ErrNoCandidate):
 			writeError(w, http.StatusConflict, dto.ErrorCodeNoCandidate, err.Error())
 
+		case errors.Is(err, repository.ErrAlreadyReviewer):
+			writeError(w, http.StatusConflict, dto.ErrorCodeAlreadyAssigned, err.Error())
+
+		case errors.Is(err, repository.ErrReviewerAuthor):
+			writeError(w, http.StatusConflict, dto.ErrorCodeReviewerIsAuthor, err.Error())
+
+		case errors.Is(err, repository.ErrUserInactive):
+			writeError(w, http.StatusConflict, dto.ErrorCodeUserInactive, err.Error())
+
+		case errors.Is(err, repository.ErrUserAbsent):
+			writeError(w, http.StatusConflict, dto.ErrorCodeUserAbsent, err.Error())
+
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	setPrETag(w, resp.PR.Version)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+/* POST /pullRequest/addReviewer */
+
+func (h *Handler) PrAddReviewer(w http.ResponseWriter, r *http.Request) {
+	var req dto.AddReviewerRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := validation.ValidateReviewerChange(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	expected, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	req.ExpectedVersion = expected
+
+	resp, err := h.PrSvc.AddReviewer(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPRNotFound),
+			errors.Is(err, repository.ErrUserNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+
+		case errors.Is(err, repository.ErrPRMerged):
+			writeError(w, http.StatusConflict, dto.ErrorCodePRMerged, err.Error())
+
+		case errors.Is(err, repository.ErrVersionConflict):
+			writeError(w, http.StatusConflict, dto.ErrorCodeVersionConflict, err.Error())
+
+		case errors.Is(err, repository.ErrAlreadyReviewer):
+			writeError(w, http.StatusConflict, dto.ErrorCodeAlreadyAssigned, err.Error())
+
+		case errors.Is(err, repository.ErrReviewerAuthor):
+			writeError(w, http.StatusConflict, dto.ErrorCodeReviewerIsAuthor, err.Error())
+
+		case errors.Is(err, repository.ErrUserInactive):
+			writeError(w, http.StatusConflict, dto.ErrorCodeUserInactive, err.Error())
+
+		case errors.Is(err, repository.ErrUserAtCapacity):
+			writeError(w, http.StatusConflict, dto.ErrorCodeAtCapacity, err.Error())
+
+		case errors.Is(err, repository.ErrUserAbsent):
+			writeError(w, http.StatusConflict, dto.ErrorCodeUserAbsent, err.Error())
+
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	setPrETag(w, resp.PR.Version)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+/* POST /pullRequest/removeReviewer */
+
+func (h *Handler) PrRemoveReviewer(w http.ResponseWriter, r *http.Request) {
+	var req dto.RemoveReviewerRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := validation.ValidateReviewerChange(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	expected, ok := ifMatchVersion(w, r)
+	if !ok {
+		return
+	}
+	req.ExpectedVersion = expected
+
+	resp, err := h.PrSvc.RemoveReviewer(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrPRNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+
+		case errors.Is(err, repository.ErrPRMerged):
+			writeError(w, http.StatusConflict, dto.ErrorCodePRMerged, err.Error())
+
+		case errors.Is(err, repository.ErrVersionConflict):
+			writeError(w, http.StatusConflict, dto.ErrorCodeVersionConflict, err.Error())
+
+		case errors.Is(err, repository.ErrReviewerNotSet):
+			writeError(w, http.StatusConflict, dto.ErrorCodeNotAssigned, err.Error())
+
 		default:
-			writeError(w, http.StatusInternalServerError, dto.ErrorCodeNotFound, internalError)
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
 		}
 		return
 	}
 
+	setPrETag(w, resp.PR.Version)
 	writeJSON(w, http.StatusOK, resp)
 }
@@ -9,7 +9,18 @@ import (
 func (h *Handler) StatsReviewerAssignments(w http.ResponseWriter, r *http.Request) {
 	resp, err := h.UserSvc.GetReviewerStats(r.Context())
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, dto.ErrorCodeNotFound, internalError)
+		writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		return
+	}
+
+	writeJSONWithETag(w, r, resp)
+}
+
+// GET /stats/prs
+func (h *Handler) StatsPrs(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.PrSvc.GetPrStats(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
 		return
 	}
 
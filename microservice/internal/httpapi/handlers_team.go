@@ -3,9 +3,12 @@ package httpapi
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
+	"review-manager/internal/codeowners"
 	"review-manager/internal/dto"
 	"review-manager/internal/repository"
+	"review-manager/internal/roster"
 	"review-manager/internal/validation"
 )
 
@@ -17,8 +20,13 @@ func (h *Handler) TeamAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// лид и место в иерархии дают права лида и эскалации, поэтому только с admin-токеном
+	if (req.LeadUserID != "" || req.ParentTeam != "") && !h.requireAdmin(w, r) {
+		return
+	}
+
 	if err := validation.ValidateTeamAdd(req); err != nil {
-		writeError(w, http.StatusBadRequest, dto.ErrorCodeNotFound, err.Error())
+		writeValidationError(w, err)
 		return
 	}
 
@@ -27,8 +35,13 @@ func (h *Handler) TeamAdd(w http.ResponseWriter, r *http.Request) {
 		switch {
 		case errors.Is(err, repository.ErrTeamExists):
 			writeError(w, http.StatusBadRequest, dto.ErrorCodeTeamExists, err.Error())
+		case errors.Is(err, repository.ErrUserDeleted):
+			writeError(w, http.StatusConflict, dto.ErrorCodeUserDeleted, err.Error())
+		// не нашлась родительская команда или лид
+		case errors.Is(err, repository.ErrTeamNotFound), errors.Is(err, repository.ErrUserNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
 		default:
-			writeError(w, http.StatusInternalServerError, dto.ErrorCodeNotFound, internalError)
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
 		}
 		return
 	}
@@ -40,8 +53,8 @@ func (h *Handler) TeamAdd(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) TeamGet(w http.ResponseWriter, r *http.Request) {
 	teamName := r.URL.Query().Get("team_name")
-	if teamName == "" {
-		writeError(w, http.StatusBadRequest, dto.ErrorCodeNotFound, rscNotFound)
+	if err := validation.ValidateTeamName(teamName); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
@@ -51,10 +64,276 @@ func (h *Handler) TeamGet(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, repository.ErrTeamNotFound):
 			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
 		default:
-			writeError(w, http.StatusInternalServerError, dto.ErrorCodeNotFound, internalError)
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSONWithETag(w, r, team)
+}
+
+/* POST /team/setDefaultCapacity */
+
+func (h *Handler) TeamSetDefaultCapacity(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetTeamCapacityRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !h.requireTeamAdmin(w, r, req.TeamName) {
+		return
+	}
+
+	if err := validation.ValidateSetTeamCapacity(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	team, err := h.TeamSvc.SetDefaultCapacity(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTeamNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
 		}
 		return
 	}
 
 	writeJSON(w, http.StatusOK, team)
 }
+
+/* POST /team/setReviewSla */
+
+func (h *Handler) TeamSetReviewSla(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetTeamReviewSlaRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !h.requireTeamAdmin(w, r, req.TeamName) {
+		return
+	}
+
+	if err := validation.ValidateSetTeamReviewSla(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	team, err := h.TeamSvc.SetReviewSla(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTeamNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, team)
+}
+
+/* POST /team/setLead */
+
+func (h *Handler) TeamSetLead(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	var req dto.SetTeamLeadRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := validation.ValidateSetTeamLead(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	resp, err := h.TeamSvc.SetLead(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTeamNotFound), errors.Is(err, repository.ErrUserNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		case errors.Is(err, repository.ErrUserInactive):
+			writeError(w, http.StatusConflict, dto.ErrorCodeUserInactive, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	// токен лида показывается один раз, повтор по Idempotency-Key вернет команду без него
+	storeRedacted(w, dto.SetTeamLeadResponse{Team: resp.Team})
+	writeJSON(w, http.StatusOK, resp)
+}
+
+/* POST /team/setParent */
+
+func (h *Handler) TeamSetParent(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	var req dto.SetTeamParentRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := validation.ValidateSetTeamParent(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	team, err := h.TeamSvc.SetParent(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTeamNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		case errors.Is(err, repository.ErrTeamCycle):
+			writeError(w, http.StatusConflict, dto.ErrorCodeTeamCycle, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, team)
+}
+
+/* POST /team/import?format=csv|yaml&dry_run=true */
+
+func (h *Handler) TeamImport(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = roster.FormatFromName(r.Header.Get("Content-Type"))
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	entries, err := roster.Parse(r.Body, format)
+	if err != nil {
+		if !bodyTooLarge(w, r, err) {
+			writeValidationError(w, err)
+		}
+		return
+	}
+
+	if err := validation.ValidateRoster(entries); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	resp, err := h.TeamSvc.ImportRoster(r.Context(), entries, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTeamExists):
+			writeError(w, http.StatusConflict, dto.ErrorCodeTeamExists, err.Error())
+		case errors.Is(err, repository.ErrUserDeleted):
+			writeError(w, http.StatusConflict, dto.ErrorCodeUserDeleted, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if !dryRun {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, resp)
+}
+
+/* POST /team/setCodeowners?team_name=... (тело - файл в формате CODEOWNERS) */
+
+func (h *Handler) TeamSetCodeowners(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if err := validation.ValidateTeamName(teamName); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if !h.requireTeamAdmin(w, r, teamName) {
+		return
+	}
+
+	rules, err := codeowners.Parse(r.Body)
+	if err != nil {
+		if !bodyTooLarge(w, r, err) {
+			writeValidationError(w, err)
+		}
+		return
+	}
+
+	resp, err := h.TeamSvc.SetCodeowners(r.Context(), teamName, rules)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTeamNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+/* GET /team/getCodeowners?team_name=... */
+
+func (h *Handler) TeamGetCodeowners(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if err := validation.ValidateTeamName(teamName); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	resp, err := h.TeamSvc.GetCodeowners(r.Context(), teamName)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTeamNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+/* POST /team/setWebhook */
+
+func (h *Handler) TeamSetWebhook(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetTeamWebhookRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !h.requireTeamAdmin(w, r, req.TeamName) {
+		return
+	}
+
+	if err := validation.ValidateSetTeamWebhook(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	resp, err := h.TeamSvc.SetWebhook(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrTeamNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
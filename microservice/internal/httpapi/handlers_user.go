@@ -3,26 +3,29 @@ package httpapi
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"review-manager/internal/dto"
 	"review-manager/internal/repository"
+	"review-manager/internal/service"
 	"review-manager/internal/validation"
 )
 
 /* POST /users/setIsActive */
 
 func (h *Handler) UserSetActive(w http.ResponseWriter, r *http.Request) {
-	if !h.requireAdmin(w, r) {
+	var req dto.SetUserIsActiveRequest
+	if !decodeJSON(w, r, &req) {
 		return
 	}
 
-	var req dto.SetUserIsActiveRequest
-	if !decodeJSON(w, r, &req) {
+	if !h.requireUserAdmin(w, r, req.UserID) {
 		return
 	}
 
 	if err := validation.ValidateUserSetActive(req); err != nil {
-		writeError(w, http.StatusBadRequest, dto.ErrorCodeNotFound, err.Error())
+		writeValidationError(w, err)
 		return
 	}
 
@@ -32,7 +35,7 @@ func (h *Handler) UserSetActive(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, repository.ErrUserNotFound):
 			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
 		default:
-			writeError(w, http.StatusInternalServerError, dto.ErrorCodeNotFound, internalError)
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
 		}
 		return
 	}
@@ -40,25 +43,227 @@ func (h *Handler) UserSetActive(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, dto.SetUserIsActiveResponse{User: user})
 }
 
-/* GET /users/getReview?user_id=... */
+/* POST /users/delete */
+
+func (h *Handler) UserDelete(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	var req dto.DeleteUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := validation.ValidateUserID(req.UserID); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	resp, err := h.UserSvc.Delete(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+/* POST /users/restore */
+
+func (h *Handler) UserRestore(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	var req dto.RestoreUserRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := validation.ValidateUserID(req.UserID); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	user, err := h.UserSvc.Restore(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.RestoreUserResponse{User: user})
+}
+
+/* GET /users/getReview?user_id=...&status=OPEN,MERGED&include_archived=true&limit=50&cursor=... (без limit - все PR) */
 
 func (h *Handler) UserGetReview(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	req := dto.UserGetReviewRequest{
+		UserID: q.Get("user_id"),
+		Cursor: q.Get("cursor"),
+	}
+	req.IncludeArchived, _ = strconv.ParseBool(q.Get("include_archived"))
+
+	// статусы можно передать через запятую или повторить параметр
+	for _, v := range q["status"] {
+		for _, st := range strings.Split(v, ",") {
+			if st = strings.TrimSpace(st); st != "" {
+				req.Statuses = append(req.Statuses, dto.PullRequestStatus(strings.ToUpper(st)))
+			}
+		}
+	}
+
+	limit, err := validation.ParsePageLimit(q.Get("limit"))
+	if err != nil {
+		writeValidationError(w, err)
+		return
+	}
+	req.Limit = limit
+
+	if err := validation.ValidateUserGetReview(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	resp, err := h.UserSvc.GetReviewPRs(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBadCursor):
+			writeValidationError(w, err)
+		case errors.Is(err, repository.ErrUserNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+/* GET /users/getAuthored?user_id=... */
+
+func (h *Handler) UserGetAuthored(w http.ResponseWriter, r *http.Request) {
 	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		writeError(w, http.StatusBadRequest, dto.ErrorCodeNotFound, rscNotFound)
+	if err := validation.ValidateUserID(userID); err != nil {
+		writeValidationError(w, err)
 		return
 	}
 
-	resp, err := h.UserSvc.GetReviewPRs(r.Context(), userID)
+	resp, err := h.UserSvc.GetAuthoredPRs(r.Context(), userID)
 	if err != nil {
 		switch {
 		case errors.Is(err, repository.ErrUserNotFound):
 			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
 		default:
-			writeError(w, http.StatusInternalServerError, dto.ErrorCodeNotFound, internalError)
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
 		}
 		return
 	}
 
 	writeJSON(w, http.StatusOK, resp)
 }
+
+/* POST /users/addAbsence */
+
+func (h *Handler) UserAddAbsence(w http.ResponseWriter, r *http.Request) {
+	var req dto.AddAbsenceRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !h.requireUserAdmin(w, r, req.UserID) {
+		return
+	}
+
+	if err := validation.ValidateAddAbsence(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	absence, err := h.UserSvc.AddAbsence(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, dto.AddAbsenceResponse{Absence: absence})
+}
+
+/* POST /users/removeAbsence */
+
+func (h *Handler) UserRemoveAbsence(w http.ResponseWriter, r *http.Request) {
+	if !h.requireAdmin(w, r) {
+		return
+	}
+
+	var req dto.RemoveAbsenceRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if err := validation.ValidateRemoveAbsence(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	if err := h.UserSvc.RemoveAbsence(r.Context(), req); err != nil {
+		switch {
+		case errors.Is(err, repository.ErrAbsenceNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/* POST /users/setCapacity */
+
+func (h *Handler) UserSetCapacity(w http.ResponseWriter, r *http.Request) {
+	var req dto.SetUserCapacityRequest
+	if !decodeJSON(w, r, &req) {
+		return
+	}
+
+	if !h.requireUserAdmin(w, r, req.UserID) {
+		return
+	}
+
+	if err := validation.ValidateSetUserCapacity(req); err != nil {
+		writeValidationError(w, err)
+		return
+	}
+
+	user, err := h.UserSvc.SetCapacity(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			writeError(w, http.StatusNotFound, dto.ErrorCodeNotFound, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, dto.SetUserCapacityResponse{User: user})
+}
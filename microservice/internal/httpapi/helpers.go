@@ -2,10 +2,13 @@ package httpapi
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strings"
 
 	"review-manager/internal/dto"
+	"review-manager/internal/repository"
+	"review-manager/internal/validation"
 )
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
@@ -24,29 +27,95 @@ func writeError(w http.ResponseWriter, status int, code, msg string) {
 	writeJSON(w, status, resp)
 }
 
+// writeValidationError отвечает 400 VALIDATION_FAILED; ошибки полей запроса и строк файла
+// отдаются все сразу списком в details, message - они же одной строкой
+func writeValidationError(w http.ResponseWriter, err error) {
+	body := dto.ErrorBody{Code: dto.ErrorCodeValidationFailed, Message: err.Error()}
+
+	var (
+		fieldErrs validation.FieldErrors
+		lineErrs  validation.LineErrors
+	)
+	switch {
+	case errors.As(err, &fieldErrs):
+		for _, fe := range fieldErrs {
+			body.Details = append(body.Details, dto.ErrorDetail{Field: fe.Field, Message: fe.Msg})
+		}
+	case errors.As(err, &lineErrs):
+		for _, le := range lineErrs {
+			body.Details = append(body.Details, dto.ErrorDetail{Line: le.Line, Message: le.Msg})
+		}
+	}
+
+	writeJSON(w, http.StatusBadRequest, dto.ErrorResponse{Error: body})
+}
+
 func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
 	dec := json.NewDecoder(r.Body)
 	dec.DisallowUnknownFields()
 
 	if err := dec.Decode(dst); err != nil {
-		writeError(w, http.StatusBadRequest, dto.ErrorCodeNotFound, invalidJSON)
+		if !bodyTooLarge(w, r, err) {
+			writeError(w, http.StatusBadRequest, dto.ErrorCodeValidationFailed, invalidJSON)
+		}
 		return false
 	}
 	return true
 }
 
-func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+// токен из заголовка Authorization: Bearer <token>, false - заголовка нет
+func bearerToken(r *http.Request) (string, bool) {
 	auth := r.Header.Get("Authorization")
 	if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
-		writeError(w, http.StatusUnauthorized, dto.ErrorCodeNotFound, rscNotFound)
-		return false
+		return "", false
 	}
 
 	token := strings.TrimPrefix(auth, "Bearer ")
-	token = strings.TrimSpace(token)
+	return strings.TrimSpace(token), true
+}
+
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	token, ok := bearerToken(r)
+	if !ok || !h.isAdminToken(token) {
+		writeError(w, http.StatusUnauthorized, dto.ErrorCodeUnauthorized, unauthorized)
+		return false
+	}
 
-	if token != h.AdminToken {
-		writeError(w, http.StatusUnauthorized, dto.ErrorCodeNotFound, rscNotFound)
+	return true
+}
+
+// requireTeamAdmin - кроме admin-токена пускает токен лида команды teamName или лида одной из ее родительских команд
+func (h *Handler) requireTeamAdmin(w http.ResponseWriter, r *http.Request, teamName string) bool {
+	return h.requireAdminOrLead(w, r, func(token string) (bool, error) {
+		return h.TeamSvc.IsLeadToken(r.Context(), teamName, token)
+	})
+}
+
+// requireUserAdmin - то же, что requireTeamAdmin, для команды пользователя userID
+func (h *Handler) requireUserAdmin(w http.ResponseWriter, r *http.Request, userID string) bool {
+	return h.requireAdminOrLead(w, r, func(token string) (bool, error) {
+		return h.TeamSvc.IsUserLeadToken(r.Context(), userID, token)
+	})
+}
+
+func (h *Handler) requireAdminOrLead(w http.ResponseWriter, r *http.Request, isLead func(token string) (bool, error)) bool {
+	token, ok := bearerToken(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, dto.ErrorCodeUnauthorized, unauthorized)
+		return false
+	}
+	if h.isAdminToken(token) {
+		return true
+	}
+
+	// для несуществующей команды или пользователя токен лида не подходит
+	ok, err := isLead(token)
+	if err != nil && !errors.Is(err, repository.ErrTeamNotFound) && !errors.Is(err, repository.ErrUserNotFound) {
+		writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+		return false
+	}
+	if !ok {
+		writeError(w, http.StatusUnauthorized, dto.ErrorCodeUnauthorized, unauthorized)
 		return false
 	}
 
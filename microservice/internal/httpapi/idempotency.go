@@ -0,0 +1,225 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/repository"
+)
+
+const (
+	idempotencyHeader    = "Idempotency-Key"
+	idempotencyReplayed  = "Idempotent-Replayed"
+	maxIdempotencyKeyLen = 255
+
+	// на сколько занимается ключ, пока запрос выполняется: если реплика упадет, не дописав ответ,
+	// повторы получат 409 только до конца аренды, а не весь TTL. Должна быть дольше любого POST-хэндлера
+	idempotencyLease = time.Minute
+)
+
+// заголовки ответа, которые сохраняем и отдаем при повторе
+var idempotencyHeaders = []string{"Content-Type", "ETag"}
+
+// Idempotency - middleware для POST-запросов с заголовком Idempotency-Key:
+// первый ответ сохраняется на TTL и отдается как есть на повторы с тем же ключом и тем же токеном.
+// Ключи разных вызывающих не пересекаются, поэтому чужой ответ по известному ключу без токена не получить
+type Idempotency struct {
+	Keys repository.IdempotencyRepo
+	TTL  time.Duration
+}
+
+func NewIdempotency(keys repository.IdempotencyRepo, ttl time.Duration) *Idempotency {
+	return &Idempotency{
+		Keys: keys,
+		TTL:  ttl,
+	}
+}
+
+func (m *Idempotency) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLen {
+			writeError(w, http.StatusBadRequest, dto.ErrorCodeValidationFailed, idempotencyKeyTooLong)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			if !bodyTooLarge(w, r, err) {
+				writeError(w, http.StatusBadRequest, dto.ErrorCodeValidationFailed, invalidJSON)
+			}
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key = callerScope(r) + ":" + key
+		hash := requestHash(r, body)
+		now := time.Now().UTC()
+
+		rec, reserved, err := m.Keys.Reserve(r.Context(), key, hash, now, now.Add(min(m.TTL, idempotencyLease)))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, dto.ErrorCodeInternal, internalError)
+			return
+		}
+
+		if !reserved {
+			switch {
+			case rec.RequestHash != hash:
+				writeError(w, http.StatusUnprocessableEntity, dto.ErrorCodeIdempotencyKeyReused, idempotencyKeyReused)
+			case rec.StatusCode == nil:
+				writeError(w, http.StatusConflict, dto.ErrorCodeIdempotencyInProgress, idempotencyInProgress)
+			default:
+				replay(w, rec)
+			}
+			return
+		}
+
+		// ответ уже ушел клиенту, сохраняем его даже если клиент отвалился
+		ctx := context.WithoutCancel(r.Context())
+
+		// после паники ответа нет, снимаем резерв, чтобы повтор мог пройти заново
+		defer func() {
+			if p := recover(); p != nil {
+				m.release(ctx, key, rec.LeaseID)
+				panic(p)
+			}
+		}()
+
+		rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r)
+
+		// 5xx и 401 не запоминаем, чтобы повтор мог пройти заново
+		if rw.status >= http.StatusInternalServerError || rw.status == http.StatusUnauthorized {
+			m.release(ctx, key, rec.LeaseID)
+			return
+		}
+
+		headers := make(map[string]string, len(idempotencyHeaders))
+		for _, name := range idempotencyHeaders {
+			if v := w.Header().Get(name); v != "" {
+				headers[name] = v
+			}
+		}
+
+		stored := rw.body.Bytes()
+		if rw.redacted != nil {
+			stored = rw.redacted
+		}
+		// аренда истекла и ключ перезанял повтор: его ответ не затираем
+		err = m.Keys.Complete(ctx, key, rec.LeaseID, rw.status, headers, stored, time.Now().UTC().Add(m.TTL))
+		if errors.Is(err, repository.ErrLeaseLost) {
+			slog.Warn("idempotency: lease expired before response was stored", "key", key)
+		} else if err != nil {
+			slog.Error("idempotency: complete", "key", key, "err", err)
+		}
+	})
+}
+
+func (m *Idempotency) release(ctx context.Context, key, leaseID string) {
+	if err := m.Keys.Release(ctx, key, leaseID); err != nil {
+		slog.Error("idempotency: release", "key", key, "err", err)
+	}
+}
+
+// RunCleanup раз в interval удаляет истекшие ключи, пока не отменят ctx
+func (m *Idempotency) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if _, err := m.Keys.DeleteExpired(ctx, time.Now().UTC()); err != nil && ctx.Err() == nil {
+			slog.Error("idempotency cleanup", "err", err)
+		}
+	}
+}
+
+// хэш метода, пути с query и тела - один ключ нельзя переиспользовать для другого запроса
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// callerScope - хэш токена из Authorization, им ключ привязывается к вызывающему. Сам токен не храним
+func callerScope(r *http.Request) string {
+	token, ok := bearerToken(r)
+	if !ok || token == "" {
+		return "anonymous"
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:16])
+}
+
+// storeRedacted подменяет тело, которое сохранится для повторов по Idempotency-Key: ответы с секретами
+// (токен лида) клиент получает один раз, в idempotency_keys они не попадают, а повтор получает v
+func storeRedacted(w http.ResponseWriter, v any) {
+	rw, ok := w.(*recordingWriter)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		return
+	}
+	rw.redacted = buf.Bytes()
+}
+
+func replay(w http.ResponseWriter, rec repository.IdempotencyRecord) {
+	for name, v := range rec.Headers {
+		w.Header().Set(name, v)
+	}
+	w.Header().Set(idempotencyReplayed, "true")
+	w.WriteHeader(*rec.StatusCode)
+	_, _ = w.Write(rec.Body)
+}
+
+// recordingWriter пишет ответ клиенту и параллельно копит его для сохранения
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	redacted    []byte
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
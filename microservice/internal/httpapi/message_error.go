@@ -3,6 +3,19 @@ package httpapi
 // message к ошибкам в тело JSON
 var (
 	internalError = "internal error"
-	rscNotFound   = "resource not found"
+	unauthorized  = "missing or invalid token"
 	invalidJSON   = "invalid json body"
+
+	invalidIfMatch = "invalid If-Match header, expected PR version"
+
+	unsupportedFormat = "unsupported export format, use csv or ndjson"
+
+	invalidLastEventID = "invalid Last-Event-ID, expected event id"
+
+	idempotencyKeyTooLong = "Idempotency-Key is too long"
+	idempotencyKeyReused  = "Idempotency-Key was already used with a different request"
+	idempotencyInProgress = "request with this Idempotency-Key is still in progress"
+
+	rateLimited     = "too many requests, retry later"
+	bodyTooLargeMsg = "request body is too large"
 )
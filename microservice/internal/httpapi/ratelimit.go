@@ -0,0 +1,225 @@
+package httpapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/metrics"
+)
+
+// области лимитов, они же значения метки scope в метриках
+const (
+	limitScopeIP    = "ip"
+	limitScopeToken = "token"
+)
+
+// Настройки лимитов: Rate - сколько запросов в секунду восполняется, Burst - сколько можно сделать подряд.
+// Нулевой Rate выключает лимит, нулевой MaxBodyBytes - ограничение тела
+type RateLimitConfig struct {
+	IPRate       float64
+	IPBurst      int
+	TokenRate    float64
+	TokenBurst   int
+	MaxBodyBytes int64
+}
+
+// RateLimit - middleware с token bucket на каждый IP и на каждый токен из Authorization и с ограничением размера тела.
+// Лимит IP действует всегда, иначе его можно обойти, подставляя случайные токены; лимит токена - дополнительно к нему
+type RateLimit struct {
+	cfg RateLimitConfig
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+
+	limited  *metrics.Vec
+	tooLarge *metrics.Vec
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimit(cfg RateLimitConfig, reg *metrics.Registry) *RateLimit {
+	m := &RateLimit{
+		cfg:      cfg,
+		buckets:  make(map[string]*bucket),
+		limited:  reg.NewCounter("http_rate_limited_total", "Requests rejected with 429 by scope.", "scope"),
+		tooLarge: reg.NewCounter("http_request_body_too_large_total", "Requests rejected with 413.", ""),
+	}
+
+	// сами лимиты тоже видны в метриках, чтобы по графику было понятно, во что уперлись
+	rate := reg.NewGauge("http_rate_limit_rps", "Configured refill rate per bucket, 0 - disabled.", "scope")
+	rate.Set(limitScopeIP, cfg.IPRate)
+	rate.Set(limitScopeToken, cfg.TokenRate)
+	burst := reg.NewGauge("http_rate_limit_burst", "Configured bucket size.", "scope")
+	burst.Set(limitScopeIP, float64(cfg.IPBurst))
+	burst.Set(limitScopeToken, float64(cfg.TokenBurst))
+	reg.NewGauge("http_max_body_bytes", "Configured request body limit, 0 - unlimited.", "").Set("", float64(cfg.MaxBodyBytes))
+
+	return m
+}
+
+func (m *RateLimit) Wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// пробы здоровья не должны получать 429
+		if strings.HasPrefix(r.URL.Path, "/health") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, _ := bearerToken(r)
+		if wait, ok := m.Allow(clientIP(r), token); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, dto.ErrorCodeRateLimited, rateLimited)
+			return
+		}
+
+		if m.cfg.MaxBodyBytes > 0 {
+			// тело длиннее лимита обрывается на чтении с *http.MaxBytesError, 413 отдает читающий код через bodyTooLarge
+			r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, m.cfg.MaxBodyBytes), onLimit: m.tooLarge.Inc}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// тело с ограничением размера, запоминает, что в лимит уперлись
+type limitedBody struct {
+	io.ReadCloser
+	onLimit func(string)
+	hit     bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var tooLarge *http.MaxBytesError
+	if !b.hit && errors.As(err, &tooLarge) {
+		b.hit = true
+		b.onLimit("")
+	}
+	return n, err
+}
+
+// bodyTooLarge отвечает 413, если чтение тела оборвалось на лимите размера. Парсеры файлов
+// заворачивают ошибку чтения в свои, поэтому кроме err смотрим и на само тело
+func bodyTooLarge(w http.ResponseWriter, r *http.Request, err error) bool {
+	var tooLarge *http.MaxBytesError
+	body, ok := r.Body.(*limitedBody)
+	if !errors.As(err, &tooLarge) && !(ok && body.hit) {
+		return false
+	}
+
+	writeError(w, http.StatusRequestEntityTooLarge, dto.ErrorCodeBodyTooLarge, bodyTooLargeMsg)
+	return true
+}
+
+// Allow берет по токену из корзин ip и token, пустой token - только лимит IP. При отказе возвращает,
+// сколько ждать. Те же корзины использует gRPC API, поэтому лимиты общие для обоих протоколов
+func (m *RateLimit) Allow(ip, token string) (time.Duration, bool) {
+	wait, scope, ok := m.allow(ip, token, time.Now())
+	if !ok {
+		m.limited.Inc(scope)
+	}
+	return wait, ok
+}
+
+// allow - Allow с явным временем; при отказе возвращает еще и какая корзина пуста
+func (m *RateLimit) allow(ip, token string, now time.Time) (time.Duration, string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ipBucket *bucket
+	if m.cfg.IPRate > 0 {
+		ipBucket = m.bucket(limitScopeIP+":"+ip, m.cfg.IPBurst, now)
+		if wait, ok := ipBucket.take(m.cfg.IPRate, m.cfg.IPBurst, now); !ok {
+			return wait, limitScopeIP, false
+		}
+	}
+
+	if m.cfg.TokenRate <= 0 || token == "" {
+		return 0, "", true
+	}
+
+	// сами токены в памяти не держим, только их хэш
+	sum := sha256.Sum256([]byte(token))
+	tokenBucket := m.bucket(limitScopeToken+":"+hex.EncodeToString(sum[:16]), m.cfg.TokenBurst, now)
+	if wait, ok := tokenBucket.take(m.cfg.TokenRate, m.cfg.TokenBurst, now); !ok {
+		// запрос не прошел, поэтому возвращаем взятое из корзины IP
+		if ipBucket != nil {
+			ipBucket.tokens++
+		}
+		return wait, limitScopeToken, false
+	}
+	return 0, "", true
+}
+
+func (m *RateLimit) bucket(key string, burst int, now time.Time) *bucket {
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), last: now}
+		m.buckets[key] = b
+	}
+	return b
+}
+
+// take восполняет корзину за прошедшее время и берет из нее один токен, при нехватке - сколько ждать следующего
+func (b *bucket) take(rate float64, burst int, now time.Time) (time.Duration, bool) {
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return time.Duration((1 - b.tokens) / rate * float64(time.Second)), false
+}
+
+// RunCleanup раз в interval удаляет корзины, которые успели заполниться до конца: они ничем не отличаются от новых
+func (m *RateLimit) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.cleanup(now)
+		}
+	}
+}
+
+func (m *RateLimit) cleanup(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, b := range m.buckets {
+		rate, burst := m.cfg.IPRate, m.cfg.IPBurst
+		if strings.HasPrefix(key, limitScopeToken+":") {
+			rate, burst = m.cfg.TokenRate, m.cfg.TokenBurst
+		}
+		if b.tokens+now.Sub(b.last).Seconds()*rate >= float64(burst) {
+			delete(m.buckets, key)
+		}
+	}
+}
+
+// IP клиента по адресу соединения. X-Forwarded-For не смотрим: его может подставить сам клиент
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
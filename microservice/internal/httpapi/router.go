@@ -1,25 +1,61 @@
 package httpapi
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"sync/atomic"
 
+	"review-manager/internal/events"
+	"review-manager/internal/repository"
 	"review-manager/internal/service"
 )
 
 type Handler struct {
-	TeamSvc    *service.TeamService
-	UserSvc    *service.UserService
-	PrSvc      *service.PRService
-	AdminToken string
+	TeamSvc      *service.TeamService
+	UserSvc      *service.UserService
+	PrSvc        *service.PRService
+	ExportSvc    *service.ExportService
+	StaleJob     *service.StaleReviewJob
+	ReconcileJob *service.ReconcileJob
+	Events       *events.Broker
+	Reads        *repository.ReadRouter
+
+	// admin-токен меняется на лету при перечитывании конфигурации
+	adminToken atomic.Pointer[string]
 }
 
-func NewHandler(team *service.TeamService, user *service.UserService, pr *service.PRService, admToken string) *Handler {
-	return &Handler{
-		TeamSvc:    team,
-		UserSvc:    user,
-		PrSvc:      pr,
-		AdminToken: admToken,
+func NewHandler(
+	team *service.TeamService,
+	user *service.UserService,
+	pr *service.PRService,
+	export *service.ExportService,
+	staleJob *service.StaleReviewJob,
+	reconcileJob *service.ReconcileJob,
+	broker *events.Broker,
+	reads *repository.ReadRouter,
+	admToken string,
+) *Handler {
+	h := &Handler{
+		TeamSvc:      team,
+		UserSvc:      user,
+		PrSvc:        pr,
+		ExportSvc:    export,
+		StaleJob:     staleJob,
+		ReconcileJob: reconcileJob,
+		Events:       broker,
+		Reads:        reads,
 	}
+	h.SetAdminToken(admToken)
+	return h
+}
+
+// SetAdminToken заменяет admin-токен, запросы в обработке дорабатывают со старым
+func (h *Handler) SetAdminToken(token string) {
+	h.adminToken.Store(&token)
+}
+
+func (h *Handler) isAdminToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(*h.adminToken.Load())) == 1
 }
 
 func NewMux(h *Handler) http.Handler {
@@ -30,22 +66,52 @@ func NewMux(h *Handler) http.Handler {
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("ok\n"))
 	})
+	mux.HandleFunc("/health/ready", h.HealthReady)
 
 	// Teams
 	mux.HandleFunc("/team/add", h.TeamAdd)
 	mux.HandleFunc("/team/get", h.TeamGet)
+	mux.HandleFunc("/team/import", h.TeamImport)
+	mux.HandleFunc("/team/setDefaultCapacity", h.TeamSetDefaultCapacity)
+	mux.HandleFunc("/team/setCodeowners", h.TeamSetCodeowners)
+	mux.HandleFunc("/team/getCodeowners", h.TeamGetCodeowners)
+	mux.HandleFunc("/team/setWebhook", h.TeamSetWebhook)
+	mux.HandleFunc("/team/setReviewSla", h.TeamSetReviewSla)
+	mux.HandleFunc("/team/setLead", h.TeamSetLead)
+	mux.HandleFunc("/team/setParent", h.TeamSetParent)
 
 	// Users
 	mux.HandleFunc("/users/setIsActive", h.UserSetActive)
 	mux.HandleFunc("/users/getReview", h.UserGetReview)
+	mux.HandleFunc("/users/getAuthored", h.UserGetAuthored)
+	mux.HandleFunc("/users/addAbsence", h.UserAddAbsence)
+	mux.HandleFunc("/users/removeAbsence", h.UserRemoveAbsence)
+	mux.HandleFunc("/users/setCapacity", h.UserSetCapacity)
+	mux.HandleFunc("/users/delete", h.UserDelete)
+	mux.HandleFunc("/users/restore", h.UserRestore)
 
 	// Pull Requests
 	mux.HandleFunc("/pullRequest/create", h.PrCreate)
 	mux.HandleFunc("/pullRequest/merge", h.MergePR)
 	mux.HandleFunc("/pullRequest/reassign", h.PrReassign)
+	mux.HandleFunc("/pullRequest/addReviewer", h.PrAddReviewer)
+	mux.HandleFunc("/pullRequest/removeReviewer", h.PrRemoveReviewer)
 
 	// Stats
 	mux.HandleFunc("/stats/reviewers", h.StatsReviewerAssignments)
+	mux.HandleFunc("/stats/prs", h.StatsPrs)
+
+	// Export
+	mux.HandleFunc("/export/pullRequests", h.ExportPullRequests)
+	mux.HandleFunc("/export/teams", h.ExportTeams)
+	mux.HandleFunc("/export/reviewerStats", h.ExportReviewerStats)
+
+	// Events
+	mux.HandleFunc("/events/stream", h.EventsStream)
+
+	// Admin
+	mux.HandleFunc("/admin/runStaleReviews", h.AdminRunStaleReviews)
+	mux.HandleFunc("/admin/reconcile", h.AdminReconcile)
 
 	return mux
 }
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry - счетчики и текущие значения, отдаются в текстовом формате Prometheus на GET /metrics
+type Registry struct {
+	mu   sync.Mutex
+	vecs []*Vec
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Vec - метрика с одной меткой label, у метрики без меток label пустой
+type Vec struct {
+	name  string
+	help  string
+	kind  string
+	label string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounter регистрирует счетчик, который только растет
+func (r *Registry) NewCounter(name, help, label string) *Vec {
+	return r.register(name, help, "counter", label)
+}
+
+// NewGauge регистрирует значение, которое задается через Set
+func (r *Registry) NewGauge(name, help, label string) *Vec {
+	return r.register(name, help, "gauge", label)
+}
+
+func (r *Registry) register(name, help, kind, label string) *Vec {
+	v := &Vec{name: name, help: help, kind: kind, label: label, values: make(map[string]float64)}
+
+	r.mu.Lock()
+	r.vecs = append(r.vecs, v)
+	r.mu.Unlock()
+	return v
+}
+
+// Inc увеличивает значение с меткой labelValue на 1
+func (v *Vec) Inc(labelValue string) {
+	v.mu.Lock()
+	v.values[labelValue]++
+	v.mu.Unlock()
+}
+
+// Set задает значение с меткой labelValue
+func (v *Vec) Set(labelValue string, value float64) {
+	v.mu.Lock()
+	v.values[labelValue] = value
+	v.mu.Unlock()
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	r.mu.Lock()
+	vecs := append([]*Vec(nil), r.vecs...)
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, v := range vecs {
+		v.write(&b)
+	}
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func (v *Vec) write(b *strings.Builder) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", v.name, v.help, v.name, v.kind)
+
+	// у счетчика без меток значение есть всегда, даже если он еще не рос
+	if v.label == "" && len(v.values) == 0 {
+		fmt.Fprintf(b, "%s 0\n", v.name)
+		return
+	}
+
+	keys := make([]string, 0, len(v.values))
+	for k := range v.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		value := strconv.FormatFloat(v.values[k], 'g', -1, 64)
+		if v.label == "" {
+			fmt.Fprintf(b, "%s %s\n", v.name, value)
+			continue
+		}
+		fmt.Fprintf(b, "%s{%s=%q} %s\n", v.name, v.label, k, value)
+	}
+}
@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"review-manager/internal/dto"
+)
+
+// Message - текст уведомления без привязки к конкретному чату
+type Message struct {
+	Title   string
+	Body    string
+	Context string
+}
+
+// разметка жирного и кода отличается: в Slack mrkdwn *bold*, в Mattermost **bold**
+type markup struct {
+	bold func(string) string
+}
+
+var (
+	slackMarkup      = markup{bold: func(s string) string { return "*" + s + "*" }}
+	mattermostMarkup = markup{bold: func(s string) string { return "**" + s + "**" }}
+)
+
+func code(s string) string {
+	return "`" + s + "`"
+}
+
+// buildMessage собирает уведомление по событию, false - по такому событию в чат не пишем
+func buildMessage(e dto.Event, m markup) (Message, bool) {
+	if e.PR == nil {
+		return Message{}, false
+	}
+	pr := e.PR
+	prRef := fmt.Sprintf("%s (%s)", m.bold(pr.PullRequestName), code(pr.PullRequestID))
+
+	var msg Message
+	switch e.Type {
+	case dto.EventReviewerAssigned:
+		msg = Message{
+			Title: "Review requested",
+			Body:  fmt.Sprintf("%s was assigned to review %s by %s", code(e.UserID), prRef, code(pr.AuthorID)),
+		}
+
+	case dto.EventReviewerReplaced:
+		msg = Message{
+			Title: "Reviewer replaced",
+			Body:  fmt.Sprintf("%s now reviews %s instead of %s", code(e.UserID), prRef, code(e.OldUserID)),
+		}
+
+	case dto.EventPrMerged:
+		msg = Message{
+			Title: "Pull request merged",
+			Body:  fmt.Sprintf("%s by %s was merged", prRef, code(pr.AuthorID)),
+		}
+
+	case dto.EventReviewReminder:
+		msg = Message{
+			Title: "Review is overdue",
+			Body:  fmt.Sprintf("%s, %s is still waiting for your review", code(e.UserID), prRef),
+		}
+
+	// заменить зависшего ревьювера некем, решает лид команды
+	case dto.EventReviewEscalated:
+		msg = Message{
+			Title: "Stale review escalated",
+			Body:  fmt.Sprintf("%s has been waiting for %s too long and nobody can take it over", prRef, code(e.UserID)),
+		}
+		if e.EscalatedTo != "" {
+			msg.Body += fmt.Sprintf(", escalated to team lead %s", code(e.EscalatedTo))
+		}
+
+	// PR без единого ревьювера: не нашлось кандидатов при создании или последнего сняли вручную
+	case dto.EventPrCreated, dto.EventReviewerRemoved:
+		if pr.Status != dto.PrStatusOpen || len(pr.AssignedReviewers) > 0 {
+			return Message{}, false
+		}
+		msg = Message{
+			Title: "Pull request has no reviewers",
+			Body:  fmt.Sprintf("%s by %s is stuck without reviewers, assign someone manually", prRef, code(pr.AuthorID)),
+		}
+
+	default:
+		return Message{}, false
+	}
+
+	reviewers := "none"
+	if len(pr.AssignedReviewers) > 0 {
+		reviewers = strings.Join(pr.AssignedReviewers, ", ")
+	}
+	msg.Context = fmt.Sprintf("team %s · reviewers: %s · version %d", e.TeamName, reviewers, pr.Version)
+
+	return msg, true
+}
+
+// SlackPayload - тело для Slack incoming webhook в формате block-kit, text - фолбэк для уведомлений
+func SlackPayload(msg Message) map[string]any {
+	return map[string]any{
+		"text": msg.Title + ": " + msg.Body,
+		"blocks": []map[string]any{
+			{
+				"type": "header",
+				"text": map[string]any{"type": "plain_text", "text": msg.Title},
+			},
+			{
+				"type": "section",
+				"text": map[string]any{"type": "mrkdwn", "text": msg.Body},
+			},
+			{
+				"type": "context",
+				"elements": []map[string]any{
+					{"type": "mrkdwn", "text": msg.Context},
+				},
+			},
+		},
+	}
+}
+
+// MattermostPayload - Mattermost не понимает blocks, поэтому шлем markdown в text
+func MattermostPayload(msg Message) map[string]any {
+	return map[string]any{
+		"username": "review-manager",
+		"text":     fmt.Sprintf("#### %s\n%s\n_%s_", msg.Title, msg.Body, msg.Context),
+	}
+}
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/events"
+	"review-manager/internal/repository"
+)
+
+// Notifier подписывается на события сервисов и отправляет их в webhook чата команды автора PR
+type Notifier struct {
+	Events   *events.Broker
+	Webhooks repository.WebhookRepo
+	Client   *http.Client
+
+	// webhook для команд без своего, nil - их события не отправляются; меняется на лету
+	fallback atomic.Pointer[repository.TeamWebhook]
+}
+
+func NewNotifier(broker *events.Broker, webhooks repository.WebhookRepo, client *http.Client) *Notifier {
+	return &Notifier{
+		Events:   broker,
+		Webhooks: webhooks,
+		Client:   client,
+	}
+}
+
+// SetFallback задает запасной webhook для команд без своего, пустой url - отключает его
+func (n *Notifier) SetFallback(url, kind string) {
+	if url == "" {
+		n.fallback.Store(nil)
+		return
+	}
+	n.fallback.Store(&repository.TeamWebhook{URL: url, Kind: kind})
+}
+
+// Run рассылает уведомления, пока не отменят ctx. Если брокер отключил нас за медленную
+// отправку, переподписываемся с последнего обработанного id и дочитываем пропущенное из буфера
+func (n *Notifier) Run(ctx context.Context) {
+	lastID := n.Events.LastID()
+
+	for {
+		backlog, sub := n.Events.Subscribe(lastID, events.Filter{})
+		for _, e := range backlog {
+			n.handleLogged(ctx, e)
+			lastID = e.ID
+		}
+
+		lastID = n.consume(ctx, sub, lastID)
+		sub.Close()
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+func (n *Notifier) consume(ctx context.Context, sub *events.Subscription, lastID uint64) uint64 {
+	for {
+		select {
+		case <-ctx.Done():
+			return lastID
+		case e, ok := <-sub.C:
+			if !ok {
+				return lastID
+			}
+			n.handleLogged(ctx, e)
+			lastID = e.ID
+		}
+	}
+}
+
+func (n *Notifier) handleLogged(ctx context.Context, e dto.Event) {
+	if err := n.Handle(ctx, e); err != nil && ctx.Err() == nil {
+		slog.Warn("notifier: event not delivered", "event_id", e.ID, "type", e.Type, "err", err)
+	}
+}
+
+// Handle отправляет уведомление по одному событию в webhook команды или, если его нет, в запасной
+func (n *Notifier) Handle(ctx context.Context, e dto.Event) error {
+	if e.TeamName == "" {
+		return nil
+	}
+
+	hook, err := n.Webhooks.GetByTeamName(ctx, e.TeamName)
+	if errors.Is(err, repository.ErrWebhookNotFound) {
+		fallback := n.fallback.Load()
+		if fallback == nil {
+			return nil
+		}
+		hook, err = *fallback, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var payload map[string]any
+	switch hook.Kind {
+	case dto.WebhookKindMattermost:
+		msg, ok := buildMessage(e, mattermostMarkup)
+		if !ok {
+			return nil
+		}
+		payload = MattermostPayload(msg)
+	default:
+		msg, ok := buildMessage(e, slackMarkup)
+		if !ok {
+			return nil
+		}
+		payload = SlackPayload(msg)
+	}
+
+	return n.post(ctx, hook.URL, payload)
+}
+
+func (n *Notifier) post(ctx context.Context, url string, payload map[string]any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AbsenceRepo interface {
+	// добавляем период недоступности пользователя
+	Add(ctx context.Context, a Absence) (Absence, error)
+
+	// удаляем период недоступности по id
+	Delete(ctx context.Context, absenceID int) error
+
+	// текущие и будущие отсутствия пользователя (ends_at > now), по возрастанию starts_at
+	ListUpcoming(ctx context.Context, userID string, now time.Time) ([]Absence, error)
+
+	// начавшиеся отсутствия, по которым еще не переназначали ревью
+	ListStartedUnprocessed(ctx context.Context, now time.Time) ([]Absence, error)
+
+	// помечаем, что ревью по отсутствию уже переназначены
+	MarkReviewsReassigned(ctx context.Context, absenceID int, at time.Time) error
+}
+
+type PgAbsenceRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgAbsenceRepo(db *pgxpool.Pool) *PgAbsenceRepo {
+	return &PgAbsenceRepo{db: db}
+}
+
+func (r *PgAbsenceRepo) Add(ctx context.Context, a Absence) (Absence, error) {
+	const q = `
+		INSERT INTO user_absences (user_id, starts_at, ends_at, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`
+	db := currentDB(ctx, r.db)
+
+	if err := db.QueryRow(ctx, q, a.UserID, a.StartsAt, a.EndsAt, a.Reason).Scan(&a.ID); err != nil {
+		return Absence{}, err
+	}
+	return a, nil
+}
+
+func (r *PgAbsenceRepo) Delete(ctx context.Context, absenceID int) error {
+	const q = `DELETE FROM user_absences WHERE id = $1`
+
+	db := currentDB(ctx, r.db)
+
+	ct, err := db.Exec(ctx, q, absenceID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrAbsenceNotFound
+	}
+	return nil
+}
+
+func (r *PgAbsenceRepo) ListUpcoming(ctx context.Context, userID string, now time.Time) ([]Absence, error) {
+	const q = `
+		SELECT id, user_id, starts_at, ends_at, reason, reviews_reassigned_at
+		FROM user_absences
+		WHERE user_id = $1
+		AND ends_at > $2
+		ORDER BY starts_at, id
+	`
+	return r.list(ctx, q, userID, now)
+}
+
+func (r *PgAbsenceRepo) ListStartedUnprocessed(ctx context.Context, now time.Time) ([]Absence, error) {
+	const q = `
+		SELECT id, user_id, starts_at, ends_at, reason, reviews_reassigned_at
+		FROM user_absences
+		WHERE starts_at <= $1
+		AND ends_at > $1
+		AND reviews_reassigned_at IS NULL
+		ORDER BY starts_at, id
+	`
+	return r.list(ctx, q, now)
+}
+
+func (r *PgAbsenceRepo) MarkReviewsReassigned(ctx context.Context, absenceID int, at time.Time) error {
+	const q = `UPDATE user_absences SET reviews_reassigned_at = $2 WHERE id = $1`
+
+	db := currentDB(ctx, r.db)
+
+	ct, err := db.Exec(ctx, q, absenceID, at)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrAbsenceNotFound
+	}
+	return nil
+}
+
+func (r *PgAbsenceRepo) list(ctx context.Context, q string, args ...any) ([]Absence, error) {
+	rows, err := r.db.Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Absence
+	for rows.Next() {
+		var a Absence
+		if err := rows.Scan(&a.ID, &a.UserID, &a.StartsAt, &a.EndsAt, &a.Reason, &a.ReviewsReassignedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, rows.Err()
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ArchiveRepo interface {
+	// переносим в архив до limit смерженных раньше mergedBefore PR вместе с ревьюверами
+	// и возвращаем их id; вызывать внутри транзакции, иначе перенос не атомарен
+	ArchiveMerged(ctx context.Context, mergedBefore time.Time, limit int) ([]string, error)
+}
+
+type PgArchiveRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgArchiveRepo(db *pgxpool.Pool) *PgArchiveRepo {
+	return &PgArchiveRepo{db: db}
+}
+
+func (r *PgArchiveRepo) ArchiveMerged(ctx context.Context, mergedBefore time.Time, limit int) ([]string, error) {
+	// PR, которые сейчас меняет другая транзакция, пропускаем до следующего прогона
+	const qPick = `
+		SELECT pull_request_id
+		FROM pull_requests
+		WHERE status_id = $1 AND merged_at < $2
+		ORDER BY merged_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED
+	`
+
+	const qPRs = `
+		INSERT INTO pull_requests_archive (
+			pull_request_id,
+			pull_request_name,
+			author_id,
+			status_id,
+			created_at,
+			merged_at,
+			need_more_reviewers,
+			reassign_count,
+			initial_reviewers_count,
+			version)
+		SELECT pull_request_id,
+			pull_request_name,
+			author_id,
+			status_id,
+			created_at,
+			merged_at,
+			need_more_reviewers,
+			reassign_count,
+			initial_reviewers_count,
+			version
+		FROM pull_requests
+		WHERE pull_request_id = ANY($1)
+	`
+
+	const qReviewers = `
+		INSERT INTO pull_request_reviewers_archive (pull_request_id, reviewer_id, assigned_at, team_id)
+		SELECT pull_request_id, reviewer_id, assigned_at, team_id
+		FROM pull_request_reviewers
+		WHERE pull_request_id = ANY($1)
+	`
+
+	// ревьюверы удаляются каскадом
+	const qDelete = `DELETE FROM pull_requests WHERE pull_request_id = ANY($1)`
+
+	db := currentDB(ctx, r.db)
+
+	rows, err := db.Query(ctx, qPick, StatusMerged, mergedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// сначала PR, потом ревьюверы - на архив PR ссылается внешний ключ
+	if _, err := db.Exec(ctx, qPRs, ids); err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(ctx, qReviewers, ids); err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(ctx, qDelete, ids); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
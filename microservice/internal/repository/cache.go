@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReadCache - кэш чтений в памяти процесса с TTL. Записи редкие, поэтому любая запись через
+// кэширующие репозитории сбрасывает его целиком, а не разбирает, какие ключи она задела.
+// Другие реплики сервиса про запись не узнают и отдают старое не дольше TTL
+type ReadCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	gen     uint64
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   any
+	expires time.Time
+}
+
+func NewReadCache(ttl time.Duration) *ReadCache {
+	return &ReadCache{ttl: ttl, entries: make(map[string]cacheEntry)}
+}
+
+// Invalidate сбрасывает кэш
+func (c *ReadCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	clear(c.entries)
+}
+
+// сброс после коммита: если сбросить раньше, параллельное чтение успеет закэшировать еще не измененные данные
+func (c *ReadCache) invalidateAfterCommit(ctx context.Context) {
+	AfterCommit(ctx, c.Invalidate)
+}
+
+// cached отдает значение по key из кэша или из load. В транзакции и при WithPrimary кэш не используется:
+// там нужны свежие данные. Ошибки не кэшируются. Отданные значения общие, менять их нельзя
+func cached[T any](ctx context.Context, c *ReadCache, key string, load func() (T, error)) (T, error) {
+	if _, ok := txFromContext(ctx); ok || ctx.Value(primaryKey{}) != nil {
+		return load()
+	}
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	gen := c.gen
+	c.mu.Unlock()
+
+	if ok && time.Now().Before(e.expires) {
+		return e.value.(T), nil
+	}
+
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+
+	// пока читали, кэш могли сбросить - тогда прочитанное уже может быть устаревшим
+	c.mu.Lock()
+	if c.gen == gen {
+		c.entries[key] = cacheEntry{value: v, expires: time.Now().Add(c.ttl)}
+	}
+	c.mu.Unlock()
+
+	return v, nil
+}
+
+// CachedTeamRepo кэширует чтения команды для /team/get
+type CachedTeamRepo struct {
+	TeamRepo
+	cache *ReadCache
+}
+
+func NewCachedTeamRepo(next TeamRepo, cache *ReadCache) *CachedTeamRepo {
+	return &CachedTeamRepo{TeamRepo: next, cache: cache}
+}
+
+func (r *CachedTeamRepo) GetByName(ctx context.Context, teamName string) (Team, error) {
+	return cached(ctx, r.cache, "team:"+teamName, func() (Team, error) {
+		return r.TeamRepo.GetByName(ctx, teamName)
+	})
+}
+
+func (r *CachedTeamRepo) ListAncestors(ctx context.Context, teamID int) ([]Team, error) {
+	return cached(ctx, r.cache, "team-ancestors:"+strconv.Itoa(teamID), func() ([]Team, error) {
+		return r.TeamRepo.ListAncestors(ctx, teamID)
+	})
+}
+
+func (r *CachedTeamRepo) ListChildren(ctx context.Context, teamID int) ([]Team, error) {
+	return cached(ctx, r.cache, "team-children:"+strconv.Itoa(teamID), func() ([]Team, error) {
+		return r.TeamRepo.ListChildren(ctx, teamID)
+	})
+}
+
+func (r *CachedTeamRepo) Create(ctx context.Context, teamName string) (Team, error) {
+	t, err := r.TeamRepo.Create(ctx, teamName)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return t, err
+}
+
+func (r *CachedTeamRepo) SetDefaultMaxOpenReviews(ctx context.Context, teamName string, limit *int) (Team, error) {
+	t, err := r.TeamRepo.SetDefaultMaxOpenReviews(ctx, teamName, limit)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return t, err
+}
+
+func (r *CachedTeamRepo) SetReviewSla(ctx context.Context, teamName string, slaHours, escalationHours *int) (Team, error) {
+	t, err := r.TeamRepo.SetReviewSla(ctx, teamName, slaHours, escalationHours)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return t, err
+}
+
+func (r *CachedTeamRepo) SetLead(ctx context.Context, teamName string, leadUserID, tokenHash *string) (Team, error) {
+	t, err := r.TeamRepo.SetLead(ctx, teamName, leadUserID, tokenHash)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return t, err
+}
+
+func (r *CachedTeamRepo) SetParent(ctx context.Context, teamName string, parentTeamID *int) (Team, error) {
+	t, err := r.TeamRepo.SetParent(ctx, teamName, parentTeamID)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return t, err
+}
+
+// CachedUserRepo кэширует участников команды для /team/get и статистику для /stats/reviewers
+type CachedUserRepo struct {
+	UserRepo
+	cache *ReadCache
+}
+
+func NewCachedUserRepo(next UserRepo, cache *ReadCache) *CachedUserRepo {
+	return &CachedUserRepo{UserRepo: next, cache: cache}
+}
+
+func (r *CachedUserRepo) ListByTeam(ctx context.Context, teamID int) ([]User, error) {
+	return cached(ctx, r.cache, "team-members:"+strconv.Itoa(teamID), func() ([]User, error) {
+		return r.UserRepo.ListByTeam(ctx, teamID)
+	})
+}
+
+func (r *CachedUserRepo) GetReviewerStats(ctx context.Context) ([]ReviewerStatRow, error) {
+	return cached(ctx, r.cache, "reviewer-stats", func() ([]ReviewerStatRow, error) {
+		return r.UserRepo.GetReviewerStats(ctx)
+	})
+}
+
+func (r *CachedUserRepo) SetIsActive(ctx context.Context, userID string, isActive bool) (User, error) {
+	u, err := r.UserRepo.SetIsActive(ctx, userID, isActive)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return u, err
+}
+
+func (r *CachedUserRepo) UpsertTeamMembers(ctx context.Context, teamID int, members []User) error {
+	err := r.UserRepo.UpsertTeamMembers(ctx, teamID, members)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return err
+}
+
+func (r *CachedUserRepo) SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (User, error) {
+	u, err := r.UserRepo.SetMaxOpenReviews(ctx, userID, limit)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return u, err
+}
+
+func (r *CachedUserRepo) SoftDelete(ctx context.Context, userID string, at time.Time) error {
+	err := r.UserRepo.SoftDelete(ctx, userID, at)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return err
+}
+
+func (r *CachedUserRepo) Restore(ctx context.Context, userID string) (User, error) {
+	u, err := r.UserRepo.Restore(ctx, userID)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return u, err
+}
+
+// CachedArchiveRepo сбрасывает кэш после переноса PR в архив, чтобы статистика ревьюверов
+// и /team/get не отдавали посчитанное до архивации до истечения TTL
+type CachedArchiveRepo struct {
+	ArchiveRepo
+	cache *ReadCache
+}
+
+func NewCachedArchiveRepo(next ArchiveRepo, cache *ReadCache) *CachedArchiveRepo {
+	return &CachedArchiveRepo{ArchiveRepo: next, cache: cache}
+}
+
+func (r *CachedArchiveRepo) ArchiveMerged(ctx context.Context, mergedBefore time.Time, limit int) ([]string, error) {
+	ids, err := r.ArchiveRepo.ArchiveMerged(ctx, mergedBefore, limit)
+	if err == nil && len(ids) > 0 {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return ids, err
+}
+
+// CachedPrRepo ничего не кэширует, а только сбрасывает кэш при изменении ревьюверов и статуса PR:
+// от них зависят открытые ревью участников в /team/get и статистика ревьюверов
+type CachedPrRepo struct {
+	PrRepo
+	cache *ReadCache
+}
+
+func NewCachedPrRepo(next PrRepo, cache *ReadCache) *CachedPrRepo {
+	return &CachedPrRepo{PrRepo: next, cache: cache}
+}
+
+func (r *CachedPrRepo) Insert(ctx context.Context, pr PullRequest) error {
+	err := r.PrRepo.Insert(ctx, pr)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return err
+}
+
+func (r *CachedPrRepo) AddReviewers(ctx context.Context, prID string, reviewerIDs []string) error {
+	err := r.PrRepo.AddReviewers(ctx, prID, reviewerIDs)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return err
+}
+
+func (r *CachedPrRepo) ReplaceReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error {
+	err := r.PrRepo.ReplaceReviewer(ctx, prID, oldReviewerID, newReviewerID)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return err
+}
+
+func (r *CachedPrRepo) RemoveReviewer(ctx context.Context, prID, reviewerID string) error {
+	err := r.PrRepo.RemoveReviewer(ctx, prID, reviewerID)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return err
+}
+
+func (r *CachedPrRepo) SetMerged(ctx context.Context, prID string, mergedAt time.Time) (PullRequest, error) {
+	pr, err := r.PrRepo.SetMerged(ctx, prID, mergedAt)
+	if err == nil {
+		r.cache.invalidateAfterCommit(ctx)
+	}
+	return pr, err
+}
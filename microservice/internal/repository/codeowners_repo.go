@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CodeownersRepo interface {
+	// заменяем все правила команды на новые (вызывать внутри транзакции)
+	ReplaceForTeam(ctx context.Context, teamID int, rules []CodeownersRule) error
+
+	// правила команды в порядке строк файла
+	ListByTeam(ctx context.Context, teamID int) ([]CodeownersRule, error)
+}
+
+type PgCodeownersRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgCodeownersRepo(db *pgxpool.Pool) *PgCodeownersRepo {
+	return &PgCodeownersRepo{db: db}
+}
+
+func (r *PgCodeownersRepo) ReplaceForTeam(ctx context.Context, teamID int, rules []CodeownersRule) error {
+	const qDel = `DELETE FROM codeowners_rules WHERE team_id = $1`
+	const qIns = `
+		INSERT INTO codeowners_rules (team_id, line, pattern, owners)
+		VALUES ($1, $2, $3, $4)
+	`
+	db := currentDB(ctx, r.db)
+
+	if _, err := db.Exec(ctx, qDel, teamID); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if _, err := db.Exec(ctx, qIns, teamID, rule.Line, rule.Pattern, rule.Owners); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PgCodeownersRepo) ListByTeam(ctx context.Context, teamID int) ([]CodeownersRule, error) {
+	const q = `
+		SELECT line, pattern, owners
+		FROM codeowners_rules
+		WHERE team_id = $1
+		ORDER BY line
+	`
+
+	rows, err := r.db.Query(ctx, q, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []CodeownersRule
+	for rows.Next() {
+		var rule CodeownersRule
+		if err := rows.Scan(&rule.Line, &rule.Pattern, &rule.Owners); err != nil {
+			return nil, err
+		}
+		res = append(res, rule)
+	}
+	return res, rows.Err()
+}
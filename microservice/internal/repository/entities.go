@@ -6,26 +6,56 @@ import (
 )
 
 const (
-	uniqueViolationErr = "23505"
-	StatusOpen         = 1
-	StatusMerged       = 2
+	uniqueViolationErr     = "23505"
+	foreignKeyViolationErr = "23503"
+	StatusOpen             = 1
+	StatusMerged           = 2
+
+	// сколько ревьюверов должно быть у PR, меньше - need_more_reviewers
+	RequiredReviewers = 2
+
+	// предел глубины иерархии команд при обходе родителей
+	MaxTeamDepth = 32
 )
 
 // Ошибки для работы с СУБД
 var (
-	ErrTeamExists     = fmt.Errorf("team_name already exists")
-	ErrTeamNotFound   = fmt.Errorf("resource not found")
-	ErrUserNotFound   = fmt.Errorf("resource not found")
-	ErrPRNotFound     = fmt.Errorf("resource not found")
-	ErrNoCandidate    = fmt.Errorf("no active candidate in team")
-	ErrReviewerNotSet = fmt.Errorf("reviewer is not assigned to this PR")
-	ErrPRMerged       = fmt.Errorf("cannot reassign on merged PR")
-	ErrPRExists       = fmt.Errorf("PR id already exists")
+	ErrTeamExists      = fmt.Errorf("team_name already exists")
+	ErrTeamNotFound    = fmt.Errorf("team not found")
+	ErrUserNotFound    = fmt.Errorf("user not found")
+	ErrUserDeleted     = fmt.Errorf("user is deleted, restore it with /users/restore first")
+	ErrPRNotFound      = fmt.Errorf("pull request not found")
+	ErrNoCandidate     = fmt.Errorf("no active candidate in team")
+	ErrAtCapacity      = fmt.Errorf("%w: all candidates are at review capacity", ErrNoCandidate)
+	ErrReviewerNotSet  = fmt.Errorf("reviewer is not assigned to this PR")
+	ErrPRMerged        = fmt.Errorf("cannot reassign on merged PR")
+	ErrPRExists        = fmt.Errorf("PR id already exists")
+	ErrAbsenceNotFound = fmt.Errorf("absence not found")
+	ErrAlreadyReviewer = fmt.Errorf("user is already a reviewer of this PR")
+	ErrReviewerAuthor  = fmt.Errorf("author cannot review own PR")
+	ErrUserInactive    = fmt.Errorf("user is inactive")
+	ErrUserAbsent      = fmt.Errorf("user is absent right now")
+	ErrUserAtCapacity  = fmt.Errorf("user has reached max open reviews")
+	ErrVersionConflict = fmt.Errorf("PR was modified concurrently, version mismatch")
+	ErrWebhookNotFound = fmt.Errorf("webhook not found")
+	ErrTeamCycle       = fmt.Errorf("parent team would create a cycle in team hierarchy")
+	ErrLeaseLost       = fmt.Errorf("idempotency key lease expired and was taken by another request")
 )
 
 type Team struct {
-	ID   int
-	Name string
+	ID                    int
+	Name                  string
+	DefaultMaxOpenReviews *int
+
+	// пороги зависшего ревью в часах, nil - значения по умолчанию
+	ReviewSlaHours  *int
+	EscalationHours *int
+
+	// лид команды и sha256 его токена, родительская команда
+	LeadUserID     *string
+	LeadTokenHash  *string
+	ParentTeamID   *int
+	ParentTeamName *string
 }
 
 type User struct {
@@ -34,6 +64,10 @@ type User struct {
 	TeamID   int
 	TeamName string
 	IsActive bool
+
+	// лимит открытых ревью пользователя (nil - берется лимит команды) и текущая загрузка
+	MaxOpenReviews *int
+	OpenReviews    int
 }
 
 type TeamWithMembers struct {
@@ -42,13 +76,16 @@ type TeamWithMembers struct {
 }
 
 type PullRequest struct {
-	ID         string
-	Name       string
-	AuthorID   string
-	StatusID   int
-	StatusName string
-	CreatedAt  time.Time
-	MergedAt   *time.Time
+	ID                string
+	Name              string
+	AuthorID          string
+	StatusID          int
+	StatusName        string
+	CreatedAt         time.Time
+	MergedAt          *time.Time
+	NeedMoreReviewers bool
+	InitialReviewers  int
+	Version           int64
 }
 
 type PullRequestShort struct {
@@ -56,6 +93,39 @@ type PullRequestShort struct {
 	Name       string
 	AuthorID   string
 	StatusName string
+	CreatedAt  time.Time
+
+	// остальные ревьюверы PR, кроме того, для кого строилась выборка
+	OtherReviewers []string
+}
+
+// фильтры и страница для /users/getReview. Статусы пустые - любые, Limit 0 - без ограничения.
+// Страницы идут по (created_at, pull_request_id) от новых к старым, After* - последняя строка прошлой страницы
+type ReviewPrFilter struct {
+	UserID          string
+	Statuses        []string
+	IncludeArchived bool
+	Limit           int
+	AfterCreatedAt  *time.Time
+	AfterID         string
+}
+
+// PR автора для /users/getAuthored вместе с ревьюверами
+type AuthoredPr struct {
+	PR        PullRequest
+	Reviewers []PrReviewerState
+}
+
+// текущее состояние ревьювера PR. IsActive - активен и не удален,
+// MovedTeam - с момента назначения перешел в другую команду
+type PrReviewerState struct {
+	UserID     string
+	Username   string
+	TeamName   string
+	IsActive   bool
+	IsAbsent   bool
+	MovedTeam  bool
+	AssignedAt time.Time
 }
 
 type ReviewerStatRow struct {
@@ -63,3 +133,112 @@ type ReviewerStatRow struct {
 	Username     string
 	ReviewsCount int
 }
+
+type TeamPrStatRow struct {
+	TeamName    string
+	OpenCount   int
+	MergedCount int
+}
+
+type AuthorPrStatRow struct {
+	AuthorID    string
+	Username    string
+	OpenCount   int
+	MergedCount int
+}
+
+type PrReassignStatRow struct {
+	PullRequestID string
+	ReassignCount int
+}
+
+// сырые данные для /stats/prs, время мержа в секундах
+type PrStatsRows struct {
+	ByTeam            []TeamPrStatRow
+	ByAuthor          []AuthorPrStatRow
+	MergeMedianSec    *float64
+	MergeP90Sec       *float64
+	Reassignments     []PrReassignStatRow
+	TotalPrs          int
+	UnderstaffedCount int
+}
+
+// фильтры выгрузки PR, пустые значения не фильтруют
+type PrFilter struct {
+	StatusID   int
+	TeamName   string
+	AuthorID   string
+	ReviewerID string
+
+	// добавить PR из архива
+	IncludeArchived bool
+}
+
+type PullRequestExportRow struct {
+	PR        PullRequest
+	TeamName  string
+	Reviewers []string
+}
+
+type TeamMemberExportRow struct {
+	TeamName string
+	UserID   string
+	Username string
+	IsActive bool
+}
+
+type Absence struct {
+	ID                  int
+	UserID              string
+	StartsAt            time.Time
+	EndsAt              time.Time
+	Reason              string
+	ReviewsReassignedAt *time.Time
+}
+
+type CodeownersRule struct {
+	Line    int
+	Pattern string
+	Owners  []string
+}
+
+// сохраненный ответ на запрос с Idempotency-Key
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	LeaseID     string // выдается при резерве, с ним вызываются Complete и Release
+	StatusCode  *int
+	Headers     map[string]string
+	Body        []byte
+}
+
+// webhook чата команды для уведомлений
+type TeamWebhook struct {
+	TeamID   int
+	TeamName string
+	URL      string
+	Kind     string
+}
+
+// назначение ревьювера на открытый PR, по которому пора напомнить или эскалировать.
+// SLA и EscalateAfter - пороги команды автора PR с учетом значений по умолчанию
+type StaleAssignment struct {
+	PullRequestID string
+	ReviewerID    string
+	TeamName      string
+	AssignedAt    time.Time
+	RemindedAt    *time.Time
+	EscalatedAt   *time.Time
+	SLA           time.Duration
+	EscalateAfter time.Duration
+}
+
+// открытый PR, который нужно чинить. Inactive - выключенные или удаленные ревьюверы,
+// Moved - сменившие команду после назначения, TeamName - команда автора
+type BrokenPr struct {
+	PullRequestID string
+	TeamName      string
+	Reviewers     []string
+	Inactive      []string
+	Moved         []string
+}
@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Выгрузки читаются курсором pgx построчно и сразу отдаются в fn,
+// так что вся выборка в памяти не держится
+type ExportRepo interface {
+	// PR вместе с ревьюверами и командой автора
+	StreamPullRequests(ctx context.Context, f PrFilter, fn func(PullRequestExportRow) error) error
+
+	// участники команд, по одной строке на участника; пустой teamName - все команды
+	StreamTeamMembers(ctx context.Context, teamName string, fn func(TeamMemberExportRow) error) error
+
+	// количество назначений ревьювером на пользователя
+	StreamReviewerStats(ctx context.Context, teamName string, fn func(ReviewerStatRow) error) error
+}
+
+type PgExportRepo struct {
+	db               *pgxpool.Pool
+	reads            *ReadRouter
+	statementTimeout time.Duration
+}
+
+// statementTimeout - свой лимит на запросы выгрузок вместо общего для пула, 0 - без ограничения.
+// Большая выгрузка медленному клиенту легко не укладывается в лимит обычных запросов,
+// поэтому общий statement_timeout пула на выгрузки не действует никогда.
+// Выгрузки читаются с реплик из reads, nil - с основной БД
+func NewPgExportRepo(db *pgxpool.Pool, reads *ReadRouter, statementTimeout time.Duration) *PgExportRepo {
+	return &PgExportRepo{db: db, reads: reads, statementTimeout: statementTimeout}
+}
+
+// query выполняет запрос выгрузки и отдает строки в scan. Свой statement_timeout ставится через SET LOCAL
+// в read-only транзакции, чтобы не остался на соединении пула
+func (r *PgExportRepo) query(ctx context.Context, scan func(pgx.Rows) error, sql string, args ...any) error {
+	pool := r.reads.ReadPool(ctx, r.db)
+
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// '0' снимает лимит пула на время транзакции
+	ms := strconv.FormatInt(r.statementTimeout.Milliseconds(), 10)
+	if _, err := tx.Exec(ctx, `SELECT set_config('statement_timeout', $1, true)`, ms); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if err := scan(rows); err != nil {
+		return err
+	}
+	return rows.Err()
+}
+
+func (r *PgExportRepo) StreamPullRequests(ctx context.Context, f PrFilter, fn func(PullRequestExportRow) error) error {
+	// архивные PR подмешиваются только при IncludeArchived. У prs нет первичного ключа,
+	// поэтому ревьюверов собираем подзапросом на каждый PR, а не GROUP BY по всей выборке
+	const q = `
+		WITH prs AS (
+			SELECT pull_request_id, pull_request_name, author_id, status_id, created_at, merged_at
+			FROM pull_requests
+			UNION ALL
+			SELECT pull_request_id, pull_request_name, author_id, status_id, created_at, merged_at
+			FROM pull_requests_archive
+			WHERE $5
+		), revs AS (
+			SELECT pull_request_id, reviewer_id FROM pull_request_reviewers
+			UNION ALL
+			SELECT pull_request_id, reviewer_id FROM pull_request_reviewers_archive
+			WHERE $5
+		)
+		SELECT pr.pull_request_id,
+			pr.pull_request_name,
+			pr.author_id,
+			pr.status_id,
+			s.name AS status_name,
+			pr.created_at,
+			pr.merged_at,
+			t.team_name,
+			COALESCE(rv.reviewers, '{}') AS reviewers
+		FROM prs pr
+		JOIN pr_statuses s ON s.id = pr.status_id
+		JOIN users u ON u.user_id = pr.author_id
+		JOIN teams t ON t.id = u.team_id
+		LEFT JOIN LATERAL (
+			SELECT array_agg(r.reviewer_id ORDER BY r.reviewer_id) AS reviewers
+			FROM revs r
+			WHERE r.pull_request_id = pr.pull_request_id
+		) rv ON true
+		WHERE ($1 = 0 OR pr.status_id = $1)
+		AND ($2 = '' OR t.team_name = $2)
+		AND ($3 = '' OR pr.author_id = $3)
+		AND ($4 = '' OR EXISTS (
+			SELECT 1 FROM revs rr
+			WHERE rr.pull_request_id = pr.pull_request_id AND rr.reviewer_id = $4
+		))
+		ORDER BY pr.created_at, pr.pull_request_id
+	`
+
+	return r.query(ctx, func(rows pgx.Rows) error {
+		for rows.Next() {
+			var (
+				row      PullRequestExportRow
+				mergedAt *time.Time
+			)
+			if err := rows.Scan(
+				&row.PR.ID,
+				&row.PR.Name,
+				&row.PR.AuthorID,
+				&row.PR.StatusID,
+				&row.PR.StatusName,
+				&row.PR.CreatedAt,
+				&mergedAt,
+				&row.TeamName,
+				&row.Reviewers,
+			); err != nil {
+				return err
+			}
+			row.PR.MergedAt = mergedAt
+
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, q, f.StatusID, f.TeamName, f.AuthorID, f.ReviewerID, f.IncludeArchived)
+}
+
+func (r *PgExportRepo) StreamTeamMembers(ctx context.Context, teamName string, fn func(TeamMemberExportRow) error) error {
+	const q = `
+		SELECT t.team_name, u.user_id, u.username, u.is_active
+		FROM teams t
+		JOIN users u ON u.team_id = t.id
+		WHERE ($1 = '' OR t.team_name = $1)
+		AND u.deleted_at IS NULL
+		ORDER BY t.team_name, u.user_id
+	`
+
+	return r.query(ctx, func(rows pgx.Rows) error {
+		for rows.Next() {
+			var row TeamMemberExportRow
+			if err := rows.Scan(&row.TeamName, &row.UserID, &row.Username, &row.IsActive); err != nil {
+				return err
+			}
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, q, teamName)
+}
+
+func (r *PgExportRepo) StreamReviewerStats(ctx context.Context, teamName string, fn func(ReviewerStatRow) error) error {
+	const q = reviewerStatsSource + `
+		SELECT u.user_id,
+			u.username,
+			COUNT(rpr.pull_request_id) AS reviews_count
+		FROM users u
+		JOIN teams t ON t.id = u.team_id
+		LEFT JOIN revs rpr
+			ON rpr.reviewer_id = u.user_id
+		WHERE ($1 = '' OR t.team_name = $1)
+		AND u.deleted_at IS NULL
+		GROUP BY u.user_id, u.username
+		ORDER BY reviews_count DESC, u.user_id
+	`
+
+	return r.query(ctx, func(rows pgx.Rows) error {
+		for rows.Next() {
+			var row ReviewerStatRow
+			if err := rows.Scan(&row.UserID, &row.Username, &row.ReviewsCount); err != nil {
+				return err
+			}
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, q, teamName)
+}
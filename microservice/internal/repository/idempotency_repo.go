@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type IdempotencyRepo interface {
+	// резервируем ключ под новый запрос до expiresAt и выдаем LeaseID аренды.
+	// Если живой ключ уже есть - возвращаем его запись и false
+	Reserve(ctx context.Context, key, requestHash string, now, expiresAt time.Time) (IdempotencyRecord, bool, error)
+
+	// сохраняем ответ для ключа и продлеваем ключ до expiresAt. Если аренда leaseID истекла
+	// и ключ перезанят, ничего не меняем и возвращаем ErrLeaseLost
+	Complete(ctx context.Context, key, leaseID string, status int, headers map[string]string, body []byte, expiresAt time.Time) error
+
+	// снимаем резерв, если ответ не стоит повторять (5xx и т.п.); чужую аренду не трогаем
+	Release(ctx context.Context, key, leaseID string) error
+
+	// удаляем истекшие ключи, возвращаем сколько удалили
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type PgIdempotencyRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgIdempotencyRepo(db *pgxpool.Pool) *PgIdempotencyRepo {
+	return &PgIdempotencyRepo{db: db}
+}
+
+func (r *PgIdempotencyRepo) Reserve(
+	ctx context.Context,
+	key, requestHash string,
+	now, expiresAt time.Time,
+) (IdempotencyRecord, bool, error) {
+	// истекший ключ перезанимаем сразу, не дожидаясь очистки. Живой ключ ON CONFLICT не меняет,
+	// но блокирует до конца транзакции, поэтому qGet ниже его увидит, даже если он истечет между запросами
+	const qReserve = `
+		INSERT INTO idempotency_keys (idem_key, request_hash, lease_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (idem_key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+			lease_id = EXCLUDED.lease_id,
+			status_code = NULL,
+			headers = '{}',
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		RETURNING idem_key
+	`
+
+	const qGet = `
+		SELECT idem_key, request_hash, COALESCE(lease_id, ''), status_code, headers, response_body
+		FROM idempotency_keys
+		WHERE idem_key = $1
+	`
+
+	leaseID, err := newLeaseID()
+	if err != nil {
+		return IdempotencyRecord{}, false, err
+	}
+
+	var (
+		rec      IdempotencyRecord
+		reserved bool
+	)
+	err = pgx.BeginFunc(ctx, r.db, func(tx pgx.Tx) error {
+		var reservedKey string
+		err := tx.QueryRow(ctx, qReserve, key, requestHash, leaseID, now, expiresAt).Scan(&reservedKey)
+		if err == nil {
+			rec, reserved = IdempotencyRecord{Key: key, RequestHash: requestHash, LeaseID: leaseID}, true
+			return nil
+		}
+		if err != pgx.ErrNoRows {
+			return err
+		}
+
+		return tx.QueryRow(ctx, qGet, key).Scan(
+			&rec.Key,
+			&rec.RequestHash,
+			&rec.LeaseID,
+			&rec.StatusCode,
+			&rec.Headers,
+			&rec.Body,
+		)
+	})
+	if err != nil {
+		return IdempotencyRecord{}, false, err
+	}
+	return rec, reserved, nil
+}
+
+func (r *PgIdempotencyRepo) Complete(
+	ctx context.Context,
+	key, leaseID string,
+	status int,
+	headers map[string]string,
+	body []byte,
+	expiresAt time.Time,
+) error {
+	const q = `
+		UPDATE idempotency_keys
+		SET status_code = $3,
+			headers = $4,
+			response_body = $5,
+			expires_at = $6
+		WHERE idem_key = $1 AND lease_id = $2 AND status_code IS NULL
+	`
+
+	ct, err := r.db.Exec(ctx, q, key, leaseID, status, headers, body, expiresAt)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func (r *PgIdempotencyRepo) Release(ctx context.Context, key, leaseID string) error {
+	const q = `DELETE FROM idempotency_keys WHERE idem_key = $1 AND lease_id = $2 AND status_code IS NULL`
+
+	_, err := r.db.Exec(ctx, q, key, leaseID)
+	return err
+}
+
+func (r *PgIdempotencyRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	const q = `DELETE FROM idempotency_keys WHERE expires_at <= $1`
+
+	ct, err := r.db.Exec(ctx, q, now)
+	if err != nil {
+		return 0, err
+	}
+	return ct.RowsAffected(), nil
+}
+
+// случайный id аренды ключа
+func newLeaseID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Ключи advisory-блокировок фоновых задач, общие для всех реплик сервиса
+const (
+	LockKeyStaleReviews int64 = 1001
+	LockKeyArchive      int64 = 1002
+	LockKeyReconcile    int64 = 1003
+	LockKeyAbsence      int64 = 1004
+)
+
+type Locker interface {
+	// пытаемся взять advisory-блокировку по ключу без ожидания, ok=false - ее держит другая реплика.
+	// release снимает блокировку, вызывать обязательно при ok=true
+	TryLock(ctx context.Context, key int64) (release func(), ok bool, err error)
+}
+
+type PgLocker struct {
+	db *pgxpool.Pool
+}
+
+func NewPgLocker(db *pgxpool.Pool) *PgLocker {
+	return &PgLocker{db: db}
+}
+
+func (l *PgLocker) TryLock(ctx context.Context, key int64) (func(), bool, error) {
+	// блокировка сессионная, поэтому держим одно соединение до release
+	conn, err := l.db.Acquire(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var ok bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&ok); err != nil {
+		conn.Release()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	release := func() {
+		// ctx задачи к этому моменту может быть уже отменен
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			slog.Error("advisory unlock", "key", key, "err", err)
+			// соединение с висящей блокировкой в пул не возвращаем
+			_ = conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}
+	return release, true, nil
+}
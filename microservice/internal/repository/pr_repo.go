@@ -5,11 +5,12 @@ import (
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type PrRepo interface {
-	// проверяем, существует ли PR с таким идентификатором
+	// проверяем, существует ли PR с таким идентификатором, в том числе в архиве
 	Exists(ctx context.Context, prID string) (bool, error)
 
 	// создаем новый PR в базе без назначения ревьюверов
@@ -21,23 +22,44 @@ type PrRepo interface {
 	// возвращаем PR и список его ревьюверов по идентификатору
 	GetWithReviewers(ctx context.Context, prID string) (PullRequest, []string, error)
 
-	// идемпотентно помечает PR как MERGED и устанавливает merged_at
+	// то же, что GetWithReviewers, но блокирует строку PR (SELECT ... FOR UPDATE) до конца транзакции
+	GetForUpdate(ctx context.Context, prID string) (PullRequest, []string, error)
+
+	// идемпотентно помечает PR как MERGED и устанавливает merged_at, версия растет только при смене статуса
 	SetMerged(ctx context.Context, prID string, mergedAt time.Time) (PullRequest, error)
 
 	// заменяем одного ревьювера на другого в пределах одного PR
 	ReplaceReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error
+
+	// снимаем ревьювера с PR без замены
+	RemoveReviewer(ctx context.Context, prID, reviewerID string) error
+
+	// пересчитываем need_more_reviewers по текущему количеству ревьюверов и увеличиваем версию PR
+	RefreshNeedMoreReviewers(ctx context.Context, prID string) error
+
+	// доп задание: статистика по PR для /stats/prs
+	GetPrStats(ctx context.Context) (PrStatsRows, error)
+
+	// PR автора от новых к старым вместе с текущим состоянием их ревьюверов
+	ListByAuthor(ctx context.Context, authorID string) ([]AuthoredPr, error)
 }
 
 type PgPrRepo struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	reads *ReadRouter
 }
 
-func NewPgPrRepo(db *pgxpool.Pool) *PgPrRepo {
-	return &PgPrRepo{db: db}
+// reads - куда отправлять запросы списков и статистики, nil - все на основную БД
+func NewPgPrRepo(db *pgxpool.Pool, reads *ReadRouter) *PgPrRepo {
+	return &PgPrRepo{db: db, reads: reads}
 }
 
 func (r *PgPrRepo) Exists(ctx context.Context, prID string) (bool, error) {
-	const q = `SELECT EXISTS (SELECT 1 FROM pull_requests WHERE pull_request_id = $1)`
+	// id архивных PR тоже заняты
+	const q = `
+		SELECT EXISTS (SELECT 1 FROM pull_requests WHERE pull_request_id = $1)
+			OR EXISTS (SELECT 1 FROM pull_requests_archive WHERE pull_request_id = $1)
+	`
 
 	var exists bool
 	err := r.db.QueryRow(ctx, q, prID).Scan(&exists)
@@ -53,8 +75,9 @@ func (r *PgPrRepo) Insert(ctx context.Context, pr PullRequest) error {
 			status_id,
 			created_at,
 			merged_at,
-			need_more_reviewers) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+			need_more_reviewers,
+			initial_reviewers_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 	db := currentDB(ctx, r.db)
 
@@ -65,9 +88,17 @@ func (r *PgPrRepo) Insert(ctx context.Context, pr PullRequest) error {
 		pr.StatusID,
 		pr.CreatedAt,
 		pr.MergedAt,
-		false,
+		pr.NeedMoreReviewers,
+		pr.InitialReviewers,
 	)
-	return err
+	if err != nil {
+		// параллельный Create с тем же id проскочил проверку Exists
+		if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == uniqueViolationErr {
+			return ErrPRExists
+		}
+		return err
+	}
+	return nil
 }
 
 func (r *PgPrRepo) AddReviewers(ctx context.Context, prID string, reviewerIDs []string) error {
@@ -76,8 +107,8 @@ func (r *PgPrRepo) AddReviewers(ctx context.Context, prID string, reviewerIDs []
 	}
 
 	const q = `
-		INSERT INTO pull_request_reviewers(pull_request_id, reviewer_id)
-		VALUES ($1, $2)
+		INSERT INTO pull_request_reviewers(pull_request_id, reviewer_id, team_id)
+		VALUES ($1, $2, (SELECT team_id FROM users WHERE user_id = $2))
 		ON CONFLICT (pull_request_id, reviewer_id) DO NOTHING
 	`
 	db := currentDB(ctx, r.db)
@@ -91,23 +122,33 @@ func (r *PgPrRepo) AddReviewers(ctx context.Context, prID string, reviewerIDs []
 }
 
 func (r *PgPrRepo) GetWithReviewers(ctx context.Context, prID string) (PullRequest, []string, error) {
-	const qPR = `
+	return r.getWithReviewers(ctx, r.db, prID, "")
+}
+
+func (r *PgPrRepo) GetForUpdate(ctx context.Context, prID string) (PullRequest, []string, error) {
+	return r.getWithReviewers(ctx, currentDB(ctx, r.db), prID, "FOR UPDATE OF pr")
+}
+
+func (r *PgPrRepo) getWithReviewers(ctx context.Context, db DB, prID, lock string) (PullRequest, []string, error) {
+	qPR := `
 		SELECT pr.pull_request_id,
 			pr.pull_request_name,
 			pr.author_id,
 			pr.status_id,
 			s.name AS status_name,
 			pr.created_at,
-			pr.merged_at
+			pr.merged_at,
+			pr.need_more_reviewers,
+			pr.version
 		FROM pull_requests pr
 		JOIN pr_statuses s ON s.id = pr.status_id
 		WHERE pr.pull_request_id = $1
-	`
+	` + lock
 
 	var pr PullRequest
 	var mergedAt *time.Time
 
-	err := r.db.QueryRow(ctx, qPR, prID).Scan(
+	err := db.QueryRow(ctx, qPR, prID).Scan(
 		&pr.ID,
 		&pr.Name,
 		&pr.AuthorID,
@@ -115,6 +156,8 @@ func (r *PgPrRepo) GetWithReviewers(ctx context.Context, prID string) (PullReque
 		&pr.StatusName,
 		&pr.CreatedAt,
 		&mergedAt,
+		&pr.NeedMoreReviewers,
+		&pr.Version,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -126,7 +169,7 @@ func (r *PgPrRepo) GetWithReviewers(ctx context.Context, prID string) (PullReque
 
 	const qRev = `SELECT reviewer_id FROM pull_request_reviewers WHERE pull_request_id = $1`
 
-	rows, err := r.db.Query(ctx, qRev, prID)
+	rows, err := db.Query(ctx, qRev, prID)
 	if err != nil {
 		return PullRequest{}, nil, err
 	}
@@ -150,7 +193,8 @@ func (r *PgPrRepo) GetWithReviewers(ctx context.Context, prID string) (PullReque
 func (r *PgPrRepo) SetMerged(ctx context.Context, prID string, mergedAt time.Time) (PullRequest, error) {
 	const q = `
 		UPDATE pull_requests
-		SET status_id = $2,
+		SET version = version + CASE WHEN status_id = $2 THEN 0 ELSE 1 END,
+			status_id = $2,
 			merged_at = COALESCE(merged_at, $3)
 		WHERE pull_request_id = $1
 		RETURNING pull_request_id,
@@ -158,7 +202,9 @@ func (r *PgPrRepo) SetMerged(ctx context.Context, prID string, mergedAt time.Tim
 				author_id,
 				status_id,
 				created_at,
-				merged_at
+				merged_at,
+				need_more_reviewers,
+				version
 	`
 
 	db := currentDB(ctx, r.db)
@@ -175,6 +221,8 @@ func (r *PgPrRepo) SetMerged(ctx context.Context, prID string, mergedAt time.Tim
 		&pr.StatusID,
 		&pr.CreatedAt,
 		&mergedDB,
+		&pr.NeedMoreReviewers,
+		&pr.Version,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -188,11 +236,23 @@ func (r *PgPrRepo) SetMerged(ctx context.Context, prID string, mergedAt time.Tim
 }
 
 func (r *PgPrRepo) ReplaceReviewer(ctx context.Context, prID, oldReviewerID, newReviewerID string) error {
+	// заодно увеличиваем счетчик переназначений для /stats/prs, SLA нового ревьювера считается заново
 	const q = `
-		UPDATE pull_request_reviewers
-		SET reviewer_id = $3
-		WHERE pull_request_id = $1
-		AND reviewer_id = $2
+		WITH replaced AS (
+			UPDATE pull_request_reviewers
+			SET reviewer_id = $3,
+				team_id = (SELECT team_id FROM users WHERE user_id = $3),
+				assigned_at = now(),
+				reminded_at = NULL,
+				escalated_at = NULL
+			WHERE pull_request_id = $1
+			AND reviewer_id = $2
+			RETURNING pull_request_id
+		)
+		UPDATE pull_requests
+		SET reassign_count = reassign_count + 1,
+			version = version + 1
+		WHERE pull_request_id IN (SELECT pull_request_id FROM replaced)
 	`
 	db := currentDB(ctx, r.db)
 
@@ -205,3 +265,258 @@ func (r *PgPrRepo) ReplaceReviewer(ctx context.Context, prID, oldReviewerID, new
 	}
 	return nil
 }
+
+func (r *PgPrRepo) RemoveReviewer(ctx context.Context, prID, reviewerID string) error {
+	const q = `DELETE FROM pull_request_reviewers WHERE pull_request_id = $1 AND reviewer_id = $2`
+
+	db := currentDB(ctx, r.db)
+
+	ct, err := db.Exec(ctx, q, prID, reviewerID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrReviewerNotSet
+	}
+	return nil
+}
+
+func (r *PgPrRepo) RefreshNeedMoreReviewers(ctx context.Context, prID string) error {
+	const q = `
+		UPDATE pull_requests pr
+		SET need_more_reviewers = (
+			SELECT COUNT(*) FROM pull_request_reviewers r WHERE r.pull_request_id = pr.pull_request_id
+		) < $2,
+			version = pr.version + 1
+		WHERE pr.pull_request_id = $1
+	`
+
+	db := currentDB(ctx, r.db)
+
+	ct, err := db.Exec(ctx, q, prID, RequiredReviewers)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrPRNotFound
+	}
+	return nil
+}
+
+// prStatsSource - PR вместе с архивом, чтобы статистика не уменьшалась после архивации,
+// и только авторов, которые не удалены
+const prStatsSource = `
+	WITH prs AS (
+		SELECT pr.pull_request_id, pr.author_id, pr.status_id, pr.created_at, pr.merged_at,
+			pr.reassign_count, pr.initial_reviewers_count
+		FROM pull_requests pr
+		UNION ALL
+		SELECT pr.pull_request_id, pr.author_id, pr.status_id, pr.created_at, pr.merged_at,
+			pr.reassign_count, pr.initial_reviewers_count
+		FROM pull_requests_archive pr
+	), live_prs AS (
+		SELECT prs.*
+		FROM prs
+		JOIN users u ON u.user_id = prs.author_id
+		WHERE u.deleted_at IS NULL
+	)
+`
+
+func (r *PgPrRepo) GetPrStats(ctx context.Context) (PrStatsRows, error) {
+	const qTeams = prStatsSource + `
+		SELECT t.team_name,
+			COUNT(pr.pull_request_id) FILTER (WHERE pr.status_id = $1) AS open_count,
+			COUNT(pr.pull_request_id) FILTER (WHERE pr.status_id = $2) AS merged_count
+		FROM teams t
+		JOIN users u ON u.team_id = t.id AND u.deleted_at IS NULL
+		LEFT JOIN live_prs pr ON pr.author_id = u.user_id
+		GROUP BY t.team_name
+		ORDER BY t.team_name
+	`
+
+	const qAuthors = prStatsSource + `
+		SELECT u.user_id,
+			u.username,
+			COUNT(*) FILTER (WHERE pr.status_id = $1) AS open_count,
+			COUNT(*) FILTER (WHERE pr.status_id = $2) AS merged_count
+		FROM live_prs pr
+		JOIN users u ON u.user_id = pr.author_id
+		GROUP BY u.user_id, u.username
+		ORDER BY u.user_id
+	`
+
+	const qMerge = prStatsSource + `
+		SELECT percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM merged_at - created_at)),
+			percentile_cont(0.9) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM merged_at - created_at))
+		FROM live_prs
+		WHERE merged_at IS NOT NULL
+	`
+
+	const qReassign = prStatsSource + `
+		SELECT pull_request_id, reassign_count
+		FROM live_prs
+		WHERE reassign_count > 0
+		ORDER BY reassign_count DESC, pull_request_id
+	`
+
+	const qTotals = prStatsSource + `
+		SELECT COUNT(*),
+			COUNT(*) FILTER (WHERE initial_reviewers_count < 2)
+		FROM live_prs
+	`
+
+	var res PrStatsRows
+	db := r.reads.reader(ctx, r.db)
+
+	rows, err := db.Query(ctx, qTeams, StatusOpen, StatusMerged)
+	if err != nil {
+		return PrStatsRows{}, err
+	}
+	for rows.Next() {
+		var t TeamPrStatRow
+		if err := rows.Scan(&t.TeamName, &t.OpenCount, &t.MergedCount); err != nil {
+			rows.Close()
+			return PrStatsRows{}, err
+		}
+		res.ByTeam = append(res.ByTeam, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return PrStatsRows{}, err
+	}
+
+	rows, err = db.Query(ctx, qAuthors, StatusOpen, StatusMerged)
+	if err != nil {
+		return PrStatsRows{}, err
+	}
+	for rows.Next() {
+		var a AuthorPrStatRow
+		if err := rows.Scan(&a.AuthorID, &a.Username, &a.OpenCount, &a.MergedCount); err != nil {
+			rows.Close()
+			return PrStatsRows{}, err
+		}
+		res.ByAuthor = append(res.ByAuthor, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return PrStatsRows{}, err
+	}
+
+	if err := db.QueryRow(ctx, qMerge).Scan(&res.MergeMedianSec, &res.MergeP90Sec); err != nil {
+		return PrStatsRows{}, err
+	}
+
+	rows, err = db.Query(ctx, qReassign)
+	if err != nil {
+		return PrStatsRows{}, err
+	}
+	for rows.Next() {
+		var p PrReassignStatRow
+		if err := rows.Scan(&p.PullRequestID, &p.ReassignCount); err != nil {
+			rows.Close()
+			return PrStatsRows{}, err
+		}
+		res.Reassignments = append(res.Reassignments, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return PrStatsRows{}, err
+	}
+
+	if err := db.QueryRow(ctx, qTotals).Scan(&res.TotalPrs, &res.UnderstaffedCount); err != nil {
+		return PrStatsRows{}, err
+	}
+
+	return res, nil
+}
+
+func (r *PgPrRepo) ListByAuthor(ctx context.Context, authorID string) ([]AuthoredPr, error) {
+	// по строке на пару PR-ревьювер, PR без ревьюверов - одной строкой с NULL
+	const q = `
+		SELECT pr.pull_request_id,
+			pr.pull_request_name,
+			pr.author_id,
+			pr.status_id,
+			s.name AS status_name,
+			pr.created_at,
+			pr.merged_at,
+			pr.need_more_reviewers,
+			pr.version,
+			u.user_id,
+			u.username,
+			t.team_name,
+			u.is_active AND u.deleted_at IS NULL,
+			EXISTS (
+				SELECT 1 FROM user_absences a
+				WHERE a.user_id = u.user_id
+				AND a.starts_at <= NOW()
+				AND a.ends_at > NOW()
+			),
+			r.team_id IS NOT NULL AND r.team_id <> u.team_id,
+			r.assigned_at
+		FROM pull_requests pr
+		JOIN pr_statuses s ON s.id = pr.status_id
+		LEFT JOIN pull_request_reviewers r ON r.pull_request_id = pr.pull_request_id
+		LEFT JOIN users u ON u.user_id = r.reviewer_id
+		LEFT JOIN teams t ON t.id = u.team_id
+		WHERE pr.author_id = $1
+		ORDER BY pr.created_at DESC, pr.pull_request_id, r.reviewer_id
+	`
+
+	db := r.reads.reader(ctx, r.db)
+
+	rows, err := db.Query(ctx, q, authorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []AuthoredPr
+	for rows.Next() {
+		var (
+			pr                             PullRequest
+			reviewerID, username, teamName *string
+			isActive, isAbsent, movedTeam  *bool
+			assignedAt                     *time.Time
+		)
+		if err := rows.Scan(
+			&pr.ID,
+			&pr.Name,
+			&pr.AuthorID,
+			&pr.StatusID,
+			&pr.StatusName,
+			&pr.CreatedAt,
+			&pr.MergedAt,
+			&pr.NeedMoreReviewers,
+			&pr.Version,
+			&reviewerID,
+			&username,
+			&teamName,
+			&isActive,
+			&isAbsent,
+			&movedTeam,
+			&assignedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if len(res) == 0 || res[len(res)-1].PR.ID != pr.ID {
+			res = append(res, AuthoredPr{PR: pr})
+		}
+		if reviewerID == nil {
+			continue
+		}
+
+		last := &res[len(res)-1]
+		last.Reviewers = append(last.Reviewers, PrReviewerState{
+			UserID:     *reviewerID,
+			Username:   *username,
+			TeamName:   *teamName,
+			IsActive:   *isActive,
+			IsAbsent:   *isAbsent,
+			MovedTeam:  *movedTeam,
+			AssignedAt: *assignedAt,
+		})
+	}
+	return res, rows.Err()
+}
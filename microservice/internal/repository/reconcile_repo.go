@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ReconcileRepo interface {
+	// открытые PR, которые нужно чинить: есть выключенный или удаленный ревьювер,
+	// ревьювер сменил команду после назначения или ревьюверов меньше RequiredReviewers
+	ListBroken(ctx context.Context) ([]BrokenPr, error)
+}
+
+type PgReconcileRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgReconcileRepo(db *pgxpool.Pool) *PgReconcileRepo {
+	return &PgReconcileRepo{db: db}
+}
+
+func (r *PgReconcileRepo) ListBroken(ctx context.Context) ([]BrokenPr, error) {
+	const q = `
+		SELECT pull_request_id, team_name, reviewers, inactive, moved
+		FROM (
+			SELECT pr.pull_request_id,
+				t.team_name,
+				pr.created_at,
+				ARRAY_REMOVE(ARRAY_AGG(r.reviewer_id ORDER BY r.reviewer_id), NULL) AS reviewers,
+				ARRAY_REMOVE(ARRAY_AGG(r.reviewer_id ORDER BY r.reviewer_id)
+					FILTER (WHERE NOT u.is_active OR u.deleted_at IS NOT NULL), NULL) AS inactive,
+				ARRAY_REMOVE(ARRAY_AGG(r.reviewer_id ORDER BY r.reviewer_id)
+					FILTER (WHERE r.team_id <> u.team_id), NULL) AS moved
+			FROM pull_requests pr
+			JOIN users a ON a.user_id = pr.author_id
+			JOIN teams t ON t.id = a.team_id
+			LEFT JOIN pull_request_reviewers r ON r.pull_request_id = pr.pull_request_id
+			LEFT JOIN users u ON u.user_id = r.reviewer_id
+			WHERE pr.status_id = $1
+			GROUP BY pr.pull_request_id, t.team_name, pr.created_at
+		) s
+		WHERE COALESCE(cardinality(reviewers), 0) < $2
+			OR cardinality(inactive) > 0
+			OR cardinality(moved) > 0
+		ORDER BY created_at, pull_request_id
+	`
+
+	rows, err := r.db.Query(ctx, q, StatusOpen, RequiredReviewers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []BrokenPr
+	for rows.Next() {
+		var b BrokenPr
+		if err := rows.Scan(&b.PullRequestID, &b.TeamName, &b.Reviewers, &b.Inactive, &b.Moved); err != nil {
+			return nil, err
+		}
+		res = append(res, b)
+	}
+	return res, rows.Err()
+}
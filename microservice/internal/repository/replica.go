@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ReadRouter раздает запросы чтения по репликам. Реплика, которая не отвечает или отстала больше maxLag,
+// выводится из ротации до следующей проверки; если подходящих реплик нет, читаем с основной БД
+type ReadRouter struct {
+	primary  *pgxpool.Pool
+	replicas []*replica
+	maxLag   time.Duration
+	next     atomic.Uint64
+
+	// замер состояния реплики, по умолчанию запрос к самой реплике
+	Probe func(ctx context.Context, pool *pgxpool.Pool) (ReplicaProbe, error)
+}
+
+// Результат одного замера реплики
+type ReplicaProbe struct {
+	InRecovery bool
+	Streaming  bool // WAL receiver подключен к основной БД
+	Lag        time.Duration
+}
+
+// ErrReplicaNotStreaming - реплика не получает WAL с основной БД: отставание по ней не измерить
+var ErrReplicaNotStreaming = errors.New("wal receiver is not streaming")
+
+type replica struct {
+	name string
+	pool *pgxpool.Pool
+
+	mu      sync.RWMutex
+	healthy bool
+	lag     time.Duration
+	err     error
+}
+
+// Состояние реплики на момент последней проверки
+type ReplicaStatus struct {
+	Name    string
+	Healthy bool
+	Lag     time.Duration
+	Err     error
+}
+
+type primaryKey struct{}
+
+// WithPrimary помечает ctx так, что чтения идут с основной БД: нужно, когда читаем только что записанное
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// до первой проверки реплики в ротацию не входят
+func NewReadRouter(primary *pgxpool.Pool, replicas []*pgxpool.Pool, maxLag time.Duration) *ReadRouter {
+	r := &ReadRouter{primary: primary, maxLag: maxLag, Probe: probeReplica}
+	for _, p := range replicas {
+		conn := p.Config().ConnConfig
+		r.replicas = append(r.replicas, &replica{name: fmt.Sprintf("%s:%d", conn.Host, conn.Port), pool: p})
+	}
+	return r
+}
+
+// reader - соединение для чтения: открытая транзакция, основная БД при WithPrimary,
+// иначе следующая здоровая реплика по кругу. Нулевой роутер всегда отдает основную БД
+func (r *ReadRouter) reader(ctx context.Context, primary *pgxpool.Pool) DB {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return r.ReadPool(ctx, primary)
+}
+
+// ReadPool - то же без учета транзакции, для запросов, которые открывают свою
+func (r *ReadRouter) ReadPool(ctx context.Context, primary *pgxpool.Pool) *pgxpool.Pool {
+	if r == nil || ctx.Value(primaryKey{}) != nil || len(r.replicas) == 0 {
+		return primary
+	}
+
+	// круг только по здоровым, иначе следующая за выпавшей реплика получает и ее долю чтений
+	healthy := make([]*pgxpool.Pool, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		rep.mu.RLock()
+		if rep.healthy {
+			healthy = append(healthy, rep.pool)
+		}
+		rep.mu.RUnlock()
+	}
+	if len(healthy) == 0 {
+		return primary
+	}
+	return healthy[r.next.Add(1)%uint64(len(healthy))]
+}
+
+// Run проверяет реплики раз в interval, пока не отменят ctx
+func (r *ReadRouter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.CheckReplicas(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckReplicas замеряет отставание каждой реплики и обновляет ротацию
+func (r *ReadRouter) CheckReplicas(ctx context.Context) {
+	for _, rep := range r.replicas {
+		p, err := r.Probe(ctx, rep.pool)
+		if err == nil && p.InRecovery && !p.Streaming {
+			err = ErrReplicaNotStreaming
+		}
+		healthy := err == nil && (r.maxLag <= 0 || p.Lag <= r.maxLag)
+
+		rep.mu.Lock()
+		if healthy != rep.healthy {
+			if healthy {
+				slog.Info("replica back in rotation", "replica", rep.name, "lag", p.Lag)
+			} else {
+				slog.Warn("replica out of rotation", "replica", rep.name, "lag", p.Lag, "err", err)
+			}
+		}
+		rep.healthy, rep.lag, rep.err = healthy, p.Lag, err
+		rep.mu.Unlock()
+	}
+}
+
+// probeReplica читает состояние реплики. Если весь полученный WAL уже применен, реплика не отстает,
+// даже когда последняя транзакция была давно, но только пока WAL receiver на связи: без него
+// receive_lsn замирает, и отставание выглядит нулевым. Без прав pg_read_all_stats статус
+// receiver'а не виден (NULL), тогда достаточно того, что процесс есть
+func probeReplica(ctx context.Context, pool *pgxpool.Pool) (ReplicaProbe, error) {
+	const q = `
+		SELECT
+			pg_is_in_recovery(),
+			EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status IS NULL OR status = 'streaming'),
+			CASE
+				WHEN NOT pg_is_in_recovery() OR pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+				ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+			END::float8
+	`
+
+	var (
+		p       ReplicaProbe
+		seconds float64
+	)
+	if err := pool.QueryRow(ctx, q).Scan(&p.InRecovery, &p.Streaming, &seconds); err != nil {
+		return ReplicaProbe{}, err
+	}
+	p.Lag = time.Duration(seconds * float64(time.Second))
+	return p, nil
+}
+
+// PingPrimary проверяет, что основная БД отвечает
+func (r *ReadRouter) PingPrimary(ctx context.Context) error {
+	return r.primary.Ping(ctx)
+}
+
+// Replicas - состояние реплик на момент последней проверки
+func (r *ReadRouter) Replicas() []ReplicaStatus {
+	res := make([]ReplicaStatus, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		rep.mu.RLock()
+		res = append(res, ReplicaStatus{Name: rep.name, Healthy: rep.healthy, Lag: rep.lag, Err: rep.err})
+		rep.mu.RUnlock()
+	}
+	return res
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type StaleReviewRepo interface {
+	// назначения на открытых PR, по которым на момент now пора напомнить (прошло SLA, напоминания не было)
+	// или эскалировать (прошел порог эскалации, эскалации не было); пороги команд без своих значений - defaultSLA и defaultEscalate
+	ListStale(ctx context.Context, now time.Time, defaultSLA, defaultEscalate time.Duration) ([]StaleAssignment, error)
+
+	// помечаем, что ревьюверу напомнили о PR
+	MarkReminded(ctx context.Context, prID, reviewerID string, at time.Time) error
+
+	// помечаем, что зависшее ревью эскалировали, напоминание после этого уже не нужно
+	MarkEscalated(ctx context.Context, prID, reviewerID string, at time.Time) error
+}
+
+type PgStaleReviewRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgStaleReviewRepo(db *pgxpool.Pool) *PgStaleReviewRepo {
+	return &PgStaleReviewRepo{db: db}
+}
+
+func (r *PgStaleReviewRepo) ListStale(
+	ctx context.Context,
+	now time.Time,
+	defaultSLA, defaultEscalate time.Duration,
+) ([]StaleAssignment, error) {
+	// пороги считаем в секундах, у команды они хранятся в часах
+	const q = `
+		SELECT pull_request_id, reviewer_id, team_name, assigned_at, reminded_at, escalated_at, sla_sec, escalate_sec
+		FROM (
+			SELECT r.pull_request_id,
+				r.reviewer_id,
+				t.team_name,
+				r.assigned_at,
+				r.reminded_at,
+				r.escalated_at,
+				COALESCE(t.review_sla_hours::BIGINT * 3600, $2) AS sla_sec,
+				COALESCE(t.escalation_hours::BIGINT * 3600, $3) AS escalate_sec
+			FROM pull_request_reviewers r
+			JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+			JOIN users a ON a.user_id = pr.author_id
+			JOIN teams t ON t.id = a.team_id
+			WHERE pr.status_id = $4
+		) s
+		WHERE (reminded_at IS NULL AND assigned_at <= $1 - make_interval(secs => sla_sec))
+			OR (escalated_at IS NULL AND assigned_at <= $1 - make_interval(secs => escalate_sec))
+		ORDER BY assigned_at, pull_request_id, reviewer_id
+	`
+
+	rows, err := r.db.Query(ctx, q, now, int64(defaultSLA.Seconds()), int64(defaultEscalate.Seconds()), StatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []StaleAssignment
+	for rows.Next() {
+		var (
+			a                   StaleAssignment
+			slaSec, escalateSec int64
+		)
+		if err := rows.Scan(
+			&a.PullRequestID,
+			&a.ReviewerID,
+			&a.TeamName,
+			&a.AssignedAt,
+			&a.RemindedAt,
+			&a.EscalatedAt,
+			&slaSec,
+			&escalateSec,
+		); err != nil {
+			return nil, err
+		}
+		a.SLA = time.Duration(slaSec) * time.Second
+		a.EscalateAfter = time.Duration(escalateSec) * time.Second
+		res = append(res, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (r *PgStaleReviewRepo) MarkReminded(ctx context.Context, prID, reviewerID string, at time.Time) error {
+	const q = `
+		UPDATE pull_request_reviewers
+		SET reminded_at = $3
+		WHERE pull_request_id = $1 AND reviewer_id = $2
+	`
+
+	db := currentDB(ctx, r.db)
+
+	ct, err := db.Exec(ctx, q, prID, reviewerID, at)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrReviewerNotSet
+	}
+	return nil
+}
+
+func (r *PgStaleReviewRepo) MarkEscalated(ctx context.Context, prID, reviewerID string, at time.Time) error {
+	const q = `
+		UPDATE pull_request_reviewers
+		SET escalated_at = $3,
+			reminded_at = COALESCE(reminded_at, $3)
+		WHERE pull_request_id = $1 AND reviewer_id = $2
+	`
+
+	db := currentDB(ctx, r.db)
+
+	ct, err := db.Exec(ctx, q, prID, reviewerID, at)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrReviewerNotSet
+	}
+	return nil
+}
@@ -14,6 +14,57 @@ type TeamRepo interface {
 
 	// достаем команду по названию
 	GetByName(ctx context.Context, teamName string) (Team, error)
+
+	// задаем лимит открытых ревью по умолчанию для участников команды, nil - без лимита
+	SetDefaultMaxOpenReviews(ctx context.Context, teamName string, limit *int) (Team, error)
+
+	// задаем пороги напоминания и эскалации зависших ревью в часах, nil - значение по умолчанию сервиса
+	SetReviewSla(ctx context.Context, teamName string, slaHours, escalationHours *int) (Team, error)
+
+	// назначаем лида команды вместе с хэшем его токена, nil - снимаем лида
+	SetLead(ctx context.Context, teamName string, leadUserID, tokenHash *string) (Team, error)
+
+	// задаем родительскую команду по id, nil - команда становится корневой
+	SetParent(ctx context.Context, teamName string, parentTeamID *int) (Team, error)
+
+	// родительские команды от ближайшей к корню
+	ListAncestors(ctx context.Context, teamID int) ([]Team, error)
+
+	// дочерние команды по названию
+	ListChildren(ctx context.Context, teamID int) ([]Team, error)
+}
+
+const teamColumns = `
+	teams.id,
+	teams.team_name,
+	teams.default_max_open_reviews,
+	teams.review_sla_hours,
+	teams.escalation_hours,
+	teams.lead_user_id,
+	teams.lead_token_hash,
+	teams.parent_team_id,
+	(SELECT p.team_name FROM teams p WHERE p.id = teams.parent_team_id)`
+
+func scanTeam(row pgx.Row) (Team, error) {
+	var t Team
+	err := row.Scan(
+		&t.ID,
+		&t.Name,
+		&t.DefaultMaxOpenReviews,
+		&t.ReviewSlaHours,
+		&t.EscalationHours,
+		&t.LeadUserID,
+		&t.LeadTokenHash,
+		&t.ParentTeamID,
+		&t.ParentTeamName,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return Team{}, ErrTeamNotFound
+		}
+		return Team{}, err
+	}
+	return t, nil
 }
 
 type PgTeamRepo struct {
@@ -41,15 +92,106 @@ func (r *PgTeamRepo) Create(ctx context.Context, teamName string) (Team, error)
 }
 
 func (r *PgTeamRepo) GetByName(ctx context.Context, teamName string) (Team, error) {
-	const q = `SELECT id, team_name FROM teams WHERE team_name = $1`
+	q := `SELECT ` + teamColumns + ` FROM teams WHERE team_name = $1`
 
-	var t Team
-	err := r.db.QueryRow(ctx, q, teamName).Scan(&t.ID, &t.Name)
+	return scanTeam(r.db.QueryRow(ctx, q, teamName))
+}
+
+func (r *PgTeamRepo) SetDefaultMaxOpenReviews(ctx context.Context, teamName string, limit *int) (Team, error) {
+	q := `
+		UPDATE teams
+		SET default_max_open_reviews = $2
+		WHERE team_name = $1
+		RETURNING ` + teamColumns
+
+	db := currentDB(ctx, r.db)
+
+	return scanTeam(db.QueryRow(ctx, q, teamName, limit))
+}
+
+func (r *PgTeamRepo) SetReviewSla(ctx context.Context, teamName string, slaHours, escalationHours *int) (Team, error) {
+	q := `
+		UPDATE teams
+		SET review_sla_hours = $2,
+			escalation_hours = $3
+		WHERE team_name = $1
+		RETURNING ` + teamColumns
+
+	db := currentDB(ctx, r.db)
+
+	return scanTeam(db.QueryRow(ctx, q, teamName, slaHours, escalationHours))
+}
+
+func (r *PgTeamRepo) SetLead(ctx context.Context, teamName string, leadUserID, tokenHash *string) (Team, error) {
+	q := `
+		UPDATE teams
+		SET lead_user_id = $2,
+			lead_token_hash = $3
+		WHERE team_name = $1
+		RETURNING ` + teamColumns
+
+	db := currentDB(ctx, r.db)
+
+	t, err := scanTeam(db.QueryRow(ctx, q, teamName, leadUserID, tokenHash))
+	if pgErr, ok := err.(*pgconn.PgError); ok && pgErr.Code == foreignKeyViolationErr {
+		return Team{}, ErrUserNotFound
+	}
+	return t, err
+}
+
+func (r *PgTeamRepo) SetParent(ctx context.Context, teamName string, parentTeamID *int) (Team, error) {
+	q := `
+		UPDATE teams
+		SET parent_team_id = $2
+		WHERE team_name = $1
+		RETURNING ` + teamColumns
+
+	db := currentDB(ctx, r.db)
+
+	return scanTeam(db.QueryRow(ctx, q, teamName, parentTeamID))
+}
+
+func (r *PgTeamRepo) ListAncestors(ctx context.Context, teamID int) ([]Team, error) {
+	q := `
+		WITH RECURSIVE chain AS (
+			SELECT parent_team_id AS id, 1 AS depth
+			FROM teams
+			WHERE id = $1
+			UNION ALL
+			SELECT p.parent_team_id, c.depth + 1
+			FROM chain c
+			JOIN teams p ON p.id = c.id
+			WHERE c.depth < $2
+		)
+		SELECT ` + teamColumns + `
+		FROM chain
+		JOIN teams ON teams.id = chain.id
+		ORDER BY chain.depth
+	`
+
+	return r.listTeams(ctx, currentDB(ctx, r.db), q, teamID, MaxTeamDepth)
+}
+
+func (r *PgTeamRepo) ListChildren(ctx context.Context, teamID int) ([]Team, error) {
+	q := `SELECT ` + teamColumns + ` FROM teams WHERE teams.parent_team_id = $1 ORDER BY teams.team_name`
+
+	return r.listTeams(ctx, r.db, q, teamID)
+}
+
+func (r *PgTeamRepo) listTeams(ctx context.Context, db DB, q string, args ...any) ([]Team, error) {
+	rows, err := db.Query(ctx, q, args...)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return Team{}, ErrTeamNotFound
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Team
+	for rows.Next() {
+		t, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
 		}
-		return Team{}, err
+		res = append(res, t)
 	}
-	return t, nil
+	return res, rows.Err()
 }
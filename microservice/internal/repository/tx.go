@@ -3,7 +3,11 @@ package repository
 import (
 	"context"
 	"errors"
-	"log"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"syscall"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
@@ -19,20 +23,59 @@ type DB interface {
 
 type txKey struct{}
 
+// открытая транзакция и то, что нужно сделать после ее коммита
+type txState struct {
+	tx          pgx.Tx
+	afterCommit []func()
+}
+
 // Транзакция
 type TxManager interface {
 	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
+// коды PostgreSQL, после которых транзакцию можно просто повторить
+const (
+	serializationFailureErr = "40001"
+	deadlockDetectedErr     = "40P01"
+)
+
+// первая пауза перед повтором транзакции, дальше удваивается
+const txRetryBackoff = 50 * time.Millisecond
+
 type PgTxManager struct {
-	db *pgxpool.Pool
+	db         *pgxpool.Pool
+	maxRetries int
 }
 
-func NewPgTxManager(db *pgxpool.Pool) *PgTxManager {
-	return &PgTxManager{db: db}
+// maxRetries - сколько раз повторяем транзакцию после временной ошибки, 0 - без повторов
+func NewPgTxManager(db *pgxpool.Pool, maxRetries int) *PgTxManager {
+	return &PgTxManager{db: db, maxRetries: maxRetries}
 }
 
+// WithinTx выполняет fn в транзакции. При временной ошибке (конфликт сериализации, дедлок, обрыв соединения)
+// транзакция откатывается и fn выполняется заново, поэтому fn должна только работать с БД через ctx
+// и перезаписывать, а не накапливать внешние переменные
 func (m *PgTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := m.runTx(ctx, fn)
+		if err == nil || attempt >= m.maxRetries || !IsTransientError(err) {
+			return err
+		}
+		slog.Warn("tx retry", "attempt", attempt+1, "max", m.maxRetries, "err", err)
+
+		// пауза с разбросом, чтобы конфликтующие транзакции не столкнулись снова
+		pause := txRetryBackoff << attempt
+		pause += rand.N(pause)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(pause):
+		}
+	}
+}
+
+func (m *PgTxManager) runTx(ctx context.Context, fn func(ctx context.Context) error) error {
 	tx, err := m.db.Begin(ctx)
 	if err != nil {
 		return err
@@ -40,22 +83,72 @@ func (m *PgTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context)
 
 	defer func() {
 		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) {
-			log.Printf("tx rollback error: %v", err)
+			slog.Error("tx rollback", "err", err)
 		}
 	}()
 
-	ctxWithTx := context.WithValue(ctx, txKey{}, tx)
+	st := &txState{tx: tx}
+	ctxWithTx := context.WithValue(ctx, txKey{}, st)
 
 	if err := fn(ctxWithTx); err != nil {
 		return err
 	}
 
-	return tx.Commit(ctx)
+	if err := tx.Commit(ctx); err != nil {
+		return commitError{err: err}
+	}
+	for _, f := range st.afterCommit {
+		f()
+	}
+	return nil
+}
+
+// AfterCommit откладывает fn до коммита транзакции из ctx, вне транзакции вызывает сразу.
+// Если транзакцию откатили или повторяют, fn от этой попытки не вызывается
+func AfterCommit(ctx context.Context, fn func()) {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok {
+		st.afterCommit = append(st.afterCommit, fn)
+		return
+	}
+	fn()
+}
+
+func txFromContext(ctx context.Context) (pgx.Tx, bool) {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok {
+		return st.tx, true
+	}
+	return nil, false
+}
+
+// ошибка коммита: если соединение оборвалось на коммите, неизвестно, применилась ли транзакция
+type commitError struct {
+	err error
+}
+
+func (e commitError) Error() string { return e.err.Error() }
+func (e commitError) Unwrap() error { return e.err }
+
+// IsTransientError - ошибка, после которой транзакцию безопасно выполнить заново:
+// конфликт сериализации или дедлок, либо обрыв соединения до коммита
+func IsTransientError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == serializationFailureErr || pgErr.Code == deadlockDetectedErr
+	}
+	if errors.As(err, new(commitError)) {
+		return false
+	}
+	// pgx сам помечает ошибки, при которых запрос точно не дошел до сервера
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+	// соединение оборвалось посреди транзакции - сервер ее откатил
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
 }
 
 // currentDB — отдает либо открытую транзакцию, либо пул.
 func currentDB(ctx context.Context, pool *pgxpool.Pool) DB {
-	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+	if tx, ok := txFromContext(ctx); ok {
 		return tx
 	}
 	return pool
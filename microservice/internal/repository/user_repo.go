@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -14,14 +16,24 @@ type UserRepo interface {
 	// обновляем флаг активности пользователя и возвращаем обновленную запись
 	SetIsActive(ctx context.Context, userID string, isActive bool) (User, error)
 
-	// возвращаем список PR в короткой форме, где пользователь назначен ревьювером
-	GetReviewPrs(ctx context.Context, userID string) ([]PullRequestShort, error)
+	// возвращаем список PR в короткой форме, где пользователь назначен ревьювером,
+	// includeArchived - добавить PR из архива
+	GetReviewPrs(ctx context.Context, userID string, includeArchived bool) ([]PullRequestShort, error)
+
+	// страница PR, где пользователь ревьювер, по фильтру и общее число PR под фильтр без учета страницы
+	ListReviewPrs(ctx context.Context, f ReviewPrFilter) ([]PullRequestShort, int, error)
 
 	// возвращаем здесь limit активных пользователей команды teamID,
 	// исключая пользователя с excludeUserID (это для выбора нового ревьюевера нужно будет)
+	// и тех, у кого сейчас идет период недоступности или кто уже набрал свой лимит открытых ревью
 	FindActiveInTeamExcept(ctx context.Context, teamID int, excludeUserID string, limit int) ([]User, error)
 
-	// вставляем или обновляем участников команды в заданной teamID
+	// доступные ревьюверы среди перечисленных пользователей и участников перечисленных команд,
+	// кроме excludeUserID; условия доступности те же, что у FindActiveInTeamExcept
+	FindAvailable(ctx context.Context, userIDs []string, teamNames []string, excludeUserID string) ([]User, error)
+
+	// вставляем или обновляем участников команды в заданной teamID.
+	// Удаленного пользователя не возвращаем, а отказываем с ErrUserDeleted
 	UpsertTeamMembers(ctx context.Context, teamID int, members []User) error
 
 	// выводим участников по teamID
@@ -29,14 +41,32 @@ type UserRepo interface {
 
 	// доп задание: количество PR на пользователя
 	GetReviewerStats(ctx context.Context) ([]ReviewerStatRow, error)
+
+	// задаем пользователю лимит открытых ревью, nil - использовать лимит команды
+	SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (User, error)
+
+	// мягко удаляем пользователя: выключаем, скрываем отовсюду и снимаем с лидерства в командах
+	SoftDelete(ctx context.Context, userID string, at time.Time) error
+
+	// возвращаем мягко удаленного пользователя; он остается выключенным, лидерство не восстанавливается
+	Restore(ctx context.Context, userID string) (User, error)
+
+	// сколько активных и доступных участников команды (кроме excludeUserID) уже упираются в свой лимит ревью
+	CountAtCapacityInTeam(ctx context.Context, teamID int, excludeUserID string) (int, error)
+
+	// может ли пользователь взять ревью по тем же условиям, что у FindActiveInTeamExcept:
+	// eligible - активен и сейчас не отсутствует, underCapacity - не набрал лимит открытых ревью
+	GetAvailability(ctx context.Context, userID string) (eligible, underCapacity bool, err error)
 }
 
 type PgUserRepo struct {
-	db *pgxpool.Pool
+	db    *pgxpool.Pool
+	reads *ReadRouter
 }
 
-func NewPgUserRepo(db *pgxpool.Pool) *PgUserRepo {
-	return &PgUserRepo{db: db}
+// reads - куда отправлять запросы списков и статистики, nil - все на основную БД
+func NewPgUserRepo(db *pgxpool.Pool, reads *ReadRouter) *PgUserRepo {
+	return &PgUserRepo{db: db, reads: reads}
 }
 
 func (r *PgUserRepo) GetByID(ctx context.Context, userID string) (User, error) {
@@ -45,10 +75,12 @@ func (r *PgUserRepo) GetByID(ctx context.Context, userID string) (User, error) {
 		u.username,
 		u.team_id,
 		t.team_name,
-		u.is_active
+		u.is_active,
+		u.max_open_reviews
 	FROM users u
 	JOIN teams t ON t.id = u.team_id
 	WHERE u.user_id = $1
+	AND u.deleted_at IS NULL
 	`
 
 	var u User
@@ -58,6 +90,7 @@ func (r *PgUserRepo) GetByID(ctx context.Context, userID string) (User, error) {
 		&u.TeamID,
 		&u.TeamName,
 		&u.IsActive,
+		&u.MaxOpenReviews,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -73,8 +106,8 @@ func (r *PgUserRepo) SetIsActive(ctx context.Context, userID string, isActive bo
 		UPDATE users u
 		SET is_active = $1
 		FROM teams t
-		WHERE u.user_id = $2 AND u.team_id = t.id
-		RETURNING u.user_id, u.username, u.team_id, t.team_name, u.is_active
+		WHERE u.user_id = $2 AND u.team_id = t.id AND u.deleted_at IS NULL
+		RETURNING u.user_id, u.username, u.team_id, t.team_name, u.is_active, u.max_open_reviews
 	`
 
 	var u User
@@ -84,6 +117,7 @@ func (r *PgUserRepo) SetIsActive(ctx context.Context, userID string, isActive bo
 		&u.TeamID,
 		&u.TeamName,
 		&u.IsActive,
+		&u.MaxOpenReviews,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -94,20 +128,31 @@ func (r *PgUserRepo) SetIsActive(ctx context.Context, userID string, isActive bo
 	return u, nil
 }
 
-func (r *PgUserRepo) GetReviewPrs(ctx context.Context, userID string) ([]PullRequestShort, error) {
+func (r *PgUserRepo) GetReviewPrs(ctx context.Context, userID string, includeArchived bool) ([]PullRequestShort, error) {
 	const q = `
 		SELECT pr.pull_request_id,
 			pr.pull_request_name,
 			pr.author_id,
-			s.name AS status
+			s.name AS status,
+			pr.created_at
 		FROM pull_request_reviewers r
 		JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
 		JOIN pr_statuses s ON s.id = pr.status_id
 		WHERE r.reviewer_id = $1
-		ORDER BY pr.created_at DESC
+		UNION ALL
+		SELECT pr.pull_request_id,
+			pr.pull_request_name,
+			pr.author_id,
+			s.name AS status,
+			pr.created_at
+		FROM pull_request_reviewers_archive r
+		JOIN pull_requests_archive pr ON pr.pull_request_id = r.pull_request_id
+		JOIN pr_statuses s ON s.id = pr.status_id
+		WHERE r.reviewer_id = $1 AND $2
+		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(ctx, q, userID)
+	rows, err := r.db.Query(ctx, q, userID, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -116,7 +161,7 @@ func (r *PgUserRepo) GetReviewPrs(ctx context.Context, userID string) ([]PullReq
 	var prs []PullRequestShort
 	for rows.Next() {
 		var pr PullRequestShort
-		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.StatusName); err != nil {
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.StatusName, &pr.CreatedAt); err != nil {
 			return nil, err
 		}
 		prs = append(prs, pr)
@@ -124,6 +169,117 @@ func (r *PgUserRepo) GetReviewPrs(ctx context.Context, userID string) ([]PullReq
 	return prs, rows.Err()
 }
 
+// PR ревьювера $1 из живых таблиц и, если $2, из архива; $3 - статусы, пустой массив - любые
+const reviewPrsCTE = `
+	WITH prs AS (
+		SELECT pr.pull_request_id,
+			pr.pull_request_name,
+			pr.author_id,
+			s.name AS status,
+			pr.created_at,
+			ARRAY(
+				SELECT o.reviewer_id FROM pull_request_reviewers o
+				WHERE o.pull_request_id = pr.pull_request_id AND o.reviewer_id <> $1
+				ORDER BY o.reviewer_id
+			) AS other_reviewers
+		FROM pull_request_reviewers r
+		JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+		JOIN pr_statuses s ON s.id = pr.status_id
+		WHERE r.reviewer_id = $1
+		AND (cardinality($3::text[]) = 0 OR s.name = ANY($3))
+		UNION ALL
+		SELECT pr.pull_request_id,
+			pr.pull_request_name,
+			pr.author_id,
+			s.name AS status,
+			pr.created_at,
+			ARRAY(
+				SELECT o.reviewer_id FROM pull_request_reviewers_archive o
+				WHERE o.pull_request_id = pr.pull_request_id AND o.reviewer_id <> $1
+				ORDER BY o.reviewer_id
+			) AS other_reviewers
+		FROM pull_request_reviewers_archive r
+		JOIN pull_requests_archive pr ON pr.pull_request_id = r.pull_request_id
+		JOIN pr_statuses s ON s.id = pr.status_id
+		WHERE r.reviewer_id = $1 AND $2
+		AND (cardinality($3::text[]) = 0 OR s.name = ANY($3))
+	)
+`
+
+func (r *PgUserRepo) ListReviewPrs(ctx context.Context, f ReviewPrFilter) ([]PullRequestShort, int, error) {
+	const qCount = reviewPrsCTE + `SELECT COUNT(*) FROM prs`
+
+	// LIMIT NULL - без ограничения
+	const qPage = reviewPrsCTE + `
+		SELECT pull_request_id, pull_request_name, author_id, status, created_at, other_reviewers
+		FROM prs
+		WHERE $4::timestamptz IS NULL OR (created_at, pull_request_id) < ($4, $5)
+		ORDER BY created_at DESC, pull_request_id DESC
+		LIMIT NULLIF($6, 0)
+	`
+
+	statuses := f.Statuses
+	if statuses == nil {
+		statuses = []string{}
+	}
+
+	// страницу и общее число читаем с одной и той же реплики
+	db := r.reads.reader(ctx, r.db)
+
+	var total int
+	if err := db.QueryRow(ctx, qCount, f.UserID, f.IncludeArchived, statuses).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Query(ctx, qPage, f.UserID, f.IncludeArchived, statuses, f.AfterCreatedAt, f.AfterID, f.Limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var prs []PullRequestShort
+	for rows.Next() {
+		var pr PullRequestShort
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.StatusName, &pr.CreatedAt, &pr.OtherReviewers); err != nil {
+			return nil, 0, err
+		}
+		prs = append(prs, pr)
+	}
+	return prs, total, rows.Err()
+}
+
+// Условие "пользователь u может ревьюить в принципе": активен, не удален и сейчас не отсутствует
+const eligibleReviewerCond = `
+		u.is_active = TRUE
+		AND u.deleted_at IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM user_absences a
+			WHERE a.user_id = u.user_id
+			AND a.starts_at <= NOW()
+			AND a.ends_at > NOW()
+		)
+`
+
+// Условие "пользователь u из команды t не набрал лимит открытых ревью" (свой или команды по умолчанию)
+const underCapacityCond = `
+		(
+			COALESCE(u.max_open_reviews, t.default_max_open_reviews) IS NULL
+			OR (
+				SELECT COUNT(*)
+				FROM pull_request_reviewers r
+				JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+				JOIN pr_statuses s ON s.id = pr.status_id
+				WHERE r.reviewer_id = u.user_id
+				AND s.name = 'OPEN'
+			) < COALESCE(u.max_open_reviews, t.default_max_open_reviews)
+		)
+`
+
+// Условие "пользователь u из команды t может взять ревью". CountAtCapacityInTeam строится из тех же частей,
+// чтобы ErrAtCapacity и выбор ревьювера не расходились
+const availableReviewerCond = eligibleReviewerCond + `
+		AND ` + underCapacityCond
+
 func (r *PgUserRepo) FindActiveInTeamExcept(
 	ctx context.Context,
 	teamID int,
@@ -139,13 +295,44 @@ func (r *PgUserRepo) FindActiveInTeamExcept(
 		FROM users u
 		JOIN teams t ON t.id = u.team_id
 		WHERE u.team_id = $1
-		AND u.is_active = TRUE
 		AND u.user_id <> $2
+		AND ` + availableReviewerCond + `
 		ORDER BY random()
 		LIMIT $3
 	`
 
-	rows, err := r.db.Query(ctx, q, teamID, excludeUserID, limit)
+	return r.listUsers(ctx, q, teamID, excludeUserID, limit)
+}
+
+func (r *PgUserRepo) FindAvailable(
+	ctx context.Context,
+	userIDs []string,
+	teamNames []string,
+	excludeUserID string,
+) ([]User, error) {
+	if len(userIDs) == 0 && len(teamNames) == 0 {
+		return nil, nil
+	}
+
+	const q = `
+		SELECT u.user_id,
+			u.username,
+			u.team_id,
+			t.team_name,
+			u.is_active
+		FROM users u
+		JOIN teams t ON t.id = u.team_id
+		WHERE (u.user_id = ANY($1) OR t.team_name = ANY($2))
+		AND u.user_id <> $3
+		AND ` + availableReviewerCond + `
+		ORDER BY random()
+	`
+
+	return r.listUsers(ctx, q, userIDs, teamNames, excludeUserID)
+}
+
+func (r *PgUserRepo) listUsers(ctx context.Context, q string, args ...any) ([]User, error) {
+	rows, err := r.db.Query(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -164,17 +351,26 @@ func (r *PgUserRepo) FindActiveInTeamExcept(
 
 func (r *PgUserRepo) UpsertTeamMembers(ctx context.Context, teamID int, members []User) error {
 	const q = `
-		INSERT INTO users (user_id, username, team_id, is_active)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (user_id, username, team_id, is_active, max_open_reviews)
+		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (user_id) DO UPDATE
 		SET username = EXCLUDED.username,
 			team_id  = EXCLUDED.team_id,
-			is_active = EXCLUDED.is_active
+			is_active = EXCLUDED.is_active,
+			max_open_reviews = COALESCE(EXCLUDED.max_open_reviews, users.max_open_reviews)
+		WHERE users.deleted_at IS NULL
+		RETURNING user_id
 	`
 	db := currentDB(ctx, r.db)
 
 	for _, m := range members {
-		if _, err := db.Exec(ctx, q, m.ID, m.Username, teamID, m.IsActive); err != nil {
+		// строка не вернулась - конфликт с удаленным пользователем, его не трогаем
+		var id string
+		err := db.QueryRow(ctx, q, m.ID, m.Username, teamID, m.IsActive, m.MaxOpenReviews).Scan(&id)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return fmt.Errorf("%w: %s", ErrUserDeleted, m.ID)
+			}
 			return err
 		}
 	}
@@ -183,13 +379,24 @@ func (r *PgUserRepo) UpsertTeamMembers(ctx context.Context, teamID int, members
 
 func (r *PgUserRepo) ListByTeam(ctx context.Context, teamID int) ([]User, error) {
 	const q = `
-		SELECT user_id, username, team_id, is_active
-		FROM USERS
-		WHERE team_id = $1
-		ORDER BY user_id
+		SELECT u.user_id,
+			u.username,
+			u.team_id,
+			u.is_active,
+			u.max_open_reviews,
+			COUNT(pr.pull_request_id) AS open_reviews
+		FROM users u
+		LEFT JOIN pull_request_reviewers r ON r.reviewer_id = u.user_id
+		LEFT JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id AND pr.status_id = $2
+		WHERE u.team_id = $1
+		AND u.deleted_at IS NULL
+		GROUP BY u.user_id
+		ORDER BY u.user_id
 	`
 
-	rows, err := r.db.Query(ctx, q, teamID)
+	db := r.reads.reader(ctx, r.db)
+
+	rows, err := db.Query(ctx, q, teamID, StatusOpen)
 	if err != nil {
 		return nil, err
 	}
@@ -198,7 +405,7 @@ func (r *PgUserRepo) ListByTeam(ctx context.Context, teamID int) ([]User, error)
 	var users []User
 	for rows.Next() {
 		var u User
-		if err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive); err != nil {
+		if err := rows.Scan(&u.ID, &u.Username, &u.TeamID, &u.IsActive, &u.MaxOpenReviews, &u.OpenReviews); err != nil {
 			return nil, err
 		}
 		users = append(users, u)
@@ -210,19 +417,31 @@ func (r *PgUserRepo) ListByTeam(ctx context.Context, teamID int) ([]User, error)
 	return users, nil
 }
 
+// reviewerStatsSource - назначения ревьюверами вместе с архивом, чтобы счетчики не уменьшались после архивации
+const reviewerStatsSource = `
+	WITH revs AS (
+		SELECT pull_request_id, reviewer_id FROM pull_request_reviewers
+		UNION ALL
+		SELECT pull_request_id, reviewer_id FROM pull_request_reviewers_archive
+	)
+`
+
 func (r *PgUserRepo) GetReviewerStats(ctx context.Context) ([]ReviewerStatRow, error) {
-	const q = `
+	const q = reviewerStatsSource + `
 		SELECT u.user_id,
 			   u.username,
 			   COUNT(rpr.pull_request_id) AS reviews_count
 		FROM users u
-		LEFT JOIN pull_request_reviewers rpr
+		LEFT JOIN revs rpr
 			ON rpr.reviewer_id = u.user_id
+		WHERE u.deleted_at IS NULL
 		GROUP BY u.user_id, u.username
 		ORDER BY reviews_count DESC, u.user_id
 	`
 
-	rows, err := r.db.Query(ctx, q)
+	db := r.reads.reader(ctx, r.db)
+
+	rows, err := db.Query(ctx, q)
 	if err != nil {
 		return nil, err
 	}
@@ -242,3 +461,126 @@ func (r *PgUserRepo) GetReviewerStats(ctx context.Context) ([]ReviewerStatRow, e
 
 	return res, nil
 }
+
+func (r *PgUserRepo) SetMaxOpenReviews(ctx context.Context, userID string, limit *int) (User, error) {
+	const q = `
+		UPDATE users u
+		SET max_open_reviews = $1
+		FROM teams t
+		WHERE u.user_id = $2 AND u.team_id = t.id AND u.deleted_at IS NULL
+		RETURNING u.user_id, u.username, u.team_id, t.team_name, u.is_active, u.max_open_reviews
+	`
+
+	var u User
+	err := r.db.QueryRow(ctx, q, limit, userID).Scan(
+		&u.ID,
+		&u.Username,
+		&u.TeamID,
+		&u.TeamName,
+		&u.IsActive,
+		&u.MaxOpenReviews,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return User{}, ErrUserNotFound
+		}
+		return User{}, err
+	}
+	return u, nil
+}
+
+func (r *PgUserRepo) CountAtCapacityInTeam(ctx context.Context, teamID int, excludeUserID string) (int, error) {
+	const q = `
+		SELECT COUNT(*)
+		FROM users u
+		JOIN teams t ON t.id = u.team_id
+		WHERE u.team_id = $1
+		AND u.user_id <> $2
+		AND ` + eligibleReviewerCond + `
+		AND NOT ` + underCapacityCond + `
+	`
+
+	var n int
+	err := r.db.QueryRow(ctx, q, teamID, excludeUserID).Scan(&n)
+	return n, err
+}
+
+func (r *PgUserRepo) GetAvailability(ctx context.Context, userID string) (bool, bool, error) {
+	const q = `
+		SELECT (` + eligibleReviewerCond + `),
+			` + underCapacityCond + `
+		FROM users u
+		JOIN teams t ON t.id = u.team_id
+		WHERE u.user_id = $1
+		AND u.deleted_at IS NULL
+	`
+
+	var eligible, underCapacity bool
+	err := currentDB(ctx, r.db).QueryRow(ctx, q, userID).Scan(&eligible, &underCapacity)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return false, false, ErrUserNotFound
+		}
+		return false, false, err
+	}
+	return eligible, underCapacity, nil
+}
+
+func (r *PgUserRepo) SoftDelete(ctx context.Context, userID string, at time.Time) error {
+	// токен лида удаленного пользователя перестает действовать вместе с лидерством
+	const q = `
+		WITH deleted AS (
+			UPDATE users
+			SET deleted_at = $2,
+				is_active = FALSE
+			WHERE user_id = $1 AND deleted_at IS NULL
+			RETURNING user_id
+		), unlead AS (
+			UPDATE teams
+			SET lead_user_id = NULL,
+				lead_token_hash = NULL
+			WHERE lead_user_id IN (SELECT user_id FROM deleted)
+		)
+		SELECT COUNT(*) FROM deleted
+	`
+
+	db := currentDB(ctx, r.db)
+
+	var n int
+	if err := db.QueryRow(ctx, q, userID, at).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *PgUserRepo) Restore(ctx context.Context, userID string) (User, error) {
+	const q = `
+		UPDATE users u
+		SET deleted_at = NULL
+		FROM teams t
+		WHERE u.user_id = $1 AND u.team_id = t.id AND u.deleted_at IS NOT NULL
+		RETURNING u.user_id, u.username, u.team_id, t.team_name, u.is_active, u.max_open_reviews
+	`
+
+	db := currentDB(ctx, r.db)
+
+	var u User
+	err := db.QueryRow(ctx, q, userID).Scan(
+		&u.ID,
+		&u.Username,
+		&u.TeamID,
+		&u.TeamName,
+		&u.IsActive,
+		&u.MaxOpenReviews,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return User{}, ErrUserNotFound
+		}
+		return User{}, err
+	}
+	return u, nil
+}
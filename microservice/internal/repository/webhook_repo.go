@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhookRepo interface {
+	// задаем или меняем webhook команды
+	Set(ctx context.Context, teamName, url, kind string) (TeamWebhook, error)
+
+	// удаляем webhook команды, если его нет - ничего не делаем
+	Delete(ctx context.Context, teamName string) error
+
+	// webhook команды по ее имени
+	GetByTeamName(ctx context.Context, teamName string) (TeamWebhook, error)
+}
+
+type PgWebhookRepo struct {
+	db *pgxpool.Pool
+}
+
+func NewPgWebhookRepo(db *pgxpool.Pool) *PgWebhookRepo {
+	return &PgWebhookRepo{db: db}
+}
+
+func (r *PgWebhookRepo) Set(ctx context.Context, teamName, url, kind string) (TeamWebhook, error) {
+	const q = `
+		INSERT INTO team_webhooks (team_id, url, kind)
+		SELECT id, $2, $3 FROM teams WHERE team_name = $1
+		ON CONFLICT (team_id) DO UPDATE
+		SET url = EXCLUDED.url,
+			kind = EXCLUDED.kind,
+			updated_at = now()
+		RETURNING team_id, url, kind
+	`
+
+	db := currentDB(ctx, r.db)
+
+	w := TeamWebhook{TeamName: teamName}
+	err := db.QueryRow(ctx, q, teamName, url, kind).Scan(&w.TeamID, &w.URL, &w.Kind)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return TeamWebhook{}, ErrTeamNotFound
+		}
+		return TeamWebhook{}, err
+	}
+	return w, nil
+}
+
+func (r *PgWebhookRepo) Delete(ctx context.Context, teamName string) error {
+	const q = `
+		DELETE FROM team_webhooks w
+		USING teams t
+		WHERE t.id = w.team_id
+		AND t.team_name = $1
+	`
+
+	db := currentDB(ctx, r.db)
+
+	_, err := db.Exec(ctx, q, teamName)
+	return err
+}
+
+func (r *PgWebhookRepo) GetByTeamName(ctx context.Context, teamName string) (TeamWebhook, error) {
+	const q = `
+		SELECT w.team_id, t.team_name, w.url, w.kind
+		FROM team_webhooks w
+		JOIN teams t ON t.id = w.team_id
+		WHERE t.team_name = $1
+	`
+
+	var w TeamWebhook
+	err := r.db.QueryRow(ctx, q, teamName).Scan(&w.TeamID, &w.TeamName, &w.URL, &w.Kind)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return TeamWebhook{}, ErrWebhookNotFound
+		}
+		return TeamWebhook{}, err
+	}
+	return w, nil
+}
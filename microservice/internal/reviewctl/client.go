@@ -0,0 +1,151 @@
+package reviewctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"review-manager/internal/dto"
+)
+
+// Коды выхода reviewctl, чтобы скрипты могли различать ошибки без разбора вывода
+const (
+	ExitOK           = 0
+	ExitFailure      = 1 // сеть, 5xx и все, что не попало ниже
+	ExitUsage        = 2 // неверные аргументы или 400 от сервера
+	ExitNotFound     = 3
+	ExitAuth         = 4
+	ExitExists       = 5 // TEAM_EXISTS, PR_EXISTS
+	ExitPRMerged     = 6
+	ExitNotAssigned  = 7
+	ExitNoCandidate  = 8  // NO_CANDIDATE, AT_CAPACITY
+	ExitConflict     = 9  // VERSION_CONFLICT
+	ExitCannotReview = 10 // ALREADY_ASSIGNED, REVIEWER_IS_AUTHOR, USER_INACTIVE, USER_ABSENT
+)
+
+// APIError - ответ сервера в формате dto.ErrorResponse
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s (HTTP %d): %s", e.Code, e.Status, e.Message)
+}
+
+// ExitCode переводит код ошибки из тела ответа в код выхода.
+// Если кода нет (ответ не от сервиса, например от прокси), смотрим на HTTP-статус
+func (e *APIError) ExitCode() int {
+	switch e.Code {
+	case dto.ErrorCodeTeamExists, dto.ErrorCodePRExists:
+		return ExitExists
+	case dto.ErrorCodePRMerged:
+		return ExitPRMerged
+	case dto.ErrorCodeNotAssigned:
+		return ExitNotAssigned
+	case dto.ErrorCodeNoCandidate, dto.ErrorCodeAtCapacity:
+		return ExitNoCandidate
+	case dto.ErrorCodeVersionConflict:
+		return ExitConflict
+	case dto.ErrorCodeAlreadyAssigned, dto.ErrorCodeReviewerIsAuthor, dto.ErrorCodeUserInactive,
+		dto.ErrorCodeUserAbsent:
+		return ExitCannotReview
+	case dto.ErrorCodeValidationFailed:
+		return ExitUsage
+	case dto.ErrorCodeUnauthorized:
+		return ExitAuth
+	case dto.ErrorCodeNotFound:
+		return ExitNotFound
+	}
+
+	switch e.Status {
+	case http.StatusBadRequest:
+		return ExitUsage
+	case http.StatusUnauthorized:
+		return ExitAuth
+	case http.StatusNotFound:
+		return ExitNotFound
+	default:
+		return ExitFailure
+	}
+}
+
+type client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func newClient(cfg Config) *client {
+	return &client{
+		baseURL: strings.TrimRight(cfg.URL, "/"),
+		token:   cfg.Token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// get выполняет GET с query-параметрами и возвращает сырое тело ответа
+func (c *client) get(path string, query url.Values) ([]byte, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req)
+}
+
+// post отправляет body как JSON; version > 0 уходит в If-Match
+func (c *client) post(path string, body any, version int64) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if version > 0 {
+		req.Header.Set("If-Match", strconv.Quote(strconv.FormatInt(version, 10)))
+	}
+	return c.do(req)
+}
+
+func (c *client) do(req *http.Request) ([]byte, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		var errResp dto.ErrorResponse
+		if err := json.Unmarshal(data, &errResp); err != nil || errResp.Error.Code == "" {
+			return nil, &APIError{Status: resp.StatusCode, Code: "HTTP_ERROR", Message: strings.TrimSpace(string(data))}
+		}
+		return nil, &APIError{Status: resp.StatusCode, Code: errResp.Error.Code, Message: errResp.Error.Message}
+	}
+
+	return data, nil
+}
@@ -0,0 +1,435 @@
+package reviewctl
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"review-manager/internal/dto"
+)
+
+// usageError - неверные аргументы команды, выходим с ExitUsage
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+type env struct {
+	client  *client
+	printer *printer
+}
+
+type command struct {
+	usage string
+	run   func(e *env, args []string) error
+}
+
+// все команды reviewctl: "<группа> <действие>"
+var commands = map[string]command{
+	"team add":           {"-name backend -member u1:Alice -member u2:Bob:inactive", teamAdd},
+	"team get":           {"-name backend", teamGet},
+	"users set-active":   {"-id u1 -active=false", usersSetActive},
+	"users reviews":      {"-id u1 [-status OPEN] [-limit 50] [-cursor c]", usersReviews},
+	"pr create":          {"-id pr-1 -name 'Add search' -author u1 [-file path ...]", prCreate},
+	"pr merge":           {"-id pr-1 [-version N]", prMerge},
+	"pr reassign":        {"-id pr-1 -old u2 [-new u3] [-version N]", prReassign},
+	"pr add-reviewer":    {"-id pr-1 -user u3 [-version N]", prAddReviewer},
+	"pr remove-reviewer": {"-id pr-1 -user u3 [-version N]", prRemoveReviewer},
+	"stats reviewers":    {"", statsReviewers},
+	"stats prs":          {"", statsPrs},
+}
+
+// повторяемый флаг: -member a -member b
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usagef("%s: %v", fs.Name(), err)
+	}
+	if fs.NArg() > 0 {
+		return usagef("%s: лишние аргументы: %s", fs.Name(), strings.Join(fs.Args(), " "))
+	}
+	return nil
+}
+
+func required(name, v string) error {
+	if v == "" {
+		return usagef("нужно указать -%s", name)
+	}
+	return nil
+}
+
+/* team */
+
+func teamAdd(e *env, args []string) error {
+	fs := newFlagSet("team add")
+	name := fs.String("name", "", "название команды")
+	var members listFlag
+	fs.Var(&members, "member", "участник user_id:username[:inactive], можно несколько раз")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("name", *name); err != nil {
+		return err
+	}
+
+	req := dto.TeamAddRequest{TeamName: *name, Members: make([]dto.TeamMember, 0, len(members))}
+	for _, m := range members {
+		parts := strings.Split(m, ":")
+		if len(parts) < 2 || len(parts) > 3 || (len(parts) == 3 && parts[2] != "inactive") {
+			return usagef("участник %q: ожидается user_id:username[:inactive]", m)
+		}
+		req.Members = append(req.Members, dto.TeamMember{
+			UserID:   parts[0],
+			Username: parts[1],
+			IsActive: len(parts) == 2,
+		})
+	}
+
+	body, err := e.client.post("/team/add", req, 0)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.TeamAddResponse
+	return e.printer.print(body, &resp, func(w io.Writer) {
+		printTeam(w, resp.Team)
+	})
+}
+
+func teamGet(e *env, args []string) error {
+	fs := newFlagSet("team get")
+	name := fs.String("name", "", "название команды")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("name", *name); err != nil {
+		return err
+	}
+
+	body, err := e.client.get("/team/get", url.Values{"team_name": {*name}})
+	if err != nil {
+		return err
+	}
+
+	var team dto.Team
+	return e.printer.print(body, &team, func(w io.Writer) {
+		printTeam(w, team)
+	})
+}
+
+func printTeam(w io.Writer, t dto.Team) {
+	row(w, "TEAM", t.TeamName, "DEFAULT LIMIT", intOrDash(t.DefaultMaxOpenReviews))
+	row(w)
+	row(w, "USER_ID", "USERNAME", "ACTIVE", "OPEN REVIEWS", "LIMIT")
+	for _, m := range t.Members {
+		row(w, m.UserID, m.Username, m.IsActive, intOrDash(m.OpenReviews), intOrDash(m.MaxOpenReviews))
+	}
+}
+
+/* users */
+
+func usersSetActive(e *env, args []string) error {
+	fs := newFlagSet("users set-active")
+	id := fs.String("id", "", "user_id")
+	active := fs.Bool("active", true, "новое значение is_active")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("id", *id); err != nil {
+		return err
+	}
+
+	body, err := e.client.post("/users/setIsActive", dto.SetUserIsActiveRequest{UserID: *id, IsActive: *active}, 0)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.SetUserIsActiveResponse
+	return e.printer.print(body, &resp, func(w io.Writer) {
+		row(w, "USER_ID", "USERNAME", "TEAM", "ACTIVE")
+		row(w, resp.User.UserID, resp.User.Username, resp.User.TeamName, resp.User.IsActive)
+	})
+}
+
+func usersReviews(e *env, args []string) error {
+	fs := newFlagSet("users reviews")
+	id := fs.String("id", "", "user_id")
+	status := fs.String("status", "", "статусы через запятую: OPEN,MERGED")
+	limit := fs.Int("limit", 0, "размер страницы, 0 - все PR")
+	cursor := fs.String("cursor", "", "next_cursor из предыдущего ответа")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("id", *id); err != nil {
+		return err
+	}
+
+	q := url.Values{"user_id": {*id}}
+	if *status != "" {
+		q.Set("status", *status)
+	}
+	if *limit > 0 {
+		q.Set("limit", strconv.Itoa(*limit))
+	}
+	if *cursor != "" {
+		q.Set("cursor", *cursor)
+	}
+
+	body, err := e.client.get("/users/getReview", q)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.UserGetReviewResponse
+	return e.printer.print(body, &resp, func(w io.Writer) {
+		row(w, "PR_ID", "NAME", "AUTHOR", "STATUS", "OTHER_REVIEWERS")
+		for _, pr := range resp.PullRequests {
+			row(w, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, orDash(strings.Join(pr.OtherReviewers, ",")))
+		}
+		row(w)
+		row(w, "TOTAL", resp.Total)
+		if resp.NextCursor != "" {
+			row(w, "NEXT_CURSOR", resp.NextCursor)
+		}
+		if len(resp.UpcomingAbsences) > 0 {
+			row(w)
+			row(w, "ABSENT FROM", "TO", "REASON")
+			for _, a := range resp.UpcomingAbsences {
+				row(w, a.StartsAt.Format(time.RFC3339), a.EndsAt.Format(time.RFC3339), orDash(a.Reason))
+			}
+		}
+	})
+}
+
+/* pr */
+
+func prCreate(e *env, args []string) error {
+	fs := newFlagSet("pr create")
+	id := fs.String("id", "", "pull_request_id")
+	name := fs.String("name", "", "pull_request_name")
+	author := fs.String("author", "", "author_id")
+	var files listFlag
+	fs.Var(&files, "file", "измененный файл для CODEOWNERS, можно несколько раз")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("id", *id); err != nil {
+		return err
+	}
+	if err := required("name", *name); err != nil {
+		return err
+	}
+	if err := required("author", *author); err != nil {
+		return err
+	}
+
+	req := dto.CreatePrRequest{
+		PullRequestID:   *id,
+		PullRequestName: *name,
+		AuthorID:        *author,
+		ChangedFiles:    files,
+	}
+	body, err := e.client.post("/pullRequest/create", req, 0)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.CreatePrResponse
+	return e.printer.print(body, &resp, func(w io.Writer) {
+		printPR(w, resp.PR)
+		if resp.LimitedByCapacity {
+			row(w, "NOTE", "часть кандидатов достигла лимита открытых ревью")
+		}
+		if len(resp.ReviewerReasons) > 0 {
+			row(w)
+			row(w, "REVIEWER", "SOURCE", "RULE")
+			for _, r := range resp.ReviewerReasons {
+				row(w, r.UserID, r.Source, orDash(r.Rule))
+			}
+		}
+	})
+}
+
+func prMerge(e *env, args []string) error {
+	fs := newFlagSet("pr merge")
+	id := fs.String("id", "", "pull_request_id")
+	version := fs.Int64("version", 0, "ожидаемая версия PR (If-Match)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("id", *id); err != nil {
+		return err
+	}
+
+	body, err := e.client.post("/pullRequest/merge", dto.MergePrRequest{PullRequestID: *id}, *version)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.MergePullRequestResponse
+	return e.printer.print(body, &resp, func(w io.Writer) {
+		printPR(w, resp.PR)
+	})
+}
+
+func prReassign(e *env, args []string) error {
+	fs := newFlagSet("pr reassign")
+	id := fs.String("id", "", "pull_request_id")
+	oldUser := fs.String("old", "", "кого заменить")
+	newUser := fs.String("new", "", "кого назначить, по умолчанию случайный кандидат")
+	version := fs.Int64("version", 0, "ожидаемая версия PR (If-Match)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("id", *id); err != nil {
+		return err
+	}
+	if err := required("old", *oldUser); err != nil {
+		return err
+	}
+
+	req := dto.ReassignPrRequest{PullRequestID: *id, OldUserID: *oldUser, NewUserID: *newUser}
+	body, err := e.client.post("/pullRequest/reassign", req, *version)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.ReassignPrResponse
+	return e.printer.print(body, &resp, func(w io.Writer) {
+		printPR(w, resp.PR)
+		row(w, "REPLACED BY", resp.ReplacedBy)
+	})
+}
+
+func prAddReviewer(e *env, args []string) error {
+	return prChangeReviewer(e, "pr add-reviewer", "/pullRequest/addReviewer", args)
+}
+
+func prRemoveReviewer(e *env, args []string) error {
+	return prChangeReviewer(e, "pr remove-reviewer", "/pullRequest/removeReviewer", args)
+}
+
+func prChangeReviewer(e *env, name, path string, args []string) error {
+	fs := newFlagSet(name)
+	id := fs.String("id", "", "pull_request_id")
+	user := fs.String("user", "", "user_id ревьювера")
+	version := fs.Int64("version", 0, "ожидаемая версия PR (If-Match)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := required("id", *id); err != nil {
+		return err
+	}
+	if err := required("user", *user); err != nil {
+		return err
+	}
+
+	body, err := e.client.post(path, dto.ReviewerChangeRequest{PullRequestID: *id, UserID: *user}, *version)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.ReviewerChangeResponse
+	return e.printer.print(body, &resp, func(w io.Writer) {
+		printPR(w, resp.PR)
+	})
+}
+
+func printPR(w io.Writer, pr dto.PullRequest) {
+	row(w, "PR_ID", "NAME", "AUTHOR", "STATUS", "REVIEWERS", "NEED MORE", "VERSION")
+	row(w, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status,
+		orDash(strings.Join(pr.AssignedReviewers, ",")), pr.NeedMoreReviewers, pr.Version)
+}
+
+/* stats */
+
+func statsReviewers(e *env, args []string) error {
+	if err := parseFlags(newFlagSet("stats reviewers"), args); err != nil {
+		return err
+	}
+
+	body, err := e.client.get("/stats/reviewers", nil)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.ReviewerStatsResponse
+	return e.printer.print(body, &resp, func(w io.Writer) {
+		row(w, "USERNAME", "REVIEWS")
+		for _, s := range resp.Stats {
+			row(w, s.Username, s.ReviewsCount)
+		}
+	})
+}
+
+func statsPrs(e *env, args []string) error {
+	if err := parseFlags(newFlagSet("stats prs"), args); err != nil {
+		return err
+	}
+
+	body, err := e.client.get("/stats/prs", nil)
+	if err != nil {
+		return err
+	}
+
+	var resp dto.PrStatsResponse
+	return e.printer.print(body, &resp, func(w io.Writer) {
+		secs := func(v *float64) string {
+			if v == nil {
+				return "-"
+			}
+			return time.Duration(*v * float64(time.Second)).Round(time.Second).String()
+		}
+
+		row(w, "TOTAL PRS", resp.TotalPrs)
+		row(w, "UNDERSTAFFED", fmt.Sprintf("%.1f%%", resp.UnderstaffedShare*100))
+		row(w, "MERGE MEDIAN", secs(resp.MergeTime.MedianSeconds))
+		row(w, "MERGE P90", secs(resp.MergeTime.P90Seconds))
+		row(w)
+		row(w, "TEAM", "OPEN", "MERGED")
+		for _, t := range resp.ByTeam {
+			row(w, t.TeamName, t.OpenCount, t.MergedCount)
+		}
+		row(w)
+		row(w, "AUTHOR", "USERNAME", "OPEN", "MERGED")
+		for _, a := range resp.ByAuthor {
+			row(w, a.AuthorID, a.Username, a.OpenCount, a.MergedCount)
+		}
+	})
+}
+
+// ExitCodeFor выбирает код выхода по ошибке команды
+func ExitCodeFor(err error) int {
+	var apiErr *APIError
+	var usageErr usageError
+	switch {
+	case errors.As(err, &apiErr):
+		return apiErr.ExitCode()
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	default:
+		return ExitFailure
+	}
+}
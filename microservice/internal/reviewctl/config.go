@@ -0,0 +1,71 @@
+package reviewctl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config - куда и с каким токеном ходит reviewctl.
+// Приоритет: флаги > переменные окружения > файл конфига
+type Config struct {
+	URL    string `yaml:"url"`
+	Token  string `yaml:"token"`
+	Output string `yaml:"output"`
+}
+
+const DefaultURL = "http://localhost:8080"
+
+// путь к конфигу по умолчанию: ~/.config/reviewctl.yaml
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "reviewctl.yaml")
+}
+
+// LoadConfig читает файл (если он есть) и поверх него переменные окружения.
+// Явно указанный, но отсутствующий файл - ошибка, файл по умолчанию необязателен
+func LoadConfig(path string) (Config, error) {
+	cfg := Config{URL: DefaultURL, Output: OutputTable}
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv("REVIEWCTL_CONFIG")
+		explicit = path != ""
+	}
+	if !explicit {
+		path = defaultConfigPath()
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				return Config{}, fmt.Errorf("конфиг %s: %w", path, err)
+			}
+		case errors.Is(err, os.ErrNotExist) && !explicit:
+		default:
+			return Config{}, fmt.Errorf("конфиг %s: %w", path, err)
+		}
+	}
+
+	if v := os.Getenv("REVIEWCTL_URL"); v != "" {
+		cfg.URL = v
+	}
+	if v := os.Getenv("REVIEWCTL_TOKEN"); v != "" {
+		cfg.Token = v
+	} else if v := os.Getenv("ADMIN_TOKEN"); v != "" && cfg.Token == "" {
+		cfg.Token = v
+	}
+	if v := os.Getenv("REVIEWCTL_OUTPUT"); v != "" {
+		cfg.Output = v
+	}
+
+	return cfg, nil
+}
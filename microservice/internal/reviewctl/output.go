@@ -0,0 +1,65 @@
+package reviewctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+)
+
+// printer выводит ответ сервера либо как есть в JSON, либо таблицей
+type printer struct {
+	out    io.Writer
+	format string
+}
+
+// print: в json-режиме переформатирует тело ответа, в табличном - раскладывает его в v и рисует table
+func (p *printer) print(body []byte, v any, table func(w io.Writer)) error {
+	if p.format == OutputJSON {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, bytes.TrimSpace(body), "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		_, err := p.out.Write(buf.Bytes())
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("не удалось разобрать ответ сервера: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(p.out, 0, 0, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+// строка таблицы из колонок через табуляцию
+func row(w io.Writer, cols ...any) {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		parts[i] = fmt.Sprint(c)
+	}
+	fmt.Fprintln(w, strings.Join(parts, "\t"))
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func intOrDash(v *int) string {
+	if v == nil {
+		return "-"
+	}
+	return fmt.Sprint(*v)
+}
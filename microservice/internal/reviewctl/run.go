@@ -0,0 +1,85 @@
+package reviewctl
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Run разбирает аргументы командной строки, выполняет команду и возвращает код выхода
+func Run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("reviewctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	urlFlag := fs.String("url", "", "адрес сервиса, по умолчанию "+DefaultURL)
+	tokenFlag := fs.String("token", "", "ADMIN_TOKEN для админских команд")
+	outputFlag := fs.String("o", "", "формат вывода: table или json")
+	configFlag := fs.String("config", "", "путь к YAML-конфигу")
+	fs.Usage = func() { printUsage(stderr) }
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	rest := fs.Args()
+	if len(rest) < 2 {
+		printUsage(stderr)
+		return ExitUsage
+	}
+
+	cmd, ok := commands[rest[0]+" "+rest[1]]
+	if !ok {
+		fmt.Fprintf(stderr, "неизвестная команда: %s %s\n\n", rest[0], rest[1])
+		printUsage(stderr)
+		return ExitUsage
+	}
+
+	cfg, err := LoadConfig(*configFlag)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return ExitUsage
+	}
+	if *urlFlag != "" {
+		cfg.URL = *urlFlag
+	}
+	if *tokenFlag != "" {
+		cfg.Token = *tokenFlag
+	}
+	if *outputFlag != "" {
+		cfg.Output = *outputFlag
+	}
+	if cfg.Output != OutputTable && cfg.Output != OutputJSON {
+		fmt.Fprintf(stderr, "неизвестный формат вывода %q, нужен table или json\n", cfg.Output)
+		return ExitUsage
+	}
+
+	e := &env{
+		client:  newClient(cfg),
+		printer: &printer{out: stdout, format: cfg.Output},
+	}
+
+	if err := cmd.run(e, rest[2:]); err != nil {
+		fmt.Fprintln(stderr, "ошибка:", err)
+		return ExitCodeFor(err)
+	}
+	return ExitOK
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "использование: reviewctl [-url URL] [-token TOKEN] [-o table|json] [-config FILE] <группа> <действие> [флаги]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "команды:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-20s %s\n", name, commands[name].usage)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "коды выхода: 0 ок, 1 прочие ошибки, 2 неверные аргументы, 3 не найдено, 4 нет доступа,")
+	fmt.Fprintln(w, "  5 уже существует, 6 PR смержен, 7 ревьювер не назначен, 8 нет кандидатов,")
+	fmt.Fprintln(w, "  9 конфликт версий, 10 пользователя нельзя назначить ревьювером")
+}
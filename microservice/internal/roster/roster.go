@@ -0,0 +1,179 @@
+package roster
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/validation"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Форматы файла с ростером команд
+const (
+	FormatCSV  = "csv"
+	FormatYAML = "yaml"
+)
+
+var ErrUnknownFormat = errors.New("unknown roster format, use csv or yaml")
+
+// колонки CSV; первая строка файла - заголовок
+var csvHeader = []string{"team", "user_id", "username", "is_active"}
+
+type yamlEntry struct {
+	Team     string `yaml:"team"`
+	UserID   string `yaml:"user_id"`
+	Username string `yaml:"username"`
+	IsActive *bool  `yaml:"is_active"`
+}
+
+// FormatFromName определяет формат по расширению файла или content-type
+func FormatFromName(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "csv"):
+		return FormatCSV
+	case strings.Contains(name, "yaml"), strings.HasSuffix(name, ".yml"):
+		return FormatYAML
+	}
+	return ""
+}
+
+// Parse разбирает файл целиком и возвращает все синтаксические ошибки с номерами строк.
+// Смысловые проверки (обязательные поля, дубли) делает validation.ValidateRoster
+func Parse(r io.Reader, format string) ([]dto.RosterEntry, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatYAML:
+		return parseYAML(r)
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+func parseCSV(r io.Reader) ([]dto.RosterEntry, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, validation.LineErrors{{Line: 1, Msg: err.Error()}}
+	}
+
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	var errs validation.LineErrors
+	for _, name := range csvHeader {
+		if _, ok := cols[name]; !ok && name != "is_active" {
+			errs = append(errs, validation.LineError{Line: 1, Msg: "missing column " + name})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var entries []dto.RosterEntry
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			line := 0
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				line = perr.Line
+			}
+			errs = append(errs, validation.LineError{Line: line, Msg: err.Error()})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := cols[name]
+			if !ok || i >= len(rec) {
+				return ""
+			}
+			return strings.TrimSpace(rec[i])
+		}
+
+		isActive := true
+		if raw := field("is_active"); raw != "" {
+			v, err := strconv.ParseBool(raw)
+			if err != nil {
+				errs = append(errs, validation.LineError{Line: line, Msg: "is_active must be true or false"})
+				continue
+			}
+			isActive = v
+		}
+
+		entries = append(entries, dto.RosterEntry{
+			Line:     line,
+			TeamName: field("team"),
+			UserID:   field("user_id"),
+			Username: field("username"),
+			IsActive: isActive,
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return entries, nil
+}
+
+func parseYAML(r io.Reader) ([]dto.RosterEntry, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil
+		}
+		return nil, validation.LineErrors{{Line: 1, Msg: err.Error()}}
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.SequenceNode {
+		return nil, validation.LineErrors{{Line: 1, Msg: "roster must be a list of entries"}}
+	}
+
+	var (
+		entries []dto.RosterEntry
+		errs    validation.LineErrors
+	)
+	for _, node := range doc.Content[0].Content {
+		var e yamlEntry
+		if err := node.Decode(&e); err != nil {
+			errs = append(errs, validation.LineError{Line: node.Line, Msg: err.Error()})
+			continue
+		}
+
+		isActive := true
+		if e.IsActive != nil {
+			isActive = *e.IsActive
+		}
+
+		entries = append(entries, dto.RosterEntry{
+			Line:     node.Line,
+			TeamName: strings.TrimSpace(e.Team),
+			UserID:   strings.TrimSpace(e.UserID),
+			Username: strings.TrimSpace(e.Username),
+			IsActive: isActive,
+		})
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return entries, nil
+}
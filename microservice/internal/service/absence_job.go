@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/repository"
+)
+
+// AbsenceJob снимает отсутствующих пользователей с открытых ревью, когда начинается их период недоступности
+type AbsenceJob struct {
+	Absences repository.AbsenceRepo
+	Users    repository.UserRepo
+	Locker   repository.Locker
+	PRs      *PRService
+}
+
+func NewAbsenceJob(absences repository.AbsenceRepo, users repository.UserRepo, locker repository.Locker, prs *PRService) *AbsenceJob {
+	return &AbsenceJob{
+		Absences: absences,
+		Users:    users,
+		Locker:   locker,
+		PRs:      prs,
+	}
+}
+
+// Run запускает RunOnce раз в interval, пока не отменят ctx
+func (j *AbsenceJob) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := j.RunOnce(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("absence job", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce переназначает открытые ревью по всем начавшимся и еще не обработанным отсутствиям.
+// Если кандидатов нет, ревьювер остается на PR, а отсутствие все равно помечается обработанным.
+// Если прогон уже идет на другой реплике, ничего не делает
+func (j *AbsenceJob) RunOnce(ctx context.Context) error {
+	release, ok, err := j.Locker.TryLock(ctx, repository.LockKeyAbsence)
+	if err != nil || !ok {
+		return err
+	}
+	defer release()
+
+	now := time.Now().UTC()
+
+	absences, err := j.Absences.ListStartedUnprocessed(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range absences {
+		prs, err := j.Users.GetReviewPrs(ctx, a.UserID, false)
+		if err != nil {
+			return err
+		}
+
+		for _, pr := range prs {
+			if pr.StatusName != string(dto.PrStatusOpen) {
+				continue
+			}
+
+			_, err := j.PRs.Reassign(ctx, dto.ReassignPrRequest{
+				PullRequestID: pr.ID,
+				OldUserID:     a.UserID,
+			})
+			switch {
+			case err == nil:
+			case errors.Is(err, repository.ErrNoCandidate),
+				errors.Is(err, repository.ErrPRMerged),
+				errors.Is(err, repository.ErrReviewerNotSet):
+				slog.Warn("absence job: reviewer not reassigned", "pr", pr.ID, "reviewer", a.UserID, "err", err)
+			default:
+				return err
+			}
+		}
+
+		if err := j.Absences.MarkReviewsReassigned(ctx, a.ID, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
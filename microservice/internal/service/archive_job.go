@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"review-manager/internal/repository"
+)
+
+// сколько PR переносим в архив за одну транзакцию
+const archiveBatchSize = 500
+
+// ArchiveJob переносит в архивные таблицы PR, смерженные раньше чем After назад
+type ArchiveJob struct {
+	Archive repository.ArchiveRepo
+	Locker  repository.Locker
+	Tx      repository.TxManager
+	After   time.Duration
+}
+
+func NewArchiveJob(archive repository.ArchiveRepo, locker repository.Locker, tx repository.TxManager, after time.Duration) *ArchiveJob {
+	return &ArchiveJob{
+		Archive: archive,
+		Locker:  locker,
+		Tx:      tx,
+		After:   after,
+	}
+}
+
+// Run запускает RunOnce раз в interval, пока не отменят ctx
+func (j *ArchiveJob) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := j.RunOnce(ctx)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("archive job", "err", err)
+		}
+		if n > 0 {
+			slog.Info("archive job: archived merged PRs", "count", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce переносит в архив все подходящие PR пачками по archiveBatchSize и возвращает их количество.
+// Если прогон уже идет на другой реплике, ничего не делает
+func (j *ArchiveJob) RunOnce(ctx context.Context) (int, error) {
+	release, ok, err := j.Locker.TryLock(ctx, repository.LockKeyArchive)
+	if err != nil || !ok {
+		return 0, err
+	}
+	defer release()
+
+	mergedBefore := time.Now().UTC().Add(-j.After)
+	total := 0
+
+	for {
+		var ids []string
+		if err := j.Tx.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			ids, err = j.Archive.ArchiveMerged(ctx, mergedBefore, archiveBatchSize)
+			return err
+		}); err != nil {
+			return total, err
+		}
+
+		total += len(ids)
+		if len(ids) < archiveBatchSize {
+			return total, nil
+		}
+	}
+}
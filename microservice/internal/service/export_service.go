@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/repository"
+)
+
+type ExportService struct {
+	Export repository.ExportRepo
+}
+
+func NewExportService(export repository.ExportRepo) *ExportService {
+	return &ExportService{Export: export}
+}
+
+// логика /export/pullRequests - каждую строку сразу отдаем в fn
+func (s *ExportService) ExportPullRequests(
+	ctx context.Context,
+	f dto.ExportPrFilter,
+	fn func(dto.PullRequestExportRow) error,
+) error {
+	filter := repository.PrFilter{
+		TeamName:        f.TeamName,
+		AuthorID:        f.AuthorID,
+		ReviewerID:      f.ReviewerID,
+		IncludeArchived: f.IncludeArchived,
+	}
+	switch dto.PullRequestStatus(f.Status) {
+	case dto.PrStatusOpen:
+		filter.StatusID = repository.StatusOpen
+	case dto.PrStatusMerged:
+		filter.StatusID = repository.StatusMerged
+	}
+
+	return s.Export.StreamPullRequests(ctx, filter, func(row repository.PullRequestExportRow) error {
+		return fn(dto.PullRequestExportRow{
+			PullRequest: PrToDTO(row.PR, row.Reviewers),
+			TeamName:    row.TeamName,
+		})
+	})
+}
+
+// логика /export/teams
+func (s *ExportService) ExportTeams(ctx context.Context, teamName string, fn func(dto.TeamMemberExportRow) error) error {
+	return s.Export.StreamTeamMembers(ctx, teamName, func(row repository.TeamMemberExportRow) error {
+		return fn(dto.TeamMemberExportRow{
+			TeamName: row.TeamName,
+			TeamMember: dto.TeamMember{
+				UserID:   row.UserID,
+				Username: row.Username,
+				IsActive: row.IsActive,
+			},
+		})
+	})
+}
+
+// логика /export/reviewerStats
+func (s *ExportService) ExportReviewerStats(ctx context.Context, teamName string, fn func(dto.ReviewerStatExportRow) error) error {
+	return s.Export.StreamReviewerStats(ctx, teamName, func(row repository.ReviewerStatRow) error {
+		return fn(dto.ReviewerStatExportRow{
+			UserID:       row.UserID,
+			Username:     row.Username,
+			ReviewsCount: row.ReviewsCount,
+		})
+	})
+}
@@ -3,22 +3,37 @@ package service
 import (
 	"context"
 	"math/rand"
+	"time"
+
 	"review-manager/internal/dto"
+	"review-manager/internal/events"
 	"review-manager/internal/repository"
-	"time"
 )
 
 type PRService struct {
-	PRs   repository.PrRepo
-	Users repository.UserRepo
-	Tx    repository.TxManager
+	PRs    repository.PrRepo
+	Users  repository.UserRepo
+	Teams  repository.TeamRepo
+	Owners repository.CodeownersRepo
+	Tx     repository.TxManager
+	Events events.Publisher
 }
 
-func NewPRService(prs repository.PrRepo, users repository.UserRepo, tx repository.TxManager) *PRService {
+func NewPRService(
+	prs repository.PrRepo,
+	users repository.UserRepo,
+	teams repository.TeamRepo,
+	owners repository.CodeownersRepo,
+	tx repository.TxManager,
+	ev events.Publisher,
+) *PRService {
 	return &PRService{
-		PRs:   prs,
-		Users: users,
-		Tx:    tx,
+		PRs:    prs,
+		Users:  users,
+		Teams:  teams,
+		Owners: owners,
+		Tx:     tx,
+		Events: ev,
 	}
 }
 
@@ -39,6 +54,8 @@ func PrToDTO(pr repository.PullRequest, reviewers []string) dto.PullRequest {
 		AuthorID:          pr.AuthorID,
 		Status:            status,
 		AssignedReviewers: reviewers,
+		NeedMoreReviewers: pr.NeedMoreReviewers,
+		Version:           pr.Version,
 		CreatedAt:         &pr.CreatedAt,
 		MergedAt:          pr.MergedAt,
 	}
@@ -59,26 +76,40 @@ func (s *PRService) Create(ctx context.Context, req dto.CreatePrRequest) (dto.Cr
 		return dto.CreatePrResponse{}, err
 	}
 
-	candidates, err := s.Users.FindActiveInTeamExcept(ctx, author.TeamID, author.ID, 2)
+	reasons, err := s.pickReviewers(ctx, author, req.ChangedFiles)
 	if err != nil {
 		return dto.CreatePrResponse{}, err
 	}
-	reviewerIDs := make([]string, 0, len(candidates))
-	for _, c := range candidates {
-		reviewerIDs = append(reviewerIDs, c.ID)
+	reviewerIDs := make([]string, 0, len(reasons))
+	for _, r := range reasons {
+		reviewerIDs = append(reviewerIDs, r.UserID)
+	}
+
+	// если ревьюверов не хватило, проверяем, не упираемся ли мы в лимиты, а не в размер команды
+	limitedByCapacity := false
+	if len(reviewerIDs) < reviewersPerPR {
+		atCapacity, err := s.Users.CountAtCapacityInTeam(ctx, author.TeamID, author.ID)
+		if err != nil {
+			return dto.CreatePrResponse{}, err
+		}
+		limitedByCapacity = atCapacity > 0
 	}
 
 	now := time.Now().UTC()
 
 	prRow := repository.PullRequest{
-		ID:        req.PullRequestID,
-		Name:      req.PullRequestName,
-		AuthorID:  req.AuthorID,
-		StatusID:  repository.StatusOpen,
-		CreatedAt: now,
-		MergedAt:  nil,
+		ID:                req.PullRequestID,
+		Name:              req.PullRequestName,
+		AuthorID:          req.AuthorID,
+		StatusID:          repository.StatusOpen,
+		CreatedAt:         now,
+		MergedAt:          nil,
+		NeedMoreReviewers: len(reviewerIDs) < reviewersPerPR,
+		InitialReviewers:  len(reviewerIDs),
+		Version:           1,
 	}
 
+	// Exists выше - только быстрый отказ, гонку двух Create ловит уникальный ключ в Insert
 	if err := s.Tx.WithinTx(ctx, func(ctx context.Context) error {
 		if err := s.PRs.Insert(ctx, prRow); err != nil {
 			return err
@@ -96,60 +127,117 @@ func (s *PRService) Create(ctx context.Context, req dto.CreatePrRequest) (dto.Cr
 		return dto.CreatePrResponse{}, err
 	}
 
+	prDTO := PrToDTO(prRow, reviewers)
+	s.publishPR(ctx, dto.Event{Type: dto.EventPrCreated, TeamName: author.TeamName}, prDTO)
+	for _, id := range reviewers {
+		s.publishPR(ctx, dto.Event{Type: dto.EventReviewerAssigned, TeamName: author.TeamName, UserID: id}, prDTO)
+	}
+
 	return dto.CreatePrResponse{
-		PR: PrToDTO(prRow, reviewers),
+		PR:                prDTO,
+		ReviewerReasons:   reasons,
+		LimitedByCapacity: limitedByCapacity,
 	}, nil
 }
 
 // логика pullRequest/merge
 func (s *PRService) Merge(ctx context.Context, req dto.MergePrRequest) (dto.MergePullRequestResponse, error) {
 	now := time.Now().UTC()
+	wasMerged := false
 
-	prRow, err := s.PRs.SetMerged(ctx, req.PullRequestID, now)
-	if err != nil {
+	if err := s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		prRow, _, err := s.lockPR(ctx, req.PullRequestID, req.ExpectedVersion)
+		if err != nil {
+			return err
+		}
+		wasMerged = prRow.StatusID == repository.StatusMerged
+
+		_, err = s.PRs.SetMerged(ctx, req.PullRequestID, now)
+		return err
+	}); err != nil {
 		return dto.MergePullRequestResponse{}, err
 	}
 
-	_, reviewers, err := s.PRs.GetWithReviewers(ctx, req.PullRequestID)
+	prRow, reviewers, err := s.PRs.GetWithReviewers(ctx, req.PullRequestID)
 	if err != nil {
 		return dto.MergePullRequestResponse{}, err
 	}
 
+	prDTO := PrToDTO(prRow, reviewers)
+	// повторный merge идемпотентен и события не порождает
+	if !wasMerged {
+		s.publishPR(ctx, dto.Event{Type: dto.EventPrMerged}, prDTO)
+	}
+
 	return dto.MergePullRequestResponse{
-		PR: PrToDTO(prRow, reviewers),
+		PR: prDTO,
 	}, nil
 }
 
 // логика pullRequest/reassign
 func (s *PRService) Reassign(ctx context.Context, req dto.ReassignPrRequest) (dto.ReassignPrResponse, error) {
+	var newID string
+
+	if err := s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		prRow, reviewers, err := s.lockPR(ctx, req.PullRequestID, req.ExpectedVersion)
+		if err != nil {
+			return err
+		}
+
+		if prRow.StatusID == repository.StatusMerged {
+			return repository.ErrPRMerged
+		}
+
+		if !contains(reviewers, req.OldUserID) {
+			return repository.ErrReviewerNotSet
+		}
+
+		if req.NewUserID != "" {
+			if err := s.checkCanReview(ctx, prRow, reviewers, req.NewUserID); err != nil {
+				return err
+			}
+			newID = req.NewUserID
+		} else {
+			newID, err = s.pickReplacement(ctx, prRow, reviewers, req.OldUserID)
+			if err != nil {
+				return err
+			}
+		}
+
+		return s.PRs.ReplaceReviewer(ctx, req.PullRequestID, req.OldUserID, newID)
+	}); err != nil {
+		return dto.ReassignPrResponse{}, err
+	}
+
 	prRow, reviewers, err := s.PRs.GetWithReviewers(ctx, req.PullRequestID)
 	if err != nil {
 		return dto.ReassignPrResponse{}, err
 	}
 
-	if prRow.StatusID == repository.StatusMerged {
-		return dto.ReassignPrResponse{}, repository.ErrPRMerged
-	}
+	prDTO := PrToDTO(prRow, reviewers)
+	s.publishPR(ctx, dto.Event{Type: dto.EventReviewerReplaced, UserID: newID, OldUserID: req.OldUserID}, prDTO)
 
-	found := false
-	for _, r := range reviewers {
-		if r == req.OldUserID {
-			found = true
-			break
-		}
-	}
-	if !found {
-		return dto.ReassignPrResponse{}, repository.ErrReviewerNotSet
-	}
+	return dto.ReassignPrResponse{
+		PR:         prDTO,
+		ReplacedBy: newID,
+	}, nil
+}
 
-	oldUser, err := s.Users.GetByID(ctx, req.OldUserID)
+// случайный активный кандидат из команды заменяемого ревьювера, если таких нет - лид команды
+func (s *PRService) pickReplacement(
+	ctx context.Context,
+	prRow repository.PullRequest,
+	reviewers []string,
+	oldUserID string,
+) (string, error) {
+	oldUser, err := s.Users.GetByID(ctx, oldUserID)
 	if err != nil {
-		return dto.ReassignPrResponse{}, err
+		return "", err
 	}
 
 	candidates, err := s.Users.FindActiveInTeamExcept(ctx, oldUser.TeamID, oldUser.ID, 20)
 	if err != nil {
-		return dto.ReassignPrResponse{}, err
+		return "", err
 	}
 
 	exclude := make(map[string]struct{}, len(reviewers)+1)
@@ -167,22 +255,272 @@ func (s *PRService) Reassign(ctx context.Context, req dto.ReassignPrRequest) (dt
 	}
 
 	if len(possible) == 0 {
-		return dto.ReassignPrResponse{}, repository.ErrNoCandidate
+		exclude[oldUser.ID] = struct{}{}
+		lead, err := s.pickLead(ctx, oldUser.TeamName, exclude)
+		if err != nil {
+			return "", err
+		}
+		if lead != "" {
+			return lead, nil
+		}
+
+		atCapacity, err := s.Users.CountAtCapacityInTeam(ctx, oldUser.TeamID, oldUser.ID)
+		if err != nil {
+			return "", err
+		}
+		if atCapacity > 0 {
+			return "", repository.ErrAtCapacity
+		}
+		return "", repository.ErrNoCandidate
 	}
 
-	newID := possible[rand.Intn(len(possible))]
+	return possible[rand.Intn(len(possible))], nil
+}
 
-	if err := s.PRs.ReplaceReviewer(ctx, req.PullRequestID, req.OldUserID, newID); err != nil {
-		return dto.ReassignPrResponse{}, err
+// логика /pullRequest/addReviewer - добавляем ревьювера сверх уже назначенных
+func (s *PRService) AddReviewer(ctx context.Context, req dto.AddReviewerRequest) (dto.ReviewerChangeResponse, error) {
+	return s.changeReviewers(ctx, req, dto.EventReviewerAssigned, func(ctx context.Context, prRow repository.PullRequest, reviewers []string) error {
+		if err := s.checkCanReview(ctx, prRow, reviewers, req.UserID); err != nil {
+			return err
+		}
+		return s.PRs.AddReviewers(ctx, req.PullRequestID, []string{req.UserID})
+	})
+}
+
+// FillReviewers добирает ревьюверов открытого PR до нужного числа случайными участниками команды автора,
+// если в команде никого нет - лидом. Возвращает PR после изменения и добавленных ревьюверов
+func (s *PRService) FillReviewers(ctx context.Context, prID string) (dto.PullRequest, []string, error) {
+	var added []string
+
+	if err := s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		added = nil
+
+		prRow, reviewers, err := s.lockPR(ctx, prID, nil)
+		if err != nil {
+			return err
+		}
+		if prRow.StatusID == repository.StatusMerged {
+			return repository.ErrPRMerged
+		}
+
+		need := reviewersPerPR - len(reviewers)
+		if need <= 0 {
+			return nil
+		}
+
+		author, err := s.Users.GetByID(ctx, prRow.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		exclude := make(map[string]struct{}, len(reviewers)+1)
+		for _, id := range reviewers {
+			exclude[id] = struct{}{}
+		}
+		exclude[author.ID] = struct{}{}
+
+		// берем с запасом, т.к. часть кандидатов уже может быть ревьюверами
+		candidates, err := s.Users.FindActiveInTeamExcept(ctx, author.TeamID, author.ID, need+len(reviewers))
+		if err != nil {
+			return err
+		}
+		for _, c := range candidates {
+			if len(added) == need {
+				break
+			}
+			if _, used := exclude[c.ID]; used {
+				continue
+			}
+			exclude[c.ID] = struct{}{}
+			added = append(added, c.ID)
+		}
+
+		if len(added) == 0 {
+			lead, err := s.pickLead(ctx, author.TeamName, exclude)
+			if err != nil {
+				return err
+			}
+			if lead == "" {
+				return nil
+			}
+			added = append(added, lead)
+		}
+
+		if err := s.PRs.AddReviewers(ctx, prID, added); err != nil {
+			return err
+		}
+		return s.PRs.RefreshNeedMoreReviewers(ctx, prID)
+	}); err != nil {
+		return dto.PullRequest{}, nil, err
 	}
 
-	prRow, reviewers, err = s.PRs.GetWithReviewers(ctx, req.PullRequestID)
+	prRow, reviewers, err := s.PRs.GetWithReviewers(ctx, prID)
 	if err != nil {
-		return dto.ReassignPrResponse{}, err
+		return dto.PullRequest{}, nil, err
 	}
 
-	return dto.ReassignPrResponse{
-		PR:         PrToDTO(prRow, reviewers),
-		ReplacedBy: newID,
-	}, nil
+	prDTO := PrToDTO(prRow, reviewers)
+	for _, id := range added {
+		s.publishPR(ctx, dto.Event{Type: dto.EventReviewerAssigned, UserID: id}, prDTO)
+	}
+
+	return prDTO, added, nil
+}
+
+// логика /pullRequest/removeReviewer - снимаем ревьювера без замены
+func (s *PRService) RemoveReviewer(ctx context.Context, req dto.RemoveReviewerRequest) (dto.ReviewerChangeResponse, error) {
+	return s.changeReviewers(ctx, req, dto.EventReviewerRemoved, func(ctx context.Context, _ repository.PullRequest, reviewers []string) error {
+		if !contains(reviewers, req.UserID) {
+			return repository.ErrReviewerNotSet
+		}
+		return s.PRs.RemoveReviewer(ctx, req.PullRequestID, req.UserID)
+	})
+}
+
+// меняем состав ревьюверов и пересчитываем need_more_reviewers в одной транзакции под блокировкой PR
+func (s *PRService) changeReviewers(
+	ctx context.Context,
+	req dto.ReviewerChangeRequest,
+	eventType string,
+	change func(ctx context.Context, prRow repository.PullRequest, reviewers []string) error,
+) (dto.ReviewerChangeResponse, error) {
+	if err := s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		prRow, reviewers, err := s.lockPR(ctx, req.PullRequestID, req.ExpectedVersion)
+		if err != nil {
+			return err
+		}
+
+		if prRow.StatusID == repository.StatusMerged {
+			return repository.ErrPRMerged
+		}
+
+		if err := change(ctx, prRow, reviewers); err != nil {
+			return err
+		}
+		return s.PRs.RefreshNeedMoreReviewers(ctx, req.PullRequestID)
+	}); err != nil {
+		return dto.ReviewerChangeResponse{}, err
+	}
+
+	prRow, reviewers, err := s.PRs.GetWithReviewers(ctx, req.PullRequestID)
+	if err != nil {
+		return dto.ReviewerChangeResponse{}, err
+	}
+
+	prDTO := PrToDTO(prRow, reviewers)
+	s.publishPR(ctx, dto.Event{Type: eventType, UserID: req.UserID}, prDTO)
+
+	return dto.ReviewerChangeResponse{PR: prDTO}, nil
+}
+
+// публикуем событие по PR, вызывается только после коммита транзакции.
+// Если команда не передана, берем ее по автору PR
+func (s *PRService) publishPR(ctx context.Context, e dto.Event, pr dto.PullRequest) {
+	if s.Events == nil {
+		return
+	}
+
+	if e.TeamName == "" {
+		if author, err := s.Users.GetByID(ctx, pr.AuthorID); err == nil {
+			e.TeamName = author.TeamName
+		}
+	}
+	e.PullRequestID = pr.PullRequestID
+	e.AuthorID = pr.AuthorID
+	e.PR = &pr
+
+	s.Events.Publish(e)
+}
+
+// блокируем PR до конца транзакции и сверяем версию из If-Match
+func (s *PRService) lockPR(ctx context.Context, prID string, expected *int64) (repository.PullRequest, []string, error) {
+	prRow, reviewers, err := s.PRs.GetForUpdate(ctx, prID)
+	if err != nil {
+		return repository.PullRequest{}, nil, err
+	}
+	if expected != nil && *expected != prRow.Version {
+		return repository.PullRequest{}, nil, repository.ErrVersionConflict
+	}
+	return prRow, reviewers, nil
+}
+
+// те же проверки, что и при автоматическом выборе: пользователь существует, активен,
+// не автор и еще не ревьювер этого PR
+func (s *PRService) checkCanReview(ctx context.Context, prRow repository.PullRequest, reviewers []string, userID string) error {
+	if userID == prRow.AuthorID {
+		return repository.ErrReviewerAuthor
+	}
+	if contains(reviewers, userID) {
+		return repository.ErrAlreadyReviewer
+	}
+
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !u.IsActive {
+		return repository.ErrUserInactive
+	}
+
+	// ручное назначение подчиняется тем же отпускам и лимитам, что и автоматический выбор
+	eligible, underCapacity, err := s.Users.GetAvailability(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !eligible {
+		return repository.ErrUserAbsent
+	}
+	if !underCapacity {
+		return repository.ErrUserAtCapacity
+	}
+	return nil
+}
+
+// логика для /stats/prs
+func (s *PRService) GetPrStats(ctx context.Context) (dto.PrStatsResponse, error) {
+	rows, err := s.PRs.GetPrStats(ctx)
+	if err != nil {
+		return dto.PrStatsResponse{}, err
+	}
+
+	resp := dto.PrStatsResponse{
+		ByTeam:        make([]dto.TeamPrStat, 0, len(rows.ByTeam)),
+		ByAuthor:      make([]dto.AuthorPrStat, 0, len(rows.ByAuthor)),
+		Reassignments: make([]dto.PrReassignStat, 0, len(rows.Reassignments)),
+		MergeTime: dto.MergeTimeStat{
+			MedianSeconds: rows.MergeMedianSec,
+			P90Seconds:    rows.MergeP90Sec,
+		},
+		TotalPrs: rows.TotalPrs,
+	}
+
+	for _, t := range rows.ByTeam {
+		resp.ByTeam = append(resp.ByTeam, dto.TeamPrStat{
+			TeamName:    t.TeamName,
+			OpenCount:   t.OpenCount,
+			MergedCount: t.MergedCount,
+		})
+	}
+
+	for _, a := range rows.ByAuthor {
+		resp.ByAuthor = append(resp.ByAuthor, dto.AuthorPrStat{
+			AuthorID:    a.AuthorID,
+			Username:    a.Username,
+			OpenCount:   a.OpenCount,
+			MergedCount: a.MergedCount,
+		})
+	}
+
+	for _, p := range rows.Reassignments {
+		resp.Reassignments = append(resp.Reassignments, dto.PrReassignStat{
+			PullRequestID: p.PullRequestID,
+			ReassignCount: p.ReassignCount,
+		})
+	}
+
+	if rows.TotalPrs > 0 {
+		resp.UnderstaffedShare = float64(rows.UnderstaffedCount) / float64(rows.TotalPrs)
+	}
+
+	return resp, nil
 }
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/repository"
+)
+
+// ReconcileJob чинит открытые PR, на которых остались выключенные ревьюверы, ревьюверы, ушедшие в другую команду,
+// или ревьюверов меньше нужного: выключенных переназначает логикой Reassign, ушедших снимает, недостающих добирает
+type ReconcileJob struct {
+	Repo   repository.ReconcileRepo
+	Locker repository.Locker
+	PRs    *PRService
+}
+
+func NewReconcileJob(repo repository.ReconcileRepo, locker repository.Locker, prs *PRService) *ReconcileJob {
+	return &ReconcileJob{
+		Repo:   repo,
+		Locker: locker,
+		PRs:    prs,
+	}
+}
+
+// Run запускает RunOnce раз в interval, пока не отменят ctx
+func (j *ReconcileJob) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.RunOnce(ctx, false); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("reconcile job", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce проверяет все открытые PR и чинит сломанные, при dryRun только описывает, что было бы сделано.
+// Одновременно прогон идет только на одной реплике: если блокировку держит другая, возвращаем отчет со Skipped
+func (j *ReconcileJob) RunOnce(ctx context.Context, dryRun bool) (dto.ReconcileReport, error) {
+	report := dto.ReconcileReport{DryRun: dryRun, PullRequests: []dto.ReconcilePr{}}
+
+	release, ok, err := j.Locker.TryLock(ctx, repository.LockKeyReconcile)
+	if err != nil {
+		return report, err
+	}
+	if !ok {
+		report.Skipped = true
+		return report, nil
+	}
+	defer release()
+
+	broken, err := j.Repo.ListBroken(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	for _, b := range broken {
+		var item dto.ReconcilePr
+		if dryRun {
+			item = j.plan(b)
+		} else {
+			item, err = j.repair(ctx, b)
+			if err != nil {
+				return report, err
+			}
+		}
+		report.PullRequests = append(report.PullRequests, item)
+	}
+
+	return report, nil
+}
+
+func newReconcilePr(b repository.BrokenPr) dto.ReconcilePr {
+	item := dto.ReconcilePr{
+		PullRequestID: b.PullRequestID,
+		TeamName:      b.TeamName,
+		Issues:        []string{},
+		Actions:       []dto.ReconcileAction{},
+	}
+	if len(b.Inactive) > 0 {
+		item.Issues = append(item.Issues, dto.ReconcileIssueInactive)
+	}
+	if len(b.Moved) > 0 {
+		item.Issues = append(item.Issues, dto.ReconcileIssueMovedTeam)
+	}
+	if len(b.Reviewers) < reviewersPerPR {
+		item.Issues = append(item.Issues, dto.ReconcileIssueUnderstaffed)
+	}
+	return item
+}
+
+// план ремонта для dry_run: кандидатов не выбираем, только считаем
+func (j *ReconcileJob) plan(b repository.BrokenPr) dto.ReconcilePr {
+	item := newReconcilePr(b)
+
+	left := len(b.Reviewers)
+	for _, id := range b.Reviewers {
+		switch {
+		case contains(b.Moved, id):
+			item.Actions = append(item.Actions, dto.ReconcileAction{Action: dto.ReconcileActionRemoved, ReviewerID: id})
+			left--
+		case contains(b.Inactive, id):
+			item.Actions = append(item.Actions, dto.ReconcileAction{Action: dto.ReconcileActionReassigned, ReviewerID: id})
+		}
+	}
+	for ; left < reviewersPerPR; left++ {
+		item.Actions = append(item.Actions, dto.ReconcileAction{Action: dto.ReconcileActionAdded})
+	}
+
+	return item
+}
+
+// ремонт PR. Ушедшего в другую команду не переназначаем через Reassign: замену тот ищет в его новой команде,
+// поэтому такого ревьювера снимаем, а место добираем из команды автора
+func (j *ReconcileJob) repair(ctx context.Context, b repository.BrokenPr) (dto.ReconcilePr, error) {
+	item := newReconcilePr(b)
+	left := len(b.Reviewers)
+
+	for _, id := range b.Reviewers {
+		moved, inactive := contains(b.Moved, id), contains(b.Inactive, id)
+		if !moved && !inactive {
+			continue
+		}
+
+		if !moved {
+			resp, err := j.PRs.Reassign(ctx, dto.ReassignPrRequest{PullRequestID: b.PullRequestID, OldUserID: id})
+			switch {
+			case err == nil:
+				item.Actions = append(item.Actions, dto.ReconcileAction{
+					Action:     dto.ReconcileActionReassigned,
+					ReviewerID: id,
+					ReplacedBy: resp.ReplacedBy,
+				})
+				continue
+			// заменить некем или ревьювера уже удалили - просто снимаем
+			case errors.Is(err, repository.ErrNoCandidate), errors.Is(err, repository.ErrUserNotFound):
+			case errors.Is(err, repository.ErrPRMerged):
+				return item, nil
+			case errors.Is(err, repository.ErrReviewerNotSet):
+				left--
+				continue
+			default:
+				return item, err
+			}
+		}
+
+		_, err := j.PRs.RemoveReviewer(ctx, dto.RemoveReviewerRequest{PullRequestID: b.PullRequestID, UserID: id})
+		switch {
+		case err == nil:
+			item.Actions = append(item.Actions, dto.ReconcileAction{Action: dto.ReconcileActionRemoved, ReviewerID: id})
+			left--
+		case errors.Is(err, repository.ErrPRMerged):
+			return item, nil
+		case errors.Is(err, repository.ErrReviewerNotSet):
+			left--
+		default:
+			return item, err
+		}
+	}
+
+	if left >= reviewersPerPR {
+		return item, nil
+	}
+
+	pr, added, err := j.PRs.FillReviewers(ctx, b.PullRequestID)
+	switch {
+	case err == nil:
+	// PR смержили, а автора удалили между выборкой и ремонтом
+	case errors.Is(err, repository.ErrPRMerged), errors.Is(err, repository.ErrUserNotFound):
+		slog.Warn("reconcile job: skip", "pr", b.PullRequestID, "err", err)
+		return item, nil
+	default:
+		return item, err
+	}
+
+	for _, id := range added {
+		item.Actions = append(item.Actions, dto.ReconcileAction{Action: dto.ReconcileActionAdded, ReviewerID: id})
+	}
+	item.StillUnderstaffed = len(pr.AssignedReviewers) < reviewersPerPR
+
+	return item, nil
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"review-manager/internal/codeowners"
+	"review-manager/internal/dto"
+	"review-manager/internal/repository"
+)
+
+// сколько ревьюверов назначаем на новый PR
+const reviewersPerPR = repository.RequiredReviewers
+
+// pickReviewers выбирает ревьюверов для нового PR: сначала владельцев измененных файлов
+// по правилам CODEOWNERS команды автора, затем добирает случайных участников команды
+func (s *PRService) pickReviewers(ctx context.Context, author repository.User, changedFiles []string) ([]dto.ReviewerReason, error) {
+	picked, err := s.pickCodeowners(ctx, author, changedFiles)
+	if err != nil {
+		return nil, err
+	}
+	if len(picked) >= reviewersPerPR {
+		return picked, nil
+	}
+
+	used := make(map[string]struct{}, len(picked))
+	for _, p := range picked {
+		used[p.UserID] = struct{}{}
+	}
+
+	// берем с запасом, т.к. часть кандидатов может уже быть выбрана по CODEOWNERS
+	candidates, err := s.Users.FindActiveInTeamExcept(ctx, author.TeamID, author.ID, reviewersPerPR+len(picked))
+	if err != nil {
+		return nil, err
+	}
+	fromTeam := 0
+	for _, c := range candidates {
+		if len(picked) == reviewersPerPR {
+			break
+		}
+		if _, ok := used[c.ID]; ok {
+			continue
+		}
+		used[c.ID] = struct{}{}
+		picked = append(picked, dto.ReviewerReason{UserID: c.ID, Source: dto.ReviewerSourceTeam})
+		fromTeam++
+	}
+
+	// в команде никого не нашлось - последним кандидатом берем лида
+	if fromTeam == 0 && len(picked) < reviewersPerPR {
+		used[author.ID] = struct{}{}
+		lead, err := s.pickLead(ctx, author.TeamName, used)
+		if err != nil {
+			return nil, err
+		}
+		if lead != "" {
+			picked = append(picked, dto.ReviewerReason{UserID: lead, Source: dto.ReviewerSourceLead})
+		}
+	}
+
+	return picked, nil
+}
+
+// лиды команды и ее родительских команд, от ближайшей к корню
+func (s *PRService) leadChain(ctx context.Context, teamName string) ([]string, error) {
+	if s.Teams == nil {
+		return nil, nil
+	}
+
+	team, err := s.Teams.GetByName(ctx, teamName)
+	if errors.Is(err, repository.ErrTeamNotFound) {
+		// команды нет в справочнике - и лидов нет
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := s.Teams.ListAncestors(ctx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var leads []string
+	for _, t := range append([]repository.Team{team}, ancestors...) {
+		if t.LeadUserID != nil && !contains(leads, *t.LeadUserID) {
+			leads = append(leads, *t.LeadUserID)
+		}
+	}
+	return leads, nil
+}
+
+// pickLead - ближайший по иерархии доступный лид не из exclude, "" - такого нет.
+// Доступность та же, что и у обычных кандидатов: активен, не отсутствует, не упирается в лимит
+func (s *PRService) pickLead(ctx context.Context, teamName string, exclude map[string]struct{}) (string, error) {
+	leads, err := s.leadChain(ctx, teamName)
+	if err != nil {
+		return "", err
+	}
+
+	var ids []string
+	for _, id := range leads {
+		if _, ok := exclude[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return "", nil
+	}
+
+	available, err := s.Users.FindAvailable(ctx, ids, nil, "")
+	if err != nil {
+		return "", err
+	}
+	for _, id := range ids {
+		for _, u := range available {
+			if u.ID == id {
+				return id, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+func (s *PRService) pickCodeowners(ctx context.Context, author repository.User, changedFiles []string) ([]dto.ReviewerReason, error) {
+	if len(changedFiles) == 0 || s.Owners == nil {
+		return nil, nil
+	}
+
+	rows, err := s.Owners.ListByTeam(ctx, author.TeamID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	rules := make([]codeowners.Rule, 0, len(rows))
+	for _, r := range rows {
+		rules = append(rules, codeowners.Rule{Line: r.Line, Pattern: r.Pattern, Owners: r.Owners})
+	}
+
+	// правила в порядке первого совпавшего файла
+	var matched []codeowners.Rule
+	seenRule := make(map[int]struct{})
+	for _, f := range changedFiles {
+		rule, ok := codeowners.MatchFile(rules, f)
+		if !ok {
+			continue
+		}
+		if _, dup := seenRule[rule.Line]; dup {
+			continue
+		}
+		seenRule[rule.Line] = struct{}{}
+		matched = append(matched, rule)
+	}
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	var userIDs, teamNames []string
+	for _, rule := range matched {
+		u, t := rule.Split()
+		userIDs = append(userIDs, u...)
+		teamNames = append(teamNames, t...)
+	}
+
+	available, err := s.Users.FindAvailable(ctx, userIDs, teamNames, author.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var picked []dto.ReviewerReason
+	used := make(map[string]struct{})
+	for _, rule := range matched {
+		ruleUsers, ruleTeams := rule.Split()
+		for _, u := range available {
+			if len(picked) == reviewersPerPR {
+				return picked, nil
+			}
+			if _, ok := used[u.ID]; ok {
+				continue
+			}
+			if !contains(ruleUsers, u.ID) && !contains(ruleTeams, u.TeamName) {
+				continue
+			}
+			used[u.ID] = struct{}{}
+			picked = append(picked, dto.ReviewerReason{
+				UserID: u.ID,
+				Source: dto.ReviewerSourceCodeowners,
+				Rule:   "line " + strconv.Itoa(rule.Line) + ": " + rule.String(),
+			})
+		}
+	}
+
+	return picked, nil
+}
+
+func contains(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/repository"
+)
+
+// StaleReviewJob следит за SLA ревью: по истечении SLA напоминает ревьюверу, а после порога эскалации
+// переназначает ревью через логику Reassign или, если заменить некем, эскалирует его лиду команды
+type StaleReviewJob struct {
+	Stale  repository.StaleReviewRepo
+	Locker repository.Locker
+	PRs    *PRService
+
+	// пороги для команд, у которых не заданы свои; меняются на лету через SetDefaults
+	mu              sync.Mutex
+	DefaultSLA      time.Duration
+	DefaultEscalate time.Duration
+}
+
+func NewStaleReviewJob(
+	stale repository.StaleReviewRepo,
+	locker repository.Locker,
+	prs *PRService,
+	defaultSLA, defaultEscalate time.Duration,
+) *StaleReviewJob {
+	return &StaleReviewJob{
+		Stale:           stale,
+		Locker:          locker,
+		PRs:             prs,
+		DefaultSLA:      defaultSLA,
+		DefaultEscalate: defaultEscalate,
+	}
+}
+
+// SetDefaults заменяет пороги по умолчанию, действует со следующего прогона
+func (j *StaleReviewJob) SetDefaults(sla, escalate time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.DefaultSLA, j.DefaultEscalate = sla, escalate
+}
+
+func (j *StaleReviewJob) defaults() (time.Duration, time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.DefaultSLA, j.DefaultEscalate
+}
+
+// Run запускает RunOnce раз в interval, пока не отменят ctx
+func (j *StaleReviewJob) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.RunOnce(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			slog.Error("stale review job", "err", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce обрабатывает все зависшие назначения. Одновременно прогон идет только на одной реплике:
+// если advisory-блокировку держит другая, возвращаем отчет со Skipped
+func (j *StaleReviewJob) RunOnce(ctx context.Context) (dto.StaleReviewReport, error) {
+	report := dto.StaleReviewReport{Actions: []dto.StaleReviewAction{}}
+
+	release, ok, err := j.Locker.TryLock(ctx, repository.LockKeyStaleReviews)
+	if err != nil {
+		return report, err
+	}
+	if !ok {
+		report.Skipped = true
+		return report, nil
+	}
+	defer release()
+
+	now := time.Now().UTC()
+
+	sla, escalate := j.defaults()
+	stale, err := j.Stale.ListStale(ctx, now, sla, escalate)
+	if err != nil {
+		return report, err
+	}
+
+	for _, a := range stale {
+		age := now.Sub(a.AssignedAt)
+		action := dto.StaleReviewAction{
+			PullRequestID: a.PullRequestID,
+			ReviewerID:    a.ReviewerID,
+			TeamName:      a.TeamName,
+			AssignedAt:    a.AssignedAt,
+		}
+
+		switch {
+		case a.EscalatedAt == nil && age >= a.EscalateAfter:
+			resp, err := j.PRs.Reassign(ctx, dto.ReassignPrRequest{
+				PullRequestID: a.PullRequestID,
+				OldUserID:     a.ReviewerID,
+			})
+			switch {
+			case err == nil:
+				action.Action = dto.StaleActionReassigned
+				action.ReplacedBy = resp.ReplacedBy
+			case errors.Is(err, repository.ErrNoCandidate):
+				lead, err := j.escalationTarget(ctx, a)
+				if err != nil {
+					return report, err
+				}
+				if err := j.Stale.MarkEscalated(ctx, a.PullRequestID, a.ReviewerID, now); err != nil {
+					return report, err
+				}
+				j.publish(ctx, dto.Event{Type: dto.EventReviewEscalated, EscalatedTo: lead}, a)
+				action.Action = dto.StaleActionEscalated
+				action.EscalatedTo = lead
+			case errors.Is(err, repository.ErrPRMerged),
+				errors.Is(err, repository.ErrReviewerNotSet):
+				// PR успели смержить или ревьювера сменили между выборкой и переназначением
+				slog.Warn("stale review job: skip", "pr", a.PullRequestID, "reviewer", a.ReviewerID, "err", err)
+				continue
+			default:
+				return report, err
+			}
+
+		case a.RemindedAt == nil && age >= a.SLA:
+			if err := j.Stale.MarkReminded(ctx, a.PullRequestID, a.ReviewerID, now); err != nil {
+				if errors.Is(err, repository.ErrReviewerNotSet) {
+					continue
+				}
+				return report, err
+			}
+			j.publish(ctx, dto.Event{Type: dto.EventReviewReminder}, a)
+			action.Action = dto.StaleActionReminded
+
+		default:
+			continue
+		}
+
+		report.Actions = append(report.Actions, action)
+	}
+
+	return report, nil
+}
+
+// эскалация уходит ближайшему по иерархии лиду, кроме самого зависшего ревьювера, "" - лида нет
+func (j *StaleReviewJob) escalationTarget(ctx context.Context, a repository.StaleAssignment) (string, error) {
+	leads, err := j.PRs.leadChain(ctx, a.TeamName)
+	if err != nil {
+		return "", err
+	}
+	for _, id := range leads {
+		if id != a.ReviewerID {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
+// публикуем напоминание или эскалацию с текущим состоянием PR, ошибка чтения только логируется
+func (j *StaleReviewJob) publish(ctx context.Context, e dto.Event, a repository.StaleAssignment) {
+	if j.PRs.Events == nil {
+		return
+	}
+
+	prRow, reviewers, err := j.PRs.PRs.GetWithReviewers(ctx, a.PullRequestID)
+	if err != nil {
+		slog.Error("stale review job: publish", "pr", a.PullRequestID, "err", err)
+		return
+	}
+
+	e.TeamName = a.TeamName
+	e.UserID = a.ReviewerID
+	j.PRs.publishPR(ctx, e, PrToDTO(prRow, reviewers))
+}
@@ -2,22 +2,38 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 
+	"review-manager/internal/codeowners"
 	"review-manager/internal/dto"
 	"review-manager/internal/repository"
 )
 
 type TeamService struct {
-	Teams repository.TeamRepo
-	Users repository.UserRepo
-	Tx    repository.TxManager
+	Teams    repository.TeamRepo
+	Users    repository.UserRepo
+	Owners   repository.CodeownersRepo
+	Webhooks repository.WebhookRepo
+	Tx       repository.TxManager
 }
 
-func NewTeamService(teams repository.TeamRepo, users repository.UserRepo, tx repository.TxManager) *TeamService {
+func NewTeamService(
+	teams repository.TeamRepo,
+	users repository.UserRepo,
+	owners repository.CodeownersRepo,
+	webhooks repository.WebhookRepo,
+	tx repository.TxManager,
+) *TeamService {
 	return &TeamService{
-		Teams: teams,
-		Users: users,
-		Tx:    tx,
+		Teams:    teams,
+		Users:    users,
+		Owners:   owners,
+		Webhooks: webhooks,
+		Tx:       tx,
 	}
 }
 
@@ -33,13 +49,21 @@ func (s *TeamService) TeamAdd(ctx context.Context, req dto.TeamAddRequest) (dto.
 			return err
 		}
 
+		if req.DefaultMaxOpenReviews != nil {
+			teamRow, err = s.Teams.SetDefaultMaxOpenReviews(ctx, teamRow.Name, req.DefaultMaxOpenReviews)
+			if err != nil {
+				return err
+			}
+		}
+
 		members := make([]repository.User, 0, len(req.Members))
 		for _, m := range req.Members {
 			members = append(members, repository.User{
-				ID:       m.UserID,
-				Username: m.Username,
-				TeamID:   teamRow.ID,
-				IsActive: m.IsActive,
+				ID:             m.UserID,
+				Username:       m.Username,
+				TeamID:         teamRow.ID,
+				IsActive:       m.IsActive,
+				MaxOpenReviews: m.MaxOpenReviews,
 			})
 		}
 
@@ -47,21 +71,52 @@ func (s *TeamService) TeamAdd(ctx context.Context, req dto.TeamAddRequest) (dto.
 			return err
 		}
 
+		if req.ReviewSlaHours != nil || req.EscalationHours != nil {
+			teamRow, err = s.Teams.SetReviewSla(ctx, teamRow.Name, req.ReviewSlaHours, req.EscalationHours)
+			if err != nil {
+				return err
+			}
+		}
+
+		// у новой команды нет потомков, поэтому цикл через родителя невозможен
+		if req.ParentTeam != "" {
+			parent, err := s.Teams.GetByName(ctx, req.ParentTeam)
+			if err != nil {
+				return err
+			}
+			teamRow, err = s.Teams.SetParent(ctx, teamRow.Name, &parent.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		// токен лиду выдается отдельно через /team/setLead
+		if req.LeadUserID != "" {
+			teamRow, err = s.Teams.SetLead(ctx, teamRow.Name, &req.LeadUserID, nil)
+			if err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
 		return dto.TeamAddResponse{}, err
 	}
 
-	dtoTeam := dto.Team{
-		TeamName: teamRow.Name,
-		Members:  append([]dto.TeamMember(nil), req.Members...),
+	members := make([]dto.TeamMember, 0, len(req.Members))
+	for _, m := range req.Members {
+		m.OpenReviews = nil
+		members = append(members, m)
 	}
 
+	dtoTeam := teamToDTO(teamRow, members)
+
 	return dto.TeamAddResponse{Team: dtoTeam}, nil
 }
 
-// логика team/get - выводим команду со всеми участниками
+// логика team/get - выводим команду со всеми участниками.
+// Участники читаются с реплики, поэтому сразу после записи вызываем с repository.WithPrimary
 func (s *TeamService) TeamGet(ctx context.Context, teamName string) (dto.Team, error) {
 	teamRow, err := s.Teams.GetByName(ctx, teamName)
 	if err != nil {
@@ -75,15 +130,358 @@ func (s *TeamService) TeamGet(ctx context.Context, teamName string) (dto.Team, e
 
 	members := make([]dto.TeamMember, 0, len(users))
 	for _, u := range users {
+		openReviews := u.OpenReviews
 		members = append(members, dto.TeamMember{
-			UserID:   u.ID,
-			Username: u.Username,
-			IsActive: u.IsActive,
+			UserID:         u.ID,
+			Username:       u.Username,
+			IsActive:       u.IsActive,
+			MaxOpenReviews: u.MaxOpenReviews,
+			OpenReviews:    &openReviews,
+		})
+	}
+
+	ancestors, err := s.Teams.ListAncestors(ctx, teamRow.ID)
+	if err != nil {
+		return dto.Team{}, err
+	}
+	children, err := s.Teams.ListChildren(ctx, teamRow.ID)
+	if err != nil {
+		return dto.Team{}, err
+	}
+
+	team := teamToDTO(teamRow, members)
+	for _, a := range ancestors {
+		team.Ancestors = append(team.Ancestors, a.Name)
+	}
+	for _, c := range children {
+		team.ChildTeams = append(team.ChildTeams, c.Name)
+	}
+
+	return team, nil
+}
+
+func teamToDTO(t repository.Team, members []dto.TeamMember) dto.Team {
+	team := dto.Team{
+		TeamName:              t.Name,
+		DefaultMaxOpenReviews: t.DefaultMaxOpenReviews,
+		ReviewSlaHours:        t.ReviewSlaHours,
+		EscalationHours:       t.EscalationHours,
+		Members:               members,
+	}
+	if t.LeadUserID != nil {
+		team.LeadUserID = *t.LeadUserID
+	}
+	if t.ParentTeamName != nil {
+		team.ParentTeam = *t.ParentTeamName
+	}
+	return team
+}
+
+// логика /team/setDefaultCapacity - меняем лимит по умолчанию и отдаем команду с участниками
+func (s *TeamService) SetDefaultCapacity(ctx context.Context, req dto.SetTeamCapacityRequest) (dto.Team, error) {
+	if _, err := s.Teams.SetDefaultMaxOpenReviews(ctx, req.TeamName, req.DefaultMaxOpenReviews); err != nil {
+		return dto.Team{}, err
+	}
+
+	return s.TeamGet(repository.WithPrimary(ctx), req.TeamName)
+}
+
+// логика /team/setReviewSla - меняем пороги напоминания и эскалации зависших ревью
+func (s *TeamService) SetReviewSla(ctx context.Context, req dto.SetTeamReviewSlaRequest) (dto.Team, error) {
+	if _, err := s.Teams.SetReviewSla(ctx, req.TeamName, req.ReviewSlaHours, req.EscalationHours); err != nil {
+		return dto.Team{}, err
+	}
+
+	return s.TeamGet(repository.WithPrimary(ctx), req.TeamName)
+}
+
+// логика /team/setLead - назначаем лида и выпускаем ему новый токен, прежний токен команды перестает действовать
+func (s *TeamService) SetLead(ctx context.Context, req dto.SetTeamLeadRequest) (dto.SetTeamLeadResponse, error) {
+	var (
+		leadID, tokenHash *string
+		token             string
+	)
+
+	if req.UserID != "" {
+		u, err := s.Users.GetByID(ctx, req.UserID)
+		if err != nil {
+			return dto.SetTeamLeadResponse{}, err
+		}
+		if !u.IsActive {
+			return dto.SetTeamLeadResponse{}, repository.ErrUserInactive
+		}
+
+		token, err = newLeadToken()
+		if err != nil {
+			return dto.SetTeamLeadResponse{}, err
+		}
+		hash := hashLeadToken(token)
+		leadID, tokenHash = &req.UserID, &hash
+	}
+
+	if _, err := s.Teams.SetLead(ctx, req.TeamName, leadID, tokenHash); err != nil {
+		return dto.SetTeamLeadResponse{}, err
+	}
+
+	team, err := s.TeamGet(repository.WithPrimary(ctx), req.TeamName)
+	if err != nil {
+		return dto.SetTeamLeadResponse{}, err
+	}
+
+	return dto.SetTeamLeadResponse{Team: team, LeadToken: token}, nil
+}
+
+// логика /team/setParent - переносим команду в иерархии, не допуская циклов
+func (s *TeamService) SetParent(ctx context.Context, req dto.SetTeamParentRequest) (dto.Team, error) {
+	err := s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		team, err := s.Teams.GetByName(ctx, req.TeamName)
+		if err != nil {
+			return err
+		}
+
+		if req.ParentTeam == "" {
+			_, err = s.Teams.SetParent(ctx, team.Name, nil)
+			return err
+		}
+
+		parent, err := s.Teams.GetByName(ctx, req.ParentTeam)
+		if err != nil {
+			return err
+		}
+
+		// команда не может оказаться среди собственных предков
+		ancestors, err := s.Teams.ListAncestors(ctx, parent.ID)
+		if err != nil {
+			return err
+		}
+		if parent.ID == team.ID || len(ancestors) >= repository.MaxTeamDepth-1 {
+			return repository.ErrTeamCycle
+		}
+		for _, a := range ancestors {
+			if a.ID == team.ID {
+				return repository.ErrTeamCycle
+			}
+		}
+
+		_, err = s.Teams.SetParent(ctx, team.Name, &parent.ID)
+		return err
+	})
+	if err != nil {
+		return dto.Team{}, err
+	}
+
+	return s.TeamGet(repository.WithPrimary(ctx), req.TeamName)
+}
+
+// IsLeadToken - принадлежит ли token лиду команды teamName или лиду одной из ее родительских команд
+func (s *TeamService) IsLeadToken(ctx context.Context, teamName, token string) (bool, error) {
+	// токен сверяем по свежим данным, мимо кэша и реплик: снятый лид должен сразу терять доступ
+	ctx = repository.WithPrimary(ctx)
+
+	team, err := s.Teams.GetByName(ctx, teamName)
+	if err != nil {
+		return false, err
+	}
+
+	ancestors, err := s.Teams.ListAncestors(ctx, team.ID)
+	if err != nil {
+		return false, err
+	}
+
+	hash := hashLeadToken(token)
+	for _, t := range append([]repository.Team{team}, ancestors...) {
+		if t.LeadTokenHash != nil && subtle.ConstantTimeCompare([]byte(*t.LeadTokenHash), []byte(hash)) == 1 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsUserLeadToken - то же, что IsLeadToken, для команды пользователя userID
+func (s *TeamService) IsUserLeadToken(ctx context.Context, userID, token string) (bool, error) {
+	u, err := s.Users.GetByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return s.IsLeadToken(ctx, u.TeamName, token)
+}
+
+func newLeadToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashLeadToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// логика /team/import - сначала считаем план изменений, при dryRun на этом и останавливаемся,
+// иначе применяем его целиком в одной транзакции
+func (s *TeamService) ImportRoster(ctx context.Context, entries []dto.RosterEntry, dryRun bool) (dto.ImportRosterResponse, error) {
+	resp := dto.ImportRosterResponse{
+		DryRun:       dryRun,
+		TeamsCreated: []string{},
+		TeamsUpdated: []string{},
+		UsersCreated: []string{},
+		UsersUpdated: []string{},
+	}
+
+	// сохраняем порядок команд как в файле
+	var teamOrder []string
+	byTeam := make(map[string][]dto.RosterEntry)
+	for _, e := range entries {
+		if _, ok := byTeam[e.TeamName]; !ok {
+			teamOrder = append(teamOrder, e.TeamName)
+		}
+		byTeam[e.TeamName] = append(byTeam[e.TeamName], e)
+	}
+
+	existingTeams := make(map[string]repository.Team, len(teamOrder))
+	for _, name := range teamOrder {
+		t, err := s.Teams.GetByName(ctx, name)
+		switch {
+		case err == nil:
+			existingTeams[name] = t
+			resp.TeamsUpdated = append(resp.TeamsUpdated, name)
+		case errors.Is(err, repository.ErrTeamNotFound):
+			resp.TeamsCreated = append(resp.TeamsCreated, name)
+		default:
+			return dto.ImportRosterResponse{}, err
+		}
+	}
+
+	for _, e := range entries {
+		u, err := s.Users.GetByID(ctx, e.UserID)
+		switch {
+		case errors.Is(err, repository.ErrUserNotFound):
+			resp.UsersCreated = append(resp.UsersCreated, e.UserID)
+		case err != nil:
+			return dto.ImportRosterResponse{}, err
+		case u.Username != e.Username || u.IsActive != e.IsActive || u.TeamID != existingTeams[e.TeamName].ID:
+			resp.UsersUpdated = append(resp.UsersUpdated, e.UserID)
+		default:
+			resp.UsersUnchanged++
+		}
+	}
+
+	if dryRun {
+		return resp, nil
+	}
+
+	err := s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		for _, name := range teamOrder {
+			teamRow, ok := existingTeams[name]
+			if !ok {
+				var err error
+				teamRow, err = s.Teams.Create(ctx, name)
+				if err != nil {
+					return err
+				}
+			}
+
+			members := make([]repository.User, 0, len(byTeam[name]))
+			for _, e := range byTeam[name] {
+				members = append(members, repository.User{
+					ID:       e.UserID,
+					Username: e.Username,
+					TeamID:   teamRow.ID,
+					IsActive: e.IsActive,
+				})
+			}
+
+			if err := s.Users.UpsertTeamMembers(ctx, teamRow.ID, members); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return dto.ImportRosterResponse{}, err
+	}
+
+	return resp, nil
+}
+
+// логика /team/setCodeowners - целиком заменяем правила команды
+func (s *TeamService) SetCodeowners(ctx context.Context, teamName string, rules []codeowners.Rule) (dto.CodeownersResponse, error) {
+	teamRow, err := s.Teams.GetByName(ctx, teamName)
+	if err != nil {
+		return dto.CodeownersResponse{}, err
+	}
+
+	rows := make([]repository.CodeownersRule, 0, len(rules))
+	for _, r := range rules {
+		rows = append(rows, repository.CodeownersRule{Line: r.Line, Pattern: r.Pattern, Owners: r.Owners})
+	}
+
+	if err := s.Tx.WithinTx(ctx, func(ctx context.Context) error {
+		return s.Owners.ReplaceForTeam(ctx, teamRow.ID, rows)
+	}); err != nil {
+		return dto.CodeownersResponse{}, err
+	}
+
+	return codeownersToDTO(teamRow.Name, rows), nil
+}
+
+// логика /team/getCodeowners
+func (s *TeamService) GetCodeowners(ctx context.Context, teamName string) (dto.CodeownersResponse, error) {
+	teamRow, err := s.Teams.GetByName(ctx, teamName)
+	if err != nil {
+		return dto.CodeownersResponse{}, err
+	}
+
+	rows, err := s.Owners.ListByTeam(ctx, teamRow.ID)
+	if err != nil {
+		return dto.CodeownersResponse{}, err
+	}
+
+	return codeownersToDTO(teamRow.Name, rows), nil
+}
+
+func codeownersToDTO(teamName string, rows []repository.CodeownersRule) dto.CodeownersResponse {
+	resp := dto.CodeownersResponse{
+		TeamName: teamName,
+		Rules:    make([]dto.CodeownersRule, 0, len(rows)),
+	}
+	for _, r := range rows {
+		resp.Rules = append(resp.Rules, dto.CodeownersRule{
+			Line:    r.Line,
+			Pattern: r.Pattern,
+			Owners:  r.Owners,
 		})
 	}
+	return resp
+}
+
+// логика /team/setWebhook - задаем webhook чата команды, пустой url его снимает
+func (s *TeamService) SetWebhook(ctx context.Context, req dto.SetTeamWebhookRequest) (dto.TeamWebhook, error) {
+	if req.URL == "" {
+		if _, err := s.Teams.GetByName(ctx, req.TeamName); err != nil {
+			return dto.TeamWebhook{}, err
+		}
+		if err := s.Webhooks.Delete(ctx, req.TeamName); err != nil {
+			return dto.TeamWebhook{}, err
+		}
+		return dto.TeamWebhook{TeamName: req.TeamName}, nil
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = dto.WebhookKindSlack
+	}
+
+	w, err := s.Webhooks.Set(ctx, req.TeamName, req.URL, kind)
+	if err != nil {
+		return dto.TeamWebhook{}, err
+	}
 
-	return dto.Team{
-		TeamName: teamRow.Name,
-		Members:  members,
+	return dto.TeamWebhook{
+		TeamName: w.TeamName,
+		URL:      w.URL,
+		Kind:     w.Kind,
 	}, nil
 }
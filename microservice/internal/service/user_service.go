@@ -2,17 +2,55 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
 
 	"review-manager/internal/dto"
+	"review-manager/internal/events"
 	"review-manager/internal/repository"
 )
 
 type UserService struct {
-	Users repository.UserRepo
+	Users    repository.UserRepo
+	Absences repository.AbsenceRepo
+	PRs      *PRService
+	Events   events.Publisher
 }
 
-func NewUserService(users repository.UserRepo) *UserService {
-	return &UserService{Users: users}
+func NewUserService(
+	users repository.UserRepo,
+	absences repository.AbsenceRepo,
+	prs *PRService,
+	ev events.Publisher,
+) *UserService {
+	return &UserService{
+		Users:    users,
+		Absences: absences,
+		PRs:      prs,
+		Events:   ev,
+	}
+}
+
+func UserToDTO(u repository.User) dto.User {
+	return dto.User{
+		UserID:         u.ID,
+		Username:       u.Username,
+		TeamName:       u.TeamName,
+		IsActive:       u.IsActive,
+		MaxOpenReviews: u.MaxOpenReviews,
+	}
+}
+
+func AbsenceToDTO(a repository.Absence) dto.Absence {
+	return dto.Absence{
+		AbsenceID: a.ID,
+		UserID:    a.UserID,
+		StartsAt:  a.StartsAt,
+		EndsAt:    a.EndsAt,
+		Reason:    a.Reason,
+	}
 }
 
 // логика для /users/setIsActive.
@@ -22,24 +60,114 @@ func (s *UserService) SetIsActive(ctx context.Context, req dto.SetUserIsActiveRe
 		return dto.User{}, err
 	}
 
-	return dto.User{
-		UserID:   u.ID,
-		Username: u.Username,
-		TeamName: u.TeamName,
-		IsActive: u.IsActive,
-	}, nil
+	if !req.IsActive && s.Events != nil {
+		s.Events.Publish(dto.Event{
+			Type:     dto.EventUserDeactivated,
+			TeamName: u.TeamName,
+			UserID:   u.ID,
+		})
+	}
+
+	return UserToDTO(u), nil
+}
+
+// логика для /users/setCapacity
+func (s *UserService) SetCapacity(ctx context.Context, req dto.SetUserCapacityRequest) (dto.User, error) {
+	u, err := s.Users.SetMaxOpenReviews(ctx, req.UserID, req.MaxOpenReviews)
+	if err != nil {
+		return dto.User{}, err
+	}
+
+	return UserToDTO(u), nil
+}
+
+// логика для /users/getAuthored: PR пользователя как автора и состояние их ревьюверов
+func (s *UserService) GetAuthoredPRs(ctx context.Context, userID string) (dto.UserGetAuthoredResponse, error) {
+	if _, err := s.Users.GetByID(ctx, userID); err != nil {
+		return dto.UserGetAuthoredResponse{}, err
+	}
+
+	rows, err := s.PRs.PRs.ListByAuthor(ctx, userID)
+	if err != nil {
+		return dto.UserGetAuthoredResponse{}, err
+	}
+
+	resp := dto.UserGetAuthoredResponse{
+		UserID:       userID,
+		PullRequests: make([]dto.AuthoredPullRequest, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		ids := make([]string, 0, len(row.Reviewers))
+		out := dto.AuthoredPullRequest{Reviewers: make([]dto.AuthoredReviewer, 0, len(row.Reviewers))}
+
+		for _, rv := range row.Reviewers {
+			ids = append(ids, rv.UserID)
+			out.Reviewers = append(out.Reviewers, dto.AuthoredReviewer{
+				UserID:     rv.UserID,
+				Username:   rv.Username,
+				TeamName:   rv.TeamName,
+				IsActive:   rv.IsActive,
+				IsAbsent:   rv.IsAbsent,
+				MovedTeam:  rv.MovedTeam,
+				AssignedAt: rv.AssignedAt,
+			})
+			// на смерженном PR менять уже нечего
+			if row.PR.StatusID == repository.StatusOpen && (!rv.IsActive || rv.MovedTeam) {
+				out.NeedsReassign = true
+			}
+		}
+		out.PR = PrToDTO(row.PR, ids)
+
+		if out.NeedsReassign {
+			resp.NeedsReassignCount++
+		}
+		resp.PullRequests = append(resp.PullRequests, out)
+	}
+
+	return resp, nil
 }
 
-// логика для /users/getReview.
-func (s *UserService) GetReviewPRs(ctx context.Context, userID string) (dto.UserGetReviewResponse, error) {
-	prs, err := s.Users.GetReviewPrs(ctx, userID)
+// курсор /users/getReview не разбирается - его подделали или он от другой версии
+var ErrBadCursor = errors.New("invalid cursor")
+
+// логика для /users/getReview: страница PR по фильтру и общее их число
+func (s *UserService) GetReviewPRs(ctx context.Context, req dto.UserGetReviewRequest) (dto.UserGetReviewResponse, error) {
+	f := repository.ReviewPrFilter{
+		UserID:          req.UserID,
+		IncludeArchived: req.IncludeArchived,
+		Limit:           req.Limit,
+	}
+	for _, st := range req.Statuses {
+		f.Statuses = append(f.Statuses, string(st))
+	}
+	if req.Cursor != "" {
+		at, id, err := decodeReviewCursor(req.Cursor)
+		if err != nil {
+			return dto.UserGetReviewResponse{}, err
+		}
+		f.AfterCreatedAt, f.AfterID = &at, id
+	}
+
+	prs, total, err := s.Users.ListReviewPrs(ctx, f)
+	if err != nil {
+		return dto.UserGetReviewResponse{}, err
+	}
+
+	absences, err := s.Absences.ListUpcoming(ctx, req.UserID, time.Now().UTC())
 	if err != nil {
 		return dto.UserGetReviewResponse{}, err
 	}
 
 	resp := dto.UserGetReviewResponse{
-		UserID:       userID,
-		PullRequests: make([]dto.PullRequestShort, 0, len(prs)),
+		UserID:           req.UserID,
+		PullRequests:     make([]dto.PullRequestShort, 0, len(prs)),
+		Total:            total,
+		UpcomingAbsences: make([]dto.Absence, 0, len(absences)),
+	}
+
+	for _, a := range absences {
+		resp.UpcomingAbsences = append(resp.UpcomingAbsences, AbsenceToDTO(a))
 	}
 
 	for _, p := range prs {
@@ -53,17 +181,146 @@ func (s *UserService) GetReviewPRs(ctx context.Context, userID string) (dto.User
 			status = dto.PullRequestStatus(p.StatusName)
 		}
 
+		createdAt := p.CreatedAt
+		others := p.OtherReviewers
+		if others == nil {
+			others = []string{}
+		}
 		resp.PullRequests = append(resp.PullRequests, dto.PullRequestShort{
 			PullRequestID:   p.ID,
 			PullRequestName: p.Name,
 			AuthorID:        p.AuthorID,
 			Status:          status,
+			CreatedAt:       &createdAt,
+			OtherReviewers:  others,
 		})
 	}
 
+	// страница заполнена целиком - дальше могут быть еще PR
+	if req.Limit > 0 && len(prs) == req.Limit {
+		last := prs[len(prs)-1]
+		resp.NextCursor = encodeReviewCursor(last.CreatedAt, last.ID)
+	}
+
 	return resp, nil
 }
 
+// курсор - base64 от "created_at|pull_request_id" последней строки страницы
+func encodeReviewCursor(at time.Time, prID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(at.UTC().Format(time.RFC3339Nano) + "|" + prID))
+}
+
+func decodeReviewCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", ErrBadCursor
+	}
+	ts, prID, ok := strings.Cut(string(raw), "|")
+	if !ok || prID == "" {
+		return time.Time{}, "", ErrBadCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return time.Time{}, "", ErrBadCursor
+	}
+	return at, prID, nil
+}
+
+// логика для /users/delete - мягкое удаление. Сначала выключаем пользователя, чтобы его больше не назначали,
+// потом переназначаем его открытые ревью (если заменить некем - просто снимаем) и только после этого скрываем
+func (s *UserService) Delete(ctx context.Context, req dto.DeleteUserRequest) (dto.DeleteUserResponse, error) {
+	u, err := s.Users.SetIsActive(ctx, req.UserID, false)
+	if err != nil {
+		return dto.DeleteUserResponse{}, err
+	}
+
+	resp := dto.DeleteUserResponse{
+		UserID:         u.ID,
+		ReassignedPrs:  []string{},
+		RemovedFromPrs: []string{},
+	}
+
+	prs, err := s.Users.GetReviewPrs(ctx, u.ID, false)
+	if err != nil {
+		return dto.DeleteUserResponse{}, err
+	}
+
+	for _, pr := range prs {
+		if pr.StatusName != string(dto.PrStatusOpen) {
+			continue
+		}
+
+		_, err := s.PRs.Reassign(ctx, dto.ReassignPrRequest{PullRequestID: pr.ID, OldUserID: u.ID})
+		if errors.Is(err, repository.ErrNoCandidate) {
+			_, err = s.PRs.RemoveReviewer(ctx, dto.RemoveReviewerRequest{PullRequestID: pr.ID, UserID: u.ID})
+			if err == nil {
+				resp.RemovedFromPrs = append(resp.RemovedFromPrs, pr.ID)
+				continue
+			}
+		}
+
+		switch {
+		case err == nil:
+			resp.ReassignedPrs = append(resp.ReassignedPrs, pr.ID)
+		// PR успели смержить или ревьювера сняли параллельно
+		case errors.Is(err, repository.ErrPRMerged), errors.Is(err, repository.ErrReviewerNotSet):
+		default:
+			return dto.DeleteUserResponse{}, err
+		}
+	}
+
+	now := time.Now().UTC()
+	if err := s.Users.SoftDelete(ctx, u.ID, now); err != nil {
+		return dto.DeleteUserResponse{}, err
+	}
+	resp.DeletedAt = now
+
+	if s.Events != nil {
+		s.Events.Publish(dto.Event{
+			Type:     dto.EventUserDeleted,
+			TeamName: u.TeamName,
+			UserID:   u.ID,
+		})
+	}
+
+	return resp, nil
+}
+
+// логика для /users/restore - возвращаем удаленного пользователя выключенным:
+// назначать его ревьювером снова начнут только после /users/setIsActive
+func (s *UserService) Restore(ctx context.Context, req dto.RestoreUserRequest) (dto.User, error) {
+	u, err := s.Users.Restore(ctx, req.UserID)
+	if err != nil {
+		return dto.User{}, err
+	}
+
+	return UserToDTO(u), nil
+}
+
+// логика для /users/addAbsence
+func (s *UserService) AddAbsence(ctx context.Context, req dto.AddAbsenceRequest) (dto.Absence, error) {
+	if _, err := s.Users.GetByID(ctx, req.UserID); err != nil {
+		return dto.Absence{}, err
+	}
+
+	a, err := s.Absences.Add(ctx, repository.Absence{
+		UserID:   req.UserID,
+		StartsAt: req.StartsAt.UTC(),
+		EndsAt:   req.EndsAt.UTC(),
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		return dto.Absence{}, err
+	}
+
+	return AbsenceToDTO(a), nil
+}
+
+// логика для /users/removeAbsence
+func (s *UserService) RemoveAbsence(ctx context.Context, req dto.RemoveAbsenceRequest) error {
+	return s.Absences.Delete(ctx, req.AbsenceID)
+}
+
 // логика для /stats/reviewers
 func (s *UserService) GetReviewerStats(ctx context.Context) (dto.ReviewerStatsResponse, error) {
 	rows, err := s.Users.GetReviewerStats(ctx)
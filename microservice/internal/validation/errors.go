@@ -0,0 +1,49 @@
+package validation
+
+import "strings"
+
+// ошибка в конкретной строке импортируемого файла
+type LineError struct {
+	Line int
+	Msg  string
+}
+
+// LineErrors собирает все ошибки файла разом, чтобы не чинить их по одной
+type LineErrors []LineError
+
+func (e LineErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, le := range e {
+		parts = append(parts, "line "+itoa(le.Line)+": "+le.Msg)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ошибка конкретного поля запроса, Msg - полный текст вместе с именем поля
+type FieldError struct {
+	Field string
+	Msg   string
+}
+
+// FieldErrors собирает ошибки всех полей запроса, а не только первого
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fe.Msg)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *FieldErrors) add(field, msg string) {
+	*e = append(*e, FieldError{Field: field, Msg: msg})
+}
+
+// err - nil, если ошибок нет: пустой FieldErrors в интерфейсе error уже не nil
+func (e FieldErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
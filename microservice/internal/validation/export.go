@@ -0,0 +1,16 @@
+package validation
+
+import (
+	"review-manager/internal/dto"
+)
+
+/* /export/pullRequests */
+func ValidateExportPrFilter(f dto.ExportPrFilter) error {
+	var errs FieldErrors
+	switch dto.PullRequestStatus(f.Status) {
+	case "", dto.PrStatusOpen, dto.PrStatusMerged:
+	default:
+		errs.add("status", "status must be OPEN or MERGED")
+	}
+	return errs.err()
+}
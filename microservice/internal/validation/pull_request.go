@@ -1,7 +1,6 @@
 package validation
 
 import (
-	"errors"
 	"strings"
 
 	"review-manager/internal/dto"
@@ -9,33 +8,57 @@ import (
 
 /* /pullRequest/create */
 func ValidateCreatePR(req dto.CreatePrRequest) error {
+	var errs FieldErrors
 	if strings.TrimSpace(req.PullRequestID) == "" {
-		return errors.New("pull_request_id is required")
+		errs.add("pull_request_id", "pull_request_id is required")
 	}
 	if strings.TrimSpace(req.PullRequestName) == "" {
-		return errors.New("pull_request_name is required")
+		errs.add("pull_request_name", "pull_request_name is required")
 	}
 	if strings.TrimSpace(req.AuthorID) == "" {
-		return errors.New("author_id is required")
+		errs.add("author_id", "author_id is required")
 	}
-	return nil
+	for i, f := range req.ChangedFiles {
+		if strings.TrimSpace(f) == "" {
+			field := "changed_files[" + itoa(i) + "]"
+			errs.add(field, field+" must not be empty")
+		}
+	}
+	return errs.err()
 }
 
 /* /pullRequest/merge */
 func ValidateMergePR(req dto.MergePrRequest) error {
+	var errs FieldErrors
 	if strings.TrimSpace(req.PullRequestID) == "" {
-		return errors.New("pull_request_id is required")
+		errs.add("pull_request_id", "pull_request_id is required")
 	}
-	return nil
+	return errs.err()
 }
 
 /* /pullRequest/reassign */
 func ValidateReassignPR(req dto.ReassignPrRequest) error {
+	var errs FieldErrors
 	if strings.TrimSpace(req.PullRequestID) == "" {
-		return errors.New("pull_request_id is required")
+		errs.add("pull_request_id", "pull_request_id is required")
 	}
 	if strings.TrimSpace(req.OldUserID) == "" {
-		return errors.New("old_user_id is required")
+		errs.add("old_user_id", "old_user_id is required")
+	}
+	if req.NewUserID != "" && req.NewUserID == req.OldUserID {
+		errs.add("new_user_id", "new_user_id must differ from old_user_id")
+	}
+	return errs.err()
+}
+
+/* /pullRequest/addReviewer, /pullRequest/removeReviewer */
+func ValidateReviewerChange(req dto.ReviewerChangeRequest) error {
+	var errs FieldErrors
+	if strings.TrimSpace(req.PullRequestID) == "" {
+		errs.add("pull_request_id", "pull_request_id is required")
+	}
+	if strings.TrimSpace(req.UserID) == "" {
+		errs.add("user_id", "user_id is required")
 	}
-	return nil
+	return errs.err()
 }
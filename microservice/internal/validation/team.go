@@ -2,28 +2,169 @@ package validation
 
 import (
 	"errors"
+	"net/url"
 	"strings"
 
 	"review-manager/internal/dto"
 )
 
 func ValidateTeamAdd(req dto.TeamAddRequest) error {
+	var errs FieldErrors
 	if strings.TrimSpace(req.TeamName) == "" {
-		return errors.New("team_name is required")
+		errs.add("team_name", "team_name is required")
 	}
 
 	if len(req.Members) == 0 {
-		return errors.New("team must have at least one member")
+		errs.add("members", "team must have at least one member")
+	}
+
+	if req.DefaultMaxOpenReviews != nil && *req.DefaultMaxOpenReviews < 0 {
+		errs.add("default_max_open_reviews", "default_max_open_reviews must be >= 0")
+	}
+
+	validateReviewSla(&errs, req.ReviewSlaHours, req.EscalationHours)
+
+	if req.ParentTeam != "" && req.ParentTeam == req.TeamName {
+		errs.add("parent_team", "team cannot be its own parent_team")
+	}
+	if len(req.Ancestors) > 0 {
+		errs.add("ancestors", "ancestors and child_teams are read-only, use parent_team")
+	}
+	if len(req.ChildTeams) > 0 {
+		errs.add("child_teams", "ancestors and child_teams are read-only, use parent_team")
 	}
 
 	for i, m := range req.Members {
+		prefix := "members[" + itoa(i) + "]"
 		if strings.TrimSpace(m.UserID) == "" {
-			return errors.New("members[" + itoa(i) + "].user_id is required")
+			errs.add(prefix+".user_id", prefix+".user_id is required")
 		}
 		if strings.TrimSpace(m.Username) == "" {
-			return errors.New("members[" + itoa(i) + "].username is required")
+			errs.add(prefix+".username", prefix+".username is required")
+		}
+		if m.MaxOpenReviews != nil && *m.MaxOpenReviews < 0 {
+			errs.add(prefix+".max_open_reviews", prefix+".max_open_reviews must be >= 0")
+		}
+	}
+
+	return errs.err()
+}
+
+/* /team/import */
+func ValidateRoster(entries []dto.RosterEntry) error {
+	if len(entries) == 0 {
+		return errors.New("roster is empty")
+	}
+
+	var errs LineErrors
+	seen := make(map[string]int, len(entries))
+
+	for _, e := range entries {
+		if strings.TrimSpace(e.TeamName) == "" {
+			errs = append(errs, LineError{Line: e.Line, Msg: "team is required"})
+		}
+		if strings.TrimSpace(e.UserID) == "" {
+			errs = append(errs, LineError{Line: e.Line, Msg: "user_id is required"})
+			continue
 		}
+		if strings.TrimSpace(e.Username) == "" {
+			errs = append(errs, LineError{Line: e.Line, Msg: "username is required"})
+		}
+		if first, ok := seen[e.UserID]; ok {
+			errs = append(errs, LineError{
+				Line: e.Line,
+				Msg:  "user_id " + e.UserID + " already listed on line " + itoa(first),
+			})
+			continue
+		}
+		seen[e.UserID] = e.Line
 	}
 
+	if len(errs) > 0 {
+		return errs
+	}
 	return nil
 }
+
+/* /team/setDefaultCapacity */
+func ValidateSetTeamCapacity(req dto.SetTeamCapacityRequest) error {
+	var errs FieldErrors
+	if strings.TrimSpace(req.TeamName) == "" {
+		errs.add("team_name", "team_name is required")
+	}
+	if req.DefaultMaxOpenReviews != nil && *req.DefaultMaxOpenReviews < 0 {
+		errs.add("default_max_open_reviews", "default_max_open_reviews must be >= 0")
+	}
+	return errs.err()
+}
+
+/* /team/setReviewSla */
+func ValidateSetTeamReviewSla(req dto.SetTeamReviewSlaRequest) error {
+	var errs FieldErrors
+	if strings.TrimSpace(req.TeamName) == "" {
+		errs.add("team_name", "team_name is required")
+	}
+	validateReviewSla(&errs, req.ReviewSlaHours, req.EscalationHours)
+	return errs.err()
+}
+
+// проверки SLA общие для /team/add и /team/setReviewSla
+func validateReviewSla(errs *FieldErrors, slaHours, escalationHours *int) {
+	if slaHours != nil && *slaHours <= 0 {
+		errs.add("review_sla_hours", "review_sla_hours must be > 0")
+	}
+	if escalationHours != nil && *escalationHours <= 0 {
+		errs.add("escalation_hours", "escalation_hours must be > 0")
+	}
+	if slaHours != nil && escalationHours != nil && *slaHours > 0 && *escalationHours <= *slaHours {
+		errs.add("escalation_hours", "escalation_hours must be greater than review_sla_hours")
+	}
+}
+
+/* /team/setLead */
+func ValidateSetTeamLead(req dto.SetTeamLeadRequest) error {
+	return ValidateTeamName(req.TeamName)
+}
+
+/* /team/setParent */
+func ValidateSetTeamParent(req dto.SetTeamParentRequest) error {
+	var errs FieldErrors
+	if strings.TrimSpace(req.TeamName) == "" {
+		errs.add("team_name", "team_name is required")
+	} else if req.ParentTeam == req.TeamName {
+		errs.add("parent_team", "team cannot be its own parent_team")
+	}
+	return errs.err()
+}
+
+/* /team/setWebhook */
+func ValidateSetTeamWebhook(req dto.SetTeamWebhookRequest) error {
+	var errs FieldErrors
+	if strings.TrimSpace(req.TeamName) == "" {
+		errs.add("team_name", "team_name is required")
+	}
+	if req.URL == "" {
+		return errs.err()
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs.add("url", "url must be an absolute http(s) URL")
+	}
+
+	switch req.Kind {
+	case "", dto.WebhookKindSlack, dto.WebhookKindMattermost:
+	default:
+		errs.add("kind", "kind must be slack or mattermost")
+	}
+	return errs.err()
+}
+
+// ValidateTeamName - для запросов и query-параметров, где из полей только team_name
+func ValidateTeamName(teamName string) error {
+	var errs FieldErrors
+	if strings.TrimSpace(teamName) == "" {
+		errs.add("team_name", "team_name is required")
+	}
+	return errs.err()
+}
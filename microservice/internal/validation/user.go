@@ -1,22 +1,94 @@
 package validation
 
 import (
-	"errors"
+	"strconv"
 	"strings"
 
 	"review-manager/internal/dto"
 )
 
 func ValidateUserSetActive(req dto.SetUserIsActiveRequest) error {
+	return ValidateUserID(req.UserID)
+}
+
+/* /users/getReview */
+func ValidateUserGetReview(req dto.UserGetReviewRequest) error {
+	var errs FieldErrors
 	if strings.TrimSpace(req.UserID) == "" {
-		return errors.New("user_id is required")
+		errs.add("user_id", "user_id is required")
+	}
+	for _, st := range req.Statuses {
+		if st != dto.PrStatusOpen && st != dto.PrStatusMerged {
+			errs.add("status", "status must be OPEN or MERGED")
+			break
+		}
+	}
+	// 0 - limit не передан, тогда отдаем все PR
+	if req.Limit < 0 || req.Limit > dto.MaxReviewPageSize {
+		errs.add("limit", pageLimitMsg)
 	}
-	return nil
+	return errs.err()
+}
+
+var pageLimitMsg = "limit must be an integer from 1 to " + itoa(dto.MaxReviewPageSize)
+
+// ParsePageLimit разбирает limit из query: пустая строка - 0 (без ограничения),
+// иначе целое от 1 до dto.MaxReviewPageSize
+func ParsePageLimit(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 || limit > dto.MaxReviewPageSize {
+		return 0, FieldErrors{{Field: "limit", Msg: pageLimitMsg}}
+	}
+	return limit, nil
 }
 
 func ValidateUserID(userID string) error {
+	var errs FieldErrors
 	if strings.TrimSpace(userID) == "" {
-		return errors.New("user_id is required")
+		errs.add("user_id", "user_id is required")
+	}
+	return errs.err()
+}
+
+/* /users/addAbsence */
+func ValidateAddAbsence(req dto.AddAbsenceRequest) error {
+	var errs FieldErrors
+	if strings.TrimSpace(req.UserID) == "" {
+		errs.add("user_id", "user_id is required")
+	}
+	if req.StartsAt.IsZero() {
+		errs.add("starts_at", "starts_at is required")
+	}
+	if req.EndsAt.IsZero() {
+		errs.add("ends_at", "ends_at is required")
+	}
+	if !req.StartsAt.IsZero() && !req.EndsAt.IsZero() && !req.EndsAt.After(req.StartsAt) {
+		errs.add("ends_at", "ends_at must be after starts_at")
+	}
+	return errs.err()
+}
+
+/* /users/removeAbsence */
+func ValidateRemoveAbsence(req dto.RemoveAbsenceRequest) error {
+	var errs FieldErrors
+	if req.AbsenceID <= 0 {
+		errs.add("absence_id", "absence_id is required")
+	}
+	return errs.err()
+}
+
+/* /users/setCapacity */
+func ValidateSetUserCapacity(req dto.SetUserCapacityRequest) error {
+	var errs FieldErrors
+	if strings.TrimSpace(req.UserID) == "" {
+		errs.add("user_id", "user_id is required")
+	}
+	if req.MaxOpenReviews != nil && *req.MaxOpenReviews < 0 {
+		errs.add("max_open_reviews", "max_open_reviews must be >= 0")
 	}
-	return nil
+	return errs.err()
 }
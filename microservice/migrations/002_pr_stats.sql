@@ -0,0 +1,13 @@
+-- Счетчики для /stats/prs:
+-- сколько раз на PR переназначали ревьюверов и сколько ревьюверов было при создании
+ALTER TABLE pull_requests
+    ADD COLUMN reassign_count          INT NOT NULL DEFAULT 0,
+    ADD COLUMN initial_reviewers_count INT NOT NULL DEFAULT 0;
+
+UPDATE pull_requests pr
+SET initial_reviewers_count = (
+    SELECT COUNT(*) FROM pull_request_reviewers r WHERE r.pull_request_id = pr.pull_request_id
+);
+
+-- Индекс под статистику по статусам:
+CREATE INDEX idx_pull_requests_status ON pull_requests (status_id);
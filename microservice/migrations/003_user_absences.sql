@@ -0,0 +1,17 @@
+-- Периоды недоступности (отпуск, out-of-office)
+CREATE TABLE user_absences (
+    id         SERIAL PRIMARY KEY,
+    user_id    TEXT NOT NULL REFERENCES users (user_id) ON DELETE CASCADE,
+    starts_at  TIMESTAMPTZ NOT NULL,
+    ends_at    TIMESTAMPTZ NOT NULL,
+    reason     TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- когда фоновая задача переназначила открытые ревью пользователя
+    reviews_reassigned_at TIMESTAMPTZ,
+
+    CHECK (ends_at > starts_at)
+);
+
+-- Индекс под проверку "отсутствует ли пользователь сейчас" и список ближайших отсутствий:
+CREATE INDEX idx_user_absences_user_period ON user_absences (user_id, ends_at, starts_at);
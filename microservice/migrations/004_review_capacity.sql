@@ -0,0 +1,6 @@
+-- Лимиты одновременно открытых ревью: у пользователя и по умолчанию для команды, NULL - без лимита
+ALTER TABLE users
+    ADD COLUMN max_open_reviews INT CHECK (max_open_reviews >= 0);
+
+ALTER TABLE teams
+    ADD COLUMN default_max_open_reviews INT CHECK (default_max_open_reviews >= 0);
@@ -0,0 +1,8 @@
+-- Правила владения кодом в формате CODEOWNERS, line - номер строки в загруженном файле
+CREATE TABLE codeowners_rules (
+    team_id INT NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+    line    INT NOT NULL,
+    pattern TEXT NOT NULL,
+    owners  TEXT[] NOT NULL,
+    PRIMARY KEY (team_id, line)
+);
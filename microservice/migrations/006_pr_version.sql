@@ -0,0 +1,4 @@
+-- Версия PR для оптимистичной блокировки (ETag / If-Match):
+-- увеличивается при каждом изменении ревьюверов или статуса
+ALTER TABLE pull_requests
+    ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,13 @@
+-- Ключи Idempotency-Key: хэш запроса и сохраненный ответ для повтора.
+-- status_code = NULL, пока первый запрос с этим ключом еще выполняется
+CREATE TABLE idempotency_keys (
+    idem_key      TEXT PRIMARY KEY,
+    request_hash  TEXT        NOT NULL,
+    status_code   INT,
+    headers       JSONB       NOT NULL DEFAULT '{}',
+    response_body BYTEA,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at    TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires ON idempotency_keys (expires_at);
@@ -0,0 +1,7 @@
+-- Incoming-webhook чата для уведомлений команды: slack (block-kit) или mattermost
+CREATE TABLE team_webhooks (
+    team_id    INT PRIMARY KEY REFERENCES teams (id) ON DELETE CASCADE,
+    url        TEXT        NOT NULL,
+    kind       TEXT        NOT NULL DEFAULT 'slack' CHECK (kind IN ('slack', 'mattermost')),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1,13 @@
+-- SLA на ревью: когда ревьювера назначили, когда напомнили и когда эскалировали
+ALTER TABLE pull_request_reviewers
+    ADD COLUMN assigned_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN reminded_at  TIMESTAMPTZ,
+    ADD COLUMN escalated_at TIMESTAMPTZ;
+
+-- Индекс под поиск зависших назначений:
+CREATE INDEX idx_pr_reviewers_assigned_at ON pull_request_reviewers (assigned_at);
+
+-- Пороги команды в часах, NULL - берутся значения по умолчанию сервиса
+ALTER TABLE teams
+    ADD COLUMN review_sla_hours INT CHECK (review_sla_hours > 0),
+    ADD COLUMN escalation_hours INT CHECK (escalation_hours > 0);
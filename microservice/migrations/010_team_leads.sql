@@ -0,0 +1,10 @@
+-- Лид команды (получает эскалации, последний кандидат в ревьюверы) и родительская команда для оргструктуры.
+-- Токен лида хранится только в виде sha256
+ALTER TABLE teams
+    ADD COLUMN lead_user_id    TEXT REFERENCES users (user_id) ON DELETE SET NULL,
+    ADD COLUMN lead_token_hash TEXT,
+    ADD COLUMN parent_team_id  INT REFERENCES teams (id) ON DELETE SET NULL,
+    ADD CONSTRAINT teams_parent_not_self CHECK (parent_team_id <> id);
+
+-- Индекс под список дочерних команд:
+CREATE INDEX idx_teams_parent ON teams (parent_team_id);
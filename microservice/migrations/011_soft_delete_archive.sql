@@ -0,0 +1,32 @@
+-- Мягкое удаление пользователей: запись остается ради истории PR,
+-- но пропадает из команд, выбора ревьюверов и статистики
+ALTER TABLE users
+    ADD COLUMN deleted_at TIMESTAMPTZ;
+
+-- Архив смерженных PR. Внешних ключей на users нет: архив живет отдельно от текущих данных
+CREATE TABLE pull_requests_archive (
+    pull_request_id         TEXT PRIMARY KEY,
+    pull_request_name       TEXT        NOT NULL,
+    author_id               TEXT        NOT NULL,
+    status_id               INT         NOT NULL,
+    created_at              TIMESTAMPTZ NOT NULL,
+    merged_at               TIMESTAMPTZ,
+    need_more_reviewers     BOOLEAN     NOT NULL,
+    reassign_count          INT         NOT NULL,
+    initial_reviewers_count INT         NOT NULL,
+    version                 BIGINT      NOT NULL,
+    archived_at             TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE pull_request_reviewers_archive (
+    pull_request_id TEXT        NOT NULL REFERENCES pull_requests_archive (pull_request_id) ON DELETE CASCADE,
+    reviewer_id     TEXT        NOT NULL,
+    assigned_at     TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (pull_request_id, reviewer_id)
+);
+
+-- Индекс под /users/getReview с архивом:
+CREATE INDEX idx_pr_reviewers_archive_reviewer_id ON pull_request_reviewers_archive (reviewer_id);
+
+-- Индекс под выборку смерженных PR для архивации:
+CREATE INDEX idx_pull_requests_merged_at ON pull_requests (merged_at) WHERE merged_at IS NOT NULL;
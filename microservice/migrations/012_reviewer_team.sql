@@ -0,0 +1,17 @@
+-- Команда ревьювера на момент назначения: по ней автор видит, что ревьювер с тех пор перешел в другую команду
+ALTER TABLE pull_request_reviewers
+    ADD COLUMN team_id INT REFERENCES teams (id) ON DELETE SET NULL;
+
+UPDATE pull_request_reviewers r
+SET team_id = u.team_id
+FROM users u
+WHERE u.user_id = r.reviewer_id;
+
+-- В архиве команда ревьювера тоже сохраняется; внешнего ключа нет, как и у остального архива
+ALTER TABLE pull_request_reviewers_archive
+    ADD COLUMN team_id INT;
+
+UPDATE pull_request_reviewers_archive r
+SET team_id = u.team_id
+FROM users u
+WHERE u.user_id = r.reviewer_id;
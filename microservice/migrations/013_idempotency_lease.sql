@@ -0,0 +1,4 @@
+-- Аренда ключа: Complete и Release меняют запись, только пока ключ держит тот же запрос.
+-- Иначе запрос с истекшей арендой затер бы ответ запроса, который перезанял ключ
+ALTER TABLE idempotency_keys
+    ADD COLUMN lease_id TEXT;
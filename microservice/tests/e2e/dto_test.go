@@ -60,3 +60,11 @@ type reviewerStatItem struct {
 type reviewerStatsResponse struct {
 	Stats []reviewerStatItem `json:"stats"`
 }
+
+type prExportRow struct {
+	PullRequestID     string   `json:"pull_request_id"`
+	AuthorID          string   `json:"author_id"`
+	Status            string   `json:"status"`
+	AssignedReviewers []string `json:"assigned_reviewers"`
+	TeamName          string   `json:"team_name"`
+}
@@ -0,0 +1,59 @@
+package e2e
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// Проверяем, что /export/pullRequests выполняется на настоящей БД с архивом и без,
+// а ревьюверы PR приходят вместе с ним
+func TestE2E_ExportPullRequests(t *testing.T) {
+	teamName := "export-e2e"
+
+	doJSON(
+		t,
+		http.MethodPost,
+		"/team/add",
+		map[string]any{
+			"team_name": teamName,
+			"members": []map[string]any{
+				{"user_id": "ex1", "username": "ExportAuthor", "is_active": true},
+				{"user_id": "ex2", "username": "ExportReviewer1", "is_active": true},
+				{"user_id": "ex3", "username": "ExportReviewer2", "is_active": true},
+			},
+		},
+		http.StatusCreated,
+		nil,
+	)
+
+	var prResp prResponse
+	doJSON(
+		t,
+		http.MethodPost,
+		"/pullRequest/create",
+		map[string]any{
+			"pull_request_id":   "pr-export-1",
+			"pull_request_name": "E2E export",
+			"author_id":         "ex1",
+		},
+		http.StatusCreated,
+		&prResp,
+	)
+
+	for _, archived := range []string{"false", "true"} {
+		rows := getNDJSON[prExportRow](t, "/export/pullRequests?format=ndjson&author_id=ex1&include_archived="+archived, http.StatusOK)
+
+		if len(rows) != 1 || rows[0].PullRequestID != "pr-export-1" {
+			t.Fatalf("include_archived=%s: ожидали одну строку pr-export-1, получили %+v", archived, rows)
+		}
+		if rows[0].TeamName != teamName {
+			t.Fatalf("include_archived=%s: ожидали команду %s, получили %s", archived, teamName, rows[0].TeamName)
+		}
+
+		want := slices.Sorted(slices.Values(prResp.PR.AssignedReviewers))
+		if !slices.Equal(rows[0].AssignedReviewers, want) {
+			t.Fatalf("include_archived=%s: ожидали ревьюверов %v, получили %v", archived, want, rows[0].AssignedReviewers)
+		}
+	}
+}
@@ -64,3 +64,39 @@ func envOrDefault(key, def string) string {
 	}
 	return def
 }
+
+// GET выгрузки в NDJSON: проверяем статус и разбираем каждую строку как T
+func getNDJSON[T any](t *testing.T, path string, expectedStatus int) []T {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodGet, baseURL+path, nil)
+	if err != nil {
+		t.Fatalf("не удалось создать запрос GET %s: %v", path, err)
+	}
+	req.Header.Set("Accept", "application/x-ndjson")
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("ошибка при выполнении запроса GET %s: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		var respBody bytes.Buffer
+		_, _ = respBody.ReadFrom(resp.Body)
+		t.Fatalf("ожидали статус %d, получили %d, тело ответа: %s", expectedStatus, resp.StatusCode, respBody.String())
+	}
+
+	var rows []T
+	dec := json.NewDecoder(resp.Body)
+	for dec.More() {
+		var row T
+		if err := dec.Decode(&row); err != nil {
+			t.Fatalf("не удалось распарсить строку выгрузки: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,87 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"review-manager/internal/repository"
+	"review-manager/internal/service"
+)
+
+// Проверяем, что задача снимает отсутствующего ревьювера с открытых PR и помечает отсутствие обработанным
+func TestAbsenceJob_RunOnce_ReassignsOpenReviews(t *testing.T) {
+	prSvc, prRepo, userRepo := newTestPRService()
+	absenceRepo := NewMockAbsenceRepo()
+	job := service.NewAbsenceJob(absenceRepo, userRepo, NewMockLocker(), prSvc)
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 2, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "OnVacation", TeamID: 1, IsActive: true}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Backup", TeamID: 1, IsActive: true}
+
+	now := time.Now().UTC()
+	prRepo.PRs["pr-open"] = repository.PullRequest{ID: "pr-open", AuthorID: "u1", StatusID: repository.StatusOpen, CreatedAt: now}
+	prRepo.Reviewers["pr-open"] = []string{"u2"}
+	prRepo.PRs["pr-merged"] = repository.PullRequest{ID: "pr-merged", AuthorID: "u1", StatusID: repository.StatusMerged, CreatedAt: now}
+	prRepo.Reviewers["pr-merged"] = []string{"u2"}
+
+	userRepo.ReviewPRs["u2"] = []repository.PullRequestShort{
+		{ID: "pr-open", AuthorID: "u1", StatusName: "OPEN"},
+		{ID: "pr-merged", AuthorID: "u1", StatusName: "MERGED"},
+	}
+
+	a, _ := absenceRepo.Add(context.Background(), repository.Absence{
+		UserID:   "u2",
+		StartsAt: now.Add(-time.Minute),
+		EndsAt:   now.Add(time.Hour),
+	})
+
+	if err := job.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+
+	if got := prRepo.Reviewers["pr-open"]; len(got) != 1 || got[0] != "u3" {
+		t.Fatalf("ожидали, что на открытом PR ревьювером станет u3, получили %v", got)
+	}
+	if got := prRepo.Reviewers["pr-merged"]; len(got) != 1 || got[0] != "u2" {
+		t.Fatalf("смерженный PR не должен меняться, получили %v", got)
+	}
+	if absenceRepo.Absences[a.ID].ReviewsReassignedAt == nil {
+		t.Fatalf("ожидали, что отсутствие будет помечено обработанным")
+	}
+}
+
+// Проверяем, что задача ничего не делает, пока блокировку держит другая реплика
+func TestAbsenceJob_RunOnce_SkipsWhenLocked(t *testing.T) {
+	prSvc, prRepo, userRepo := newTestPRService()
+	absenceRepo := NewMockAbsenceRepo()
+	locker := NewMockLocker()
+	locker.Locked[repository.LockKeyAbsence] = true
+	job := service.NewAbsenceJob(absenceRepo, userRepo, locker, prSvc)
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 2, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "OnVacation", TeamID: 1, IsActive: true}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Backup", TeamID: 1, IsActive: true}
+
+	now := time.Now().UTC()
+	prRepo.PRs["pr-open"] = repository.PullRequest{ID: "pr-open", AuthorID: "u1", StatusID: repository.StatusOpen, CreatedAt: now}
+	prRepo.Reviewers["pr-open"] = []string{"u2"}
+	userRepo.ReviewPRs["u2"] = []repository.PullRequestShort{{ID: "pr-open", AuthorID: "u1", StatusName: "OPEN"}}
+
+	a, _ := absenceRepo.Add(context.Background(), repository.Absence{
+		UserID:   "u2",
+		StartsAt: now.Add(-time.Minute),
+		EndsAt:   now.Add(time.Hour),
+	})
+
+	if err := job.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+
+	if got := prRepo.Reviewers["pr-open"]; len(got) != 1 || got[0] != "u2" {
+		t.Fatalf("при занятой блокировке ревьюверы не должны меняться, получили %v", got)
+	}
+	if absenceRepo.Absences[a.ID].ReviewsReassignedAt != nil {
+		t.Fatalf("при занятой блокировке отсутствие не должно помечаться обработанным")
+	}
+}
@@ -0,0 +1,99 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"review-manager/internal/repository"
+	"review-manager/internal/service"
+)
+
+// размер пачки в ArchiveJob
+const archiveBatch = 500
+
+func newTestArchiveJob() (*service.ArchiveJob, *MockArchiveRepo, *MockLocker) {
+	repo := NewMockArchiveRepo()
+	locker := NewMockLocker()
+	job := service.NewArchiveJob(repo, locker, &MockTxManager{}, 24*time.Hour)
+	return job, repo, locker
+}
+
+// добавляем n PR, смерженных mergedAgo назад
+func addMerged(repo *MockArchiveRepo, prefix string, n int, mergedAgo time.Duration) {
+	mergedAt := time.Now().UTC().Add(-mergedAgo)
+	for i := 0; i < n; i++ {
+		repo.Merged[fmt.Sprintf("%s-%04d", prefix, i)] = mergedAt
+	}
+}
+
+// Проверяем, что задача переносит все старые PR пачками и не трогает свежие
+func TestArchiveJob_RunOnce_ArchivesInBatches(t *testing.T) {
+	cases := []struct {
+		old       int
+		wantCalls int
+	}{
+		{old: 0, wantCalls: 1},
+		{old: 10, wantCalls: 1},
+		{old: archiveBatch, wantCalls: 2},
+		{old: 2*archiveBatch + 1, wantCalls: 3},
+	}
+
+	for _, c := range cases {
+		job, repo, locker := newTestArchiveJob()
+		addMerged(repo, "old", c.old, 48*time.Hour)
+		addMerged(repo, "fresh", 3, time.Hour)
+
+		n, err := job.RunOnce(context.Background())
+		if err != nil {
+			t.Fatalf("old=%d: RunOnce вернул ошибку: %v", c.old, err)
+		}
+		if n != c.old || len(repo.Archived) != c.old {
+			t.Fatalf("old=%d: ожидали перенос %d PR, получили %d (в архиве %d)", c.old, c.old, n, len(repo.Archived))
+		}
+		if repo.Calls != c.wantCalls {
+			t.Fatalf("old=%d: ожидали %d пачек, получили %d", c.old, c.wantCalls, repo.Calls)
+		}
+		if len(repo.Merged) != 3 {
+			t.Fatalf("old=%d: свежие PR не должны архивироваться, осталось %d", c.old, len(repo.Merged))
+		}
+		if len(locker.Locked) != 0 {
+			t.Fatalf("old=%d: блокировка должна быть снята после прогона", c.old)
+		}
+	}
+}
+
+// Проверяем, что при ошибке задача останавливается и возвращает, сколько успела перенести
+func TestArchiveJob_RunOnce_StopsOnError(t *testing.T) {
+	job, repo, locker := newTestArchiveJob()
+	addMerged(repo, "old", 3*archiveBatch, 48*time.Hour)
+	repo.FailOnCall = 2
+
+	n, err := job.RunOnce(context.Background())
+	if !errors.Is(err, errMockArchive) {
+		t.Fatalf("ожидали ошибку архивации, получили %v", err)
+	}
+	if n != archiveBatch || repo.Calls != 2 {
+		t.Fatalf("ожидали одну перенесенную пачку и остановку на второй, получили %d PR за %d вызовов", n, repo.Calls)
+	}
+	if len(locker.Locked) != 0 {
+		t.Fatalf("блокировка должна быть снята и после ошибки")
+	}
+}
+
+// Проверяем, что задача ничего не делает, пока блокировку держит другая реплика
+func TestArchiveJob_RunOnce_SkipsWhenLocked(t *testing.T) {
+	job, repo, locker := newTestArchiveJob()
+	addMerged(repo, "old", 10, 48*time.Hour)
+	locker.Locked[repository.LockKeyArchive] = true
+
+	n, err := job.RunOnce(context.Background())
+	if err != nil || n != 0 {
+		t.Fatalf("ожидали пропуск без ошибки, получили %d, %v", n, err)
+	}
+	if repo.Calls != 0 || len(repo.Merged) != 10 {
+		t.Fatalf("при занятой блокировке архив трогать нельзя, вызовов %d", repo.Calls)
+	}
+}
@@ -0,0 +1,119 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"review-manager/internal/events"
+	"review-manager/internal/httpapi"
+	"review-manager/internal/repository"
+	"review-manager/internal/service"
+)
+
+// Проверяем, что статистика берется из кэша, а запись через декоратор и WithPrimary дают свежие данные
+func TestCachedUserRepo_GetReviewerStats_InvalidatesOnWrite(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockUserRepo()
+	mock.Users["u1"] = repository.User{ID: "u1", Username: "Petya", TeamID: 1, IsActive: true}
+	mock.StatsRows = []repository.ReviewerStatRow{{UserID: "u1", Username: "Petya", ReviewsCount: 1}}
+
+	cache := repository.NewReadCache(time.Minute)
+	users := repository.NewCachedUserRepo(mock, cache)
+	prs := repository.NewCachedPrRepo(NewMockPrRepo(), cache)
+
+	if _, err := users.GetReviewerStats(ctx); err != nil {
+		t.Fatalf("GetReviewerStats вернул ошибку: %v", err)
+	}
+	mock.StatsRows = []repository.ReviewerStatRow{{UserID: "u1", Username: "Petya", ReviewsCount: 2}}
+
+	rows, _ := users.GetReviewerStats(ctx)
+	if rows[0].ReviewsCount != 1 {
+		t.Fatalf("второе чтение должно прийти из кэша, получили %+v", rows)
+	}
+	if rows, _ := users.GetReviewerStats(repository.WithPrimary(ctx)); rows[0].ReviewsCount != 2 {
+		t.Fatalf("WithPrimary должен читать мимо кэша, получили %+v", rows)
+	}
+
+	if _, err := users.SetIsActive(ctx, "u1", false); err != nil {
+		t.Fatalf("SetIsActive вернул ошибку: %v", err)
+	}
+	if rows, _ := users.GetReviewerStats(ctx); rows[0].ReviewsCount != 2 {
+		t.Fatalf("после SetIsActive кэш должен сброситься, получили %+v", rows)
+	}
+
+	mock.StatsRows = []repository.ReviewerStatRow{{UserID: "u1", Username: "Petya", ReviewsCount: 3}}
+	if err := prs.Insert(ctx, repository.PullRequest{ID: "pr-1", AuthorID: "u1", StatusID: repository.StatusOpen}); err != nil {
+		t.Fatalf("Insert вернул ошибку: %v", err)
+	}
+	if rows, _ := users.GetReviewerStats(ctx); rows[0].ReviewsCount != 3 {
+		t.Fatalf("после создания PR кэш должен сброситься, получили %+v", rows)
+	}
+}
+
+// Проверяем, что прогон архивации с перенесенными PR сбрасывает кэш статистики, а пустой - нет
+func TestCachedArchiveRepo_InvalidatesAfterArchive(t *testing.T) {
+	ctx := context.Background()
+	mock := NewMockUserRepo()
+	mock.StatsRows = []repository.ReviewerStatRow{{UserID: "u1", Username: "Petya", ReviewsCount: 1}}
+
+	cache := repository.NewReadCache(time.Minute)
+	users := repository.NewCachedUserRepo(mock, cache)
+	archive := NewMockArchiveRepo()
+	job := service.NewArchiveJob(repository.NewCachedArchiveRepo(archive, cache), NewMockLocker(), &MockTxManager{}, 24*time.Hour)
+
+	if _, err := users.GetReviewerStats(ctx); err != nil {
+		t.Fatalf("GetReviewerStats вернул ошибку: %v", err)
+	}
+	mock.StatsRows = []repository.ReviewerStatRow{{UserID: "u1", Username: "Petya", ReviewsCount: 2}}
+
+	if _, err := job.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+	if rows, _ := users.GetReviewerStats(ctx); rows[0].ReviewsCount != 1 {
+		t.Fatalf("пустой прогон не должен сбрасывать кэш, получили %+v", rows)
+	}
+
+	archive.Merged["pr-1"] = time.Now().Add(-48 * time.Hour)
+	if _, err := job.RunOnce(ctx); err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+	if rows, _ := users.GetReviewerStats(ctx); rows[0].ReviewsCount != 2 {
+		t.Fatalf("после архивации кэш должен сброситься, получили %+v", rows)
+	}
+}
+
+// Проверяем ETag у /stats/reviewers: с тем же If-None-Match приходит 304 без тела
+func TestStatsReviewers_IfNoneMatch(t *testing.T) {
+	userRepo := NewMockUserRepo()
+	userRepo.StatsRows = []repository.ReviewerStatRow{{UserID: "u1", Username: "Petya", ReviewsCount: 1}}
+	userSvc := service.NewUserService(userRepo, NewMockAbsenceRepo(), nil, events.NewBroker(10))
+	mux := httpapi.NewMux(httpapi.NewHandler(nil, userSvc, nil, nil, nil, nil, nil, nil, "secret"))
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/stats/reviewers", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("ожидали 200 с ETag, получили %d %q", first.Code, etag)
+	}
+
+	if rec := get(`"other", ` + etag); rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Fatalf("ожидали 304 без тела, получили %d %q", rec.Code, rec.Body.String())
+	}
+
+	userRepo.StatsRows[0].ReviewsCount = 2
+	if rec := get(etag); rec.Code != http.StatusOK || rec.Header().Get("ETag") == etag {
+		t.Fatalf("после изменения данных ожидали 200 с новым ETag, получили %d", rec.Code)
+	}
+}
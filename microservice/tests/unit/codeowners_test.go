@@ -0,0 +1,66 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"review-manager/internal/codeowners"
+	"review-manager/internal/validation"
+)
+
+// Проверяем основные случаи сопоставления шаблонов CODEOWNERS
+func TestCodeowners_Match(t *testing.T) {
+	cases := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"*.go", "internal/service/pr_service.go", true},
+		{"*.go", "README.md", false},
+		{"/migrations/", "migrations/001_init.sql", true},
+		{"/migrations/", "internal/migrations/x.sql", false},
+		{"docs/", "docs/a/b.md", true},
+		{"internal/**/repo.go", "internal/a/b/repo.go", true},
+		{"internal/**/repo.go", "internal/repo.go", true},
+		{"/cmd/main.go", "cmd/main.go", true},
+		{"internal/httpapi", "internal/httpapi/router.go", true},
+	}
+
+	for _, c := range cases {
+		if got := codeowners.Match(c.pattern, c.file); got != c.want {
+			t.Errorf("Match(%q, %q) = %v, ожидали %v", c.pattern, c.file, got, c.want)
+		}
+	}
+}
+
+// Проверяем, что выигрывает последнее подходящее правило
+func TestCodeowners_MatchFile_LastRuleWins(t *testing.T) {
+	rules, err := codeowners.Parse(strings.NewReader("# общие\n* @u1\n\n*.sql @team:dba # базы\n"))
+	if err != nil {
+		t.Fatalf("Parse вернул ошибку: %v", err)
+	}
+
+	rule, ok := codeowners.MatchFile(rules, "migrations/001.sql")
+	if !ok || rule.Line != 4 {
+		t.Fatalf("ожидали правило со строки 4, получили %+v", rule)
+	}
+
+	users, teams := rule.Split()
+	if len(users) != 0 || len(teams) != 1 || teams[0] != "dba" {
+		t.Fatalf("владельцы разобраны некорректно: users=%v teams=%v", users, teams)
+	}
+}
+
+// Проверяем, что ошибки файла правил собираются с номерами строк
+func TestCodeowners_Parse_LineErrors(t *testing.T) {
+	_, err := codeowners.Parse(strings.NewReader("*.go\n/api/ u1\n"))
+
+	var lineErrs validation.LineErrors
+	if !errors.As(err, &lineErrs) || len(lineErrs) != 2 {
+		t.Fatalf("ожидали 2 ошибки, получили %v", err)
+	}
+	if lineErrs[0].Line != 1 || lineErrs[1].Line != 2 {
+		t.Fatalf("ожидали ошибки в строках 1 и 2, получили %v", err)
+	}
+}
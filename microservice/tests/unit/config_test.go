@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"review-manager/internal/config"
+)
+
+func writeConfigFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("не получилось записать файл: %v", err)
+	}
+	return path
+}
+
+// Проверяем порядок источников: умолчания, затем файл, затем окружение
+func TestConfig_Load_FileAndEnvOverrides(t *testing.T) {
+	path := writeConfigFile(t, `
+dsn: postgres://file
+port: "8080"
+http:
+  read_timeout: 7s
+auth:
+  admin_token: from-file
+reviews:
+  stale_sla: 24h
+`)
+	t.Setenv("ADMIN_TOKEN", "from-env")
+	t.Setenv("DB_MAX_CONNS", "20")
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load вернул ошибку: %v", err)
+	}
+	if cfg.DSN != "postgres://file" || cfg.HTTP.ReadTimeout != 7*time.Second || cfg.Reviews.StaleSLA != 24*time.Hour {
+		t.Fatalf("значения из файла не применились: %+v", cfg)
+	}
+	if cfg.Auth.AdminToken != "from-env" || cfg.DB.MaxConns != 20 {
+		t.Fatalf("окружение должно перекрывать файл: %+v", cfg)
+	}
+	if cfg.HTTP.WriteTimeout != 5*time.Second || cfg.Reviews.StaleEscalateAfter != 96*time.Hour {
+		t.Fatalf("незаданные поля должны остаться по умолчанию: %+v", cfg)
+	}
+}
+
+// Проверяем, что неизвестный ключ и все ошибки проверки отдаются при старте
+func TestConfig_Load_ReportsAllErrors(t *testing.T) {
+	if _, err := config.Load(writeConfigFile(t, "prot: 8080\n")); err == nil || !strings.Contains(err.Error(), "prot") {
+		t.Fatalf("ожидали ошибку про неизвестный ключ, получили %v", err)
+	}
+
+	path := writeConfigFile(t, `
+log_level: loud
+reviews:
+  stale_sla: 10h
+  stale_escalate_after: 5h
+`)
+	t.Setenv("HTTP_IDLE_TIMEOUT", "soon")
+	// обязательные поля не должны подтянуться из окружения, в котором идут тесты
+	t.Setenv("DSN", "")
+	t.Setenv("ADMIN_TOKEN", "")
+
+	_, err := config.Load(path)
+	if err == nil {
+		t.Fatalf("ожидали ошибку проверки")
+	}
+	for _, want := range []string{"HTTP_IDLE_TIMEOUT", "dsn is required", "admin_token is required", "log_level", "stale_escalate_after"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("в ошибке нет %q: %v", want, err)
+		}
+	}
+}
+
+// Проверяем разделение настроек на применяемые на лету и требующие перезапуска
+func TestConfig_StructuralChanges(t *testing.T) {
+	cur := config.Default()
+	next := cur
+	next.LogLevel = "debug"
+	next.Auth.AdminToken = "rotated"
+	next.Reviews.StaleSLA = time.Hour
+	next.Port = "9090"
+	next.DB.MaxConns = 50
+
+	changed := config.StructuralChanges(cur, next)
+	if !slices.Equal(changed, []string{"port", "db.max_conns"}) {
+		t.Fatalf("ожидали port и db.max_conns, получили %v", changed)
+	}
+
+	applied := cur.WithReloadable(next)
+	if applied.LogLevel != "debug" || applied.Auth.AdminToken != "rotated" || applied.Reviews.StaleSLA != time.Hour || applied.Port != cur.Port {
+		t.Fatalf("на лету должны примениться только динамические настройки: %+v", applied)
+	}
+}
+
+// Проверяем список реплик из окружения и то, что его смена требует перезапуска
+func TestConfig_ReplicaDSNs(t *testing.T) {
+	path := writeConfigFile(t, `
+dsn: postgres://primary
+port: "8080"
+auth:
+  admin_token: secret
+`)
+	t.Setenv("DB_REPLICA_DSNS", "postgres://replica-1, postgres://replica-2,")
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load вернул ошибку: %v", err)
+	}
+	if !slices.Equal(cfg.DB.ReplicaDSNs, []string{"postgres://replica-1", "postgres://replica-2"}) {
+		t.Fatalf("ожидали две реплики, получили %v", cfg.DB.ReplicaDSNs)
+	}
+
+	next := cfg
+	next.DB.ReplicaDSNs = []string{"postgres://replica-1"}
+	if changed := config.StructuralChanges(cfg, next); !slices.Equal(changed, []string{"db.replica_dsns"}) {
+		t.Fatalf("ожидали db.replica_dsns, получили %v", changed)
+	}
+}
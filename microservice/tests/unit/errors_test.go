@@ -0,0 +1,261 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/grpcapi/pb"
+	"review-manager/internal/httpapi"
+	"review-manager/internal/repository"
+	"review-manager/internal/service"
+	"review-manager/internal/validation"
+)
+
+// Проверяем, что валидация возвращает ошибки всех полей разом, а не первую
+func TestValidateTeamAdd_ReturnsAllFieldErrors(t *testing.T) {
+	sla, escalation := 4, 2
+	err := validation.ValidateTeamAdd(dto.TeamAddRequest{
+		ReviewSlaHours:  &sla,
+		EscalationHours: &escalation,
+	})
+
+	var fieldErrs validation.FieldErrors
+	if !errors.As(err, &fieldErrs) {
+		t.Fatalf("ожидали validation.FieldErrors, получили %v", err)
+	}
+
+	var fields []string
+	for _, fe := range fieldErrs {
+		fields = append(fields, fe.Field)
+	}
+	if got := strings.Join(fields, ","); got != "team_name,members,escalation_hours" {
+		t.Fatalf("неожиданный набор полей: %s", got)
+	}
+
+	if err := validation.ValidateTeamAdd(dto.TeamAddRequest{
+		TeamName: "backend",
+		Members:  []dto.TeamMember{{UserID: "u1", Username: "Alice"}},
+	}); err != nil {
+		t.Fatalf("корректный запрос не должен давать ошибку, получили %v", err)
+	}
+}
+
+// Проверяем HTTP-ответ на невалидный запрос: 400, VALIDATION_FAILED и ошибки полей в details
+func TestHTTP_ValidationFailedDetails(t *testing.T) {
+	mux := httpapi.NewMux(httpapi.NewHandler(nil, nil, nil, nil, nil, nil, nil, nil, "secret"))
+
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(`{"changed_files":["a.go",""]}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("ожидали 400, получили %d", rec.Code)
+	}
+	var resp dto.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("тело не dto.ErrorResponse: %q", rec.Body.String())
+	}
+	if resp.Error.Code != dto.ErrorCodeValidationFailed {
+		t.Fatalf("ожидали %s, получили %s", dto.ErrorCodeValidationFailed, resp.Error.Code)
+	}
+
+	want := []string{"pull_request_id", "pull_request_name", "author_id", "changed_files[1]"}
+	if len(resp.Error.Details) != len(want) {
+		t.Fatalf("ожидали %d ошибок в details, получили %+v", len(want), resp.Error.Details)
+	}
+	for i, d := range resp.Error.Details {
+		if d.Field != want[i] || d.Message == "" {
+			t.Fatalf("details[%d]: ожидали поле %s с текстом, получили %+v", i, want[i], d)
+		}
+	}
+}
+
+// Проверяем, что без токена админские ручки отвечают UNAUTHORIZED, а не NOT_FOUND
+func TestHTTP_UnauthorizedCode(t *testing.T) {
+	mux := httpapi.NewMux(httpapi.NewHandler(nil, nil, nil, nil, nil, nil, nil, nil, "secret"))
+
+	req := httptest.NewRequest(http.MethodPost, "/team/setParent", strings.NewReader(`{"team_name":"backend"}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("ожидали 401, получили %d", rec.Code)
+	}
+	if code := errorCode(t, rec); code != dto.ErrorCodeUnauthorized {
+		t.Fatalf("ожидали %s, получили %s", dto.ErrorCodeUnauthorized, code)
+	}
+}
+
+// Проверяем limit в /users/getReview: без него приходят все PR, неверный дает ошибку поля limit
+func TestHTTP_UserGetReviewLimit(t *testing.T) {
+	userRepo := NewMockUserRepo()
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Alice", TeamID: 1, TeamName: "backend", IsActive: true}
+	for i := range dto.MaxReviewPageSize + 10 {
+		userRepo.ReviewPRs["u1"] = append(userRepo.ReviewPRs["u1"], repository.PullRequestShort{
+			ID:         "pr-" + strconv.Itoa(i),
+			StatusName: "OPEN",
+			CreatedAt:  time.Date(2025, 1, 1, 0, i, 0, 0, time.UTC),
+		})
+	}
+	userSvc := service.NewUserService(userRepo, NewMockAbsenceRepo(), nil, nil)
+	mux := httpapi.NewMux(httpapi.NewHandler(nil, userSvc, nil, nil, nil, nil, nil, nil, "secret"))
+
+	req := httptest.NewRequest(http.MethodGet, "/users/getReview?user_id=u1", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	var resp dto.UserGetReviewResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); rec.Code != http.StatusOK || err != nil {
+		t.Fatalf("ожидали 200, получили %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(resp.PullRequests) != dto.MaxReviewPageSize+10 || resp.NextCursor != "" {
+		t.Fatalf("без limit ожидали все %d PR одной страницей, получили %d", dto.MaxReviewPageSize+10, len(resp.PullRequests))
+	}
+
+	for _, limit := range []string{"abc", "0", "-1", "201"} {
+		req := httptest.NewRequest(http.MethodGet, "/users/getReview?user_id=u1&limit="+limit, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		var errResp dto.ErrorResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &errResp)
+		if rec.Code != http.StatusBadRequest || len(errResp.Error.Details) != 1 || errResp.Error.Details[0].Field != "limit" {
+			t.Fatalf("limit=%s: ожидали 400 с ошибкой поля limit, получили %d: %s", limit, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// Проверяем, что лимит открытых ревью отдает AT_CAPACITY, а не NO_CANDIDATE, и при случайной, и при ручной замене
+func TestHTTP_ReassignAtCapacityCode(t *testing.T) {
+	svc, prRepo, userRepo := newTestPRService()
+
+	zero := 0
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 2, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Old", TeamID: 1, IsActive: true}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Busy", TeamID: 1, IsActive: true, MaxOpenReviews: &zero}
+	prRepo.PRs["pr-cap"] = repository.PullRequest{ID: "pr-cap", AuthorID: "u1", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-cap"] = []string{"u2"}
+
+	mux := httpapi.NewMux(httpapi.NewHandler(nil, nil, svc, nil, nil, nil, nil, nil, "secret"))
+
+	for _, body := range []string{
+		`{"pull_request_id":"pr-cap","old_user_id":"u2"}`,
+		`{"pull_request_id":"pr-cap","old_user_id":"u2","new_user_id":"u3"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/pullRequest/reassign", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		var errResp dto.ErrorResponse
+		_ = json.Unmarshal(rec.Body.Bytes(), &errResp)
+		if rec.Code != http.StatusConflict || errResp.Error.Code != dto.ErrorCodeAtCapacity {
+			t.Fatalf("%s: ожидали 409 AT_CAPACITY, получили %d: %s", body, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+// Проверяем, что восстановить удаленного пользователя может только admin
+func TestHTTP_UserRestoreRequiresAdmin(t *testing.T) {
+	mux := httpapi.NewMux(httpapi.NewHandler(nil, nil, nil, nil, nil, nil, nil, nil, "secret"))
+
+	req := httptest.NewRequest(http.MethodPost, "/users/restore", strings.NewReader(`{"user_id":"u1"}`))
+	req.Header.Set("Authorization", "Bearer lead-token")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("ожидали 401, получили %d", rec.Code)
+	}
+}
+
+// Проверяем, что лида и родительскую команду в /team/add задают только с admin-токеном,
+// а обычное создание команды токена не требует
+func TestHTTP_TeamAddLeadAndParentRequireAdmin(t *testing.T) {
+	mux := httpapi.NewMux(httpapi.NewHandler(nil, nil, nil, nil, nil, nil, nil, nil, "secret"))
+
+	for _, body := range []string{
+		`{"team_name":"backend","members":[{"user_id":"u1","username":"Alice"}],"lead_user_id":"u1"}`,
+		`{"team_name":"backend","members":[{"user_id":"u1","username":"Alice"}],"parent_team":"platform"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/team/add", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer lead-token")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Fatalf("%s: ожидали 401, получили %d", body, rec.Code)
+		}
+	}
+
+	// без лида и родителя до проверки токена дело не доходит: ответ от валидации
+	req := httptest.NewRequest(http.MethodPost, "/team/add", strings.NewReader(`{"team_name":"backend"}`))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("ожидали 400 от валидации, получили %d", rec.Code)
+	}
+}
+
+// Проверяем, что в gRPC ошибки полей приходят в BadRequest.FieldViolations с reason VALIDATION_FAILED
+func TestGRPC_InvalidArgumentFieldViolations(t *testing.T) {
+	conn, _, _ := newTestGRPCClient(t, nil)
+	client := pb.NewPullRequestServiceClient(conn)
+
+	_, err := client.Merge(context.Background(), &pb.MergePullRequestRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ожидали InvalidArgument, получили %v", err)
+	}
+	if reason := errorReason(err); reason != dto.ErrorCodeValidationFailed {
+		t.Fatalf("ожидали reason %s, получили %s", dto.ErrorCodeValidationFailed, reason)
+	}
+
+	var fields []string
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	if len(fields) != 1 || fields[0] != "pull_request_id" {
+		t.Fatalf("ожидали нарушение поля pull_request_id, получили %v", fields)
+	}
+}
+
+// Проверяем, что у каждой сущности свой текст "не найдено", а errors.Is их не путает
+func TestNotFoundErrors_PerEntity(t *testing.T) {
+	notFound := []error{
+		repository.ErrTeamNotFound,
+		repository.ErrUserNotFound,
+		repository.ErrPRNotFound,
+		repository.ErrAbsenceNotFound,
+		repository.ErrWebhookNotFound,
+	}
+
+	seen := make(map[string]bool, len(notFound))
+	for i, err := range notFound {
+		if seen[err.Error()] {
+			t.Fatalf("текст ошибки %q повторяется", err.Error())
+		}
+		seen[err.Error()] = true
+
+		for j, other := range notFound {
+			if i != j && errors.Is(err, other) {
+				t.Fatalf("errors.Is(%q, %q) не должен срабатывать", err, other)
+			}
+		}
+	}
+}
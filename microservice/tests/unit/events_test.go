@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/events"
+	"review-manager/internal/repository"
+)
+
+// Проверяем, что при переподключении с Last-Event-ID отдаются только более новые события подходящего фильтра
+func TestBroker_SubscribeResumesFromLastID(t *testing.T) {
+	b := events.NewBroker(10)
+
+	b.Publish(dto.Event{Type: dto.EventPrCreated, TeamName: "backend", AuthorID: "u1"})
+	b.Publish(dto.Event{Type: dto.EventPrCreated, TeamName: "frontend", AuthorID: "u7"})
+
+	all, sub := b.Subscribe(1, events.Filter{})
+	sub.Close()
+	if len(all) != 2 {
+		t.Fatalf("ожидали 2 события в буфере, получили %d", len(all))
+	}
+
+	backlog, sub := b.Subscribe(all[0].ID, events.Filter{TeamName: "frontend"})
+	defer sub.Close()
+	if len(backlog) != 1 || backlog[0].AuthorID != "u7" {
+		t.Fatalf("ожидали одно событие frontend после Last-Event-ID, получили %+v", backlog)
+	}
+
+	b.Publish(dto.Event{Type: dto.EventPrMerged, TeamName: "backend"})
+	b.Publish(dto.Event{Type: dto.EventPrMerged, TeamName: "frontend"})
+
+	e := <-sub.C
+	if e.TeamName != "frontend" || e.Type != dto.EventPrMerged {
+		t.Fatalf("подписка по команде получила чужое событие: %+v", e)
+	}
+}
+
+// Проверяем, что буфер ограничен, а отстающий подписчик отключается
+func TestBroker_BoundedBufferAndSlowSubscriber(t *testing.T) {
+	b := events.NewBroker(3)
+
+	_, slow := b.Subscribe(0, events.Filter{})
+	for i := 0; i < 100; i++ {
+		b.Publish(dto.Event{Type: dto.EventReviewerAssigned, UserID: "u1"})
+	}
+
+	backlog, sub := b.Subscribe(1, events.Filter{})
+	sub.Close()
+	if len(backlog) != 3 {
+		t.Fatalf("ожидали 3 последних события в буфере, получили %d", len(backlog))
+	}
+
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n == 0 || n >= 100 {
+		t.Fatalf("отстающий подписчик должен получить часть событий и быть отключен, получил %d", n)
+	}
+}
+
+// Проверяем, что сервис публикует события после успешных операций и не публикует при ошибке
+func TestPRService_PublishesEvents(t *testing.T) {
+	svc, prRepo, userRepo := newTestPRService()
+	broker := svc.Events.(*events.Broker)
+	_, sub := broker.Subscribe(0, events.Filter{UserID: "u3"})
+	defer sub.Close()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Old", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "New", TeamID: 2, TeamName: "platform", IsActive: true}
+
+	prRepo.PRs["pr-e"] = repository.PullRequest{ID: "pr-e", AuthorID: "u1", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-e"] = []string{"u2"}
+
+	// неудачная операция ничего не публикует
+	_, err := svc.AddReviewer(context.Background(), dto.AddReviewerRequest{PullRequestID: "pr-e", UserID: "u1"})
+	if err == nil {
+		t.Fatalf("ожидали ошибку при назначении автора")
+	}
+
+	if _, err := svc.Reassign(context.Background(), dto.ReassignPrRequest{PullRequestID: "pr-e", OldUserID: "u2", NewUserID: "u3"}); err != nil {
+		t.Fatalf("Reassign вернул ошибку: %v", err)
+	}
+	if _, err := svc.Merge(context.Background(), dto.MergePrRequest{PullRequestID: "pr-e"}); err != nil {
+		t.Fatalf("Merge вернул ошибку: %v", err)
+	}
+	// повторный merge события не дает
+	if _, err := svc.Merge(context.Background(), dto.MergePrRequest{PullRequestID: "pr-e"}); err != nil {
+		t.Fatalf("повторный Merge вернул ошибку: %v", err)
+	}
+
+	var got []dto.Event
+	for len(sub.C) > 0 {
+		got = append(got, <-sub.C)
+	}
+	if len(got) != 2 {
+		t.Fatalf("ожидали 2 события (replaced, merged), получили %+v", got)
+	}
+	if got[0].Type != dto.EventReviewerReplaced || got[0].OldUserID != "u2" || got[0].TeamName != "backend" {
+		t.Fatalf("неверное событие замены: %+v", got[0])
+	}
+	if got[1].Type != dto.EventPrMerged || got[1].PR == nil || got[1].PR.Status != dto.PrStatusMerged {
+		t.Fatalf("неверное событие merge: %+v", got[1])
+	}
+}
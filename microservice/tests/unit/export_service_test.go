@@ -0,0 +1,78 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/repository"
+	"review-manager/internal/service"
+)
+
+func newTestExportService() (*service.ExportService, *MockExportRepo) {
+	repo := NewMockExportRepo()
+	return service.NewExportService(repo), repo
+}
+
+// Проверяем, что фильтр статуса переводится в status_id, а строки мапятся в DTO
+func TestExportService_ExportPullRequests_MapsFilterAndRows(t *testing.T) {
+	svc, repo := newTestExportService()
+
+	now := time.Now().UTC()
+	repo.PRs = []repository.PullRequestExportRow{
+		{
+			PR:        repository.PullRequest{ID: "pr-1", Name: "Feature", AuthorID: "u1", StatusID: repository.StatusOpen, CreatedAt: now},
+			TeamName:  "backend",
+			Reviewers: []string{"u2", "u3"},
+		},
+	}
+
+	var got []dto.PullRequestExportRow
+	err := svc.ExportPullRequests(context.Background(), dto.ExportPrFilter{Status: "OPEN", TeamName: "backend"},
+		func(row dto.PullRequestExportRow) error {
+			got = append(got, row)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("ExportPullRequests вернул ошибку: %v", err)
+	}
+
+	if repo.LastFilter.StatusID != repository.StatusOpen || repo.LastFilter.TeamName != "backend" {
+		t.Fatalf("фильтр передан в репозиторий некорректно, получили %+v", repo.LastFilter)
+	}
+	if len(got) != 1 {
+		t.Fatalf("ожидали 1 строку выгрузки, получили %d", len(got))
+	}
+	if got[0].PullRequestID != "pr-1" || got[0].Status != dto.PrStatusOpen || got[0].TeamName != "backend" {
+		t.Fatalf("строка выгрузки собрана некорректно, получили %+v", got[0])
+	}
+	if len(got[0].AssignedReviewers) != 2 {
+		t.Fatalf("ожидали 2 ревьювера в строке выгрузки, получили %d", len(got[0].AssignedReviewers))
+	}
+}
+
+// Проверяем, что ошибка из fn прерывает выгрузку и возвращается наружу
+func TestExportService_ExportTeams_StopsOnWriterError(t *testing.T) {
+	svc, repo := newTestExportService()
+
+	repo.Members = []repository.TeamMemberExportRow{
+		{TeamName: "backend", UserID: "u1", Username: "Anya", IsActive: true},
+		{TeamName: "backend", UserID: "u2", Username: "Kostya", IsActive: false},
+	}
+
+	writeErr := errors.New("client gone")
+	calls := 0
+	err := svc.ExportTeams(context.Background(), "", func(dto.TeamMemberExportRow) error {
+		calls++
+		return writeErr
+	})
+
+	if !errors.Is(err, writeErr) {
+		t.Fatalf("ожидали ошибку записи, получили %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("ожидали, что выгрузка остановится после первой строки, вызовов было %d", calls)
+	}
+}
@@ -0,0 +1,188 @@
+package unit
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/grpcapi"
+	"review-manager/internal/grpcapi/pb"
+	"review-manager/internal/repository"
+	"review-manager/internal/service"
+)
+
+// поднимаем gRPC сервер на in-memory соединении поверх сервисов с мок-репозиториями, limiter может быть nil
+func newTestGRPCClient(t *testing.T, limiter grpcapi.Limiter) (*grpc.ClientConn, *MockPrRepo, *MockUserRepo) {
+	t.Helper()
+
+	prSvc, prRepo, userRepo := newTestPRService()
+	teamSvc, _, _ := newTestTeamService()
+	userSvc, _ := newTestUserService()
+
+	conn := dialTestGRPC(t, grpcapi.NewGRPCServer(grpcapi.NewServer(teamSvc, userSvc, prSvc, "secret"), limiter, 0))
+	return conn, prRepo, userRepo
+}
+
+// запускаем gs на in-memory соединении и подключаемся к нему
+func dialTestGRPC(t *testing.T, gs *grpc.Server) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("не удалось подключиться к gRPC серверу: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+func errorReason(err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+// Проверяем, что ошибки сервисов переводятся в gRPC-коды с тем же dto-кодом в ErrorInfo
+func TestGRPC_PullRequest_MapsServiceErrors(t *testing.T) {
+	conn, prRepo, userRepo := newTestGRPCClient(t, nil)
+	client := pb.NewPullRequestServiceClient(conn)
+	ctx := context.Background()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, IsActive: true}
+	prRepo.PRs["pr-1"] = repository.PullRequest{ID: "pr-1", AuthorID: "u1", StatusID: repository.StatusMerged, Version: 2}
+
+	_, err := client.Create(ctx, &pb.CreatePullRequestRequest{PullRequestId: "pr-1", PullRequestName: "dup", AuthorId: "u1"})
+	if status.Code(err) != codes.AlreadyExists || errorReason(err) != "PR_EXISTS" {
+		t.Fatalf("ожидали AlreadyExists/PR_EXISTS, получили %v (%s)", err, errorReason(err))
+	}
+
+	_, err = client.Reassign(ctx, &pb.ReassignRequest{PullRequestId: "pr-1", OldUserId: "u2"})
+	if status.Code(err) != codes.FailedPrecondition || errorReason(err) != "PR_MERGED" {
+		t.Fatalf("ожидали FailedPrecondition/PR_MERGED, получили %v (%s)", err, errorReason(err))
+	}
+
+	stale := int64(1)
+	_, err = client.Merge(ctx, &pb.MergePullRequestRequest{PullRequestId: "pr-1", ExpectedVersion: &stale})
+	if status.Code(err) != codes.Aborted || errorReason(err) != "VERSION_CONFLICT" {
+		t.Fatalf("ожидали Aborted/VERSION_CONFLICT, получили %v (%s)", err, errorReason(err))
+	}
+
+	_, err = client.Merge(ctx, &pb.MergePullRequestRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("ожидали InvalidArgument для пустого запроса, получили %v", err)
+	}
+
+	resp, err := client.Merge(ctx, &pb.MergePullRequestRequest{PullRequestId: "pr-1"})
+	if err != nil || resp.GetPr().GetStatus() != "MERGED" {
+		t.Fatalf("ожидали успешный merge, получили %v, %v", resp, err)
+	}
+}
+
+// Проверяем, что админские методы требуют токен в metadata, как и в HTTP
+func TestGRPC_AdminMethodsRequireToken(t *testing.T) {
+	conn, _, _ := newTestGRPCClient(t, nil)
+	client := pb.NewUserServiceClient(conn)
+
+	_, err := client.SetIsActive(context.Background(), &pb.SetIsActiveRequest{UserId: "u1"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("ожидали Unauthenticated без токена, получили %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+	_, err = client.SetIsActive(ctx, &pb.SetIsActiveRequest{UserId: "u1"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("с токеном ожидали NotFound для неизвестного пользователя, получили %v", err)
+	}
+}
+
+// Проверяем, что методы команды и пользователя принимают, как и в HTTP, токен лида своей команды:
+// admin-токен и токен лида проходят, токен лида другой команды и вызов без токена - нет
+func TestGRPC_AdminMethodsAcceptLeadToken(t *testing.T) {
+	ctx := context.Background()
+	userRepo := NewMockUserRepo()
+	teamSvc := service.NewTeamService(NewMockTeamRepo(), userRepo, NewMockCodeownersRepo(), NewMockWebhookRepo(), &MockTxManager{})
+	userSvc, prRepo := newTestUserServiceWithPRs(userRepo, NewMockAbsenceRepo())
+	prSvc := service.NewPRService(prRepo, userRepo, NewMockTeamRepo(), NewMockCodeownersRepo(), &MockTxManager{}, nil)
+
+	tokens := make(map[string]string)
+	for _, name := range []string{"backend", "frontend"} {
+		if _, err := teamSvc.TeamAdd(ctx, dto.TeamAddRequest{
+			TeamName: name,
+			Members:  []dto.TeamMember{{UserID: name + "-1", Username: name, IsActive: true}},
+		}); err != nil {
+			t.Fatalf("TeamAdd(%s) вернул ошибку: %v", name, err)
+		}
+		// мок не знает названий команд, а IsUserLeadToken ищет команду по TeamName
+		u := userRepo.Users[name+"-1"]
+		u.TeamName = name
+		userRepo.Users[name+"-1"] = u
+
+		resp, err := teamSvc.SetLead(ctx, dto.SetTeamLeadRequest{TeamName: name, UserID: name + "-1"})
+		if err != nil {
+			t.Fatalf("SetLead(%s) вернул ошибку: %v", name, err)
+		}
+		tokens[name] = resp.LeadToken
+	}
+
+	conn := dialTestGRPC(t, grpcapi.NewGRPCServer(grpcapi.NewServer(teamSvc, userSvc, prSvc, "secret"), nil, 0))
+	teams := pb.NewTeamServiceClient(conn)
+	users := pb.NewUserServiceClient(conn)
+
+	calls := map[string]func(ctx context.Context) error{
+		"SetDefaultCapacity": func(ctx context.Context) error {
+			_, err := teams.SetDefaultCapacity(ctx, &pb.SetDefaultCapacityRequest{TeamName: "backend"})
+			return err
+		},
+		"SetIsActive": func(ctx context.Context) error {
+			_, err := users.SetIsActive(ctx, &pb.SetIsActiveRequest{UserId: "backend-1", IsActive: true})
+			return err
+		},
+		"SetCapacity": func(ctx context.Context) error {
+			_, err := users.SetCapacity(ctx, &pb.SetCapacityRequest{UserId: "backend-1"})
+			return err
+		},
+	}
+	cases := []struct {
+		name  string
+		token string
+		want  codes.Code
+	}{
+		{"admin", "secret", codes.OK},
+		{"лид своей команды", tokens["backend"], codes.OK},
+		{"лид другой команды", tokens["frontend"], codes.Unauthenticated},
+		{"без токена", "", codes.Unauthenticated},
+	}
+
+	for method, call := range calls {
+		for _, c := range cases {
+			callCtx := ctx
+			if c.token != "" {
+				callCtx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+			}
+			if err := call(callCtx); status.Code(err) != c.want {
+				t.Fatalf("%s, %s: ожидали %v, получили %v", method, c.name, c.want, err)
+			}
+		}
+	}
+}
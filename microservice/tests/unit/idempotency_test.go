@@ -0,0 +1,209 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/httpapi"
+)
+
+// хэндлер-счетчик: отвечает 201 с телом запроса и номером вызова в ETag
+func countingHandler(calls *int, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	})
+}
+
+func doIdempotent(h http.Handler, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// Проверяем, что повтор с тем же ключом и телом отдает сохраненный ответ без второго вызова хэндлера
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	h := httpapi.NewIdempotency(NewMockIdempotencyRepo(), time.Hour).Wrap(countingHandler(&calls, http.StatusCreated))
+
+	first := doIdempotent(h, "k1", `{"pull_request_id":"pr-1"}`)
+	second := doIdempotent(h, "k1", `{"pull_request_id":"pr-1"}`)
+
+	if calls != 1 {
+		t.Fatalf("ожидали один вызов хэндлера, получили %d", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Fatalf("повтор должен вернуть исходный ответ, получили %d %q", second.Code, second.Body.String())
+	}
+	if second.Header().Get("ETag") != `"1"` || second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("ожидали сохраненные заголовки и Idempotent-Replayed, получили %v", second.Header())
+	}
+}
+
+// Проверяем, что ключ нельзя переиспользовать с другим телом
+func TestIdempotency_RejectsDifferentBody(t *testing.T) {
+	calls := 0
+	h := httpapi.NewIdempotency(NewMockIdempotencyRepo(), time.Hour).Wrap(countingHandler(&calls, http.StatusCreated))
+
+	doIdempotent(h, "k2", `{"pull_request_id":"pr-1"}`)
+	resp := doIdempotent(h, "k2", `{"pull_request_id":"pr-2"}`)
+
+	if resp.Code != http.StatusUnprocessableEntity || !strings.Contains(resp.Body.String(), "IDEMPOTENCY_KEY_REUSED") {
+		t.Fatalf("ожидали 422 IDEMPOTENCY_KEY_REUSED, получили %d %q", resp.Code, resp.Body.String())
+	}
+	if calls != 1 {
+		t.Fatalf("хэндлер не должен вызываться при конфликте ключа, вызовов: %d", calls)
+	}
+}
+
+// Проверяем, что 5xx не запоминается и повтор выполняется заново, а без ключа middleware прозрачен
+func TestIdempotency_DoesNotStoreServerErrors(t *testing.T) {
+	calls := 0
+	repo := NewMockIdempotencyRepo()
+	h := httpapi.NewIdempotency(repo, time.Hour).Wrap(countingHandler(&calls, http.StatusInternalServerError))
+
+	doIdempotent(h, "k3", `{}`)
+	doIdempotent(h, "k3", `{}`)
+	doIdempotent(h, "", `{}`)
+
+	if calls != 3 {
+		t.Fatalf("ожидали 3 вызова хэндлера, получили %d", calls)
+	}
+	if len(repo.Keys) != 0 {
+		t.Fatalf("ключ после 5xx должен быть освобожден")
+	}
+}
+
+// Проверяем, что ключ привязан к токену: повтор без токена не получает сохраненный ответ и упирается в 401,
+// а токен лида не сохраняется и не отдается при повторе даже с тем же токеном
+func TestIdempotency_ScopedToCallerAndRedactsLeadToken(t *testing.T) {
+	teamSvc, _, _ := newTestTeamService()
+	if _, err := teamSvc.TeamAdd(context.Background(), dto.TeamAddRequest{
+		TeamName: "backend",
+		Members:  []dto.TeamMember{{UserID: "u1", Username: "Lead", IsActive: true}},
+	}); err != nil {
+		t.Fatalf("TeamAdd вернул ошибку: %v", err)
+	}
+
+	repo := NewMockIdempotencyRepo()
+	mux := httpapi.NewMux(httpapi.NewHandler(teamSvc, nil, nil, nil, nil, nil, nil, nil, "secret"))
+	h := httpapi.NewIdempotency(repo, time.Hour).Wrap(mux)
+
+	setLead := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/team/setLead", strings.NewReader(`{"team_name":"backend","user_id":"u1"}`))
+		req.Header.Set("Idempotency-Key", "lead-1")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	leadToken := func(rec *httptest.ResponseRecorder) string {
+		var resp dto.SetTeamLeadResponse
+		if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("тело не dto.SetTeamLeadResponse: %q", rec.Body.String())
+		}
+		return resp.LeadToken
+	}
+
+	first := setLead("secret")
+	if first.Code != http.StatusOK || leadToken(first) == "" {
+		t.Fatalf("ожидали 200 с токеном лида, получили %d %q", first.Code, first.Body.String())
+	}
+
+	if rec := setLead(""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("повтор без токена должен получить 401, получили %d %q", rec.Code, rec.Body.String())
+	}
+
+	replayed := setLead("secret")
+	if replayed.Code != http.StatusOK || replayed.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("повтор с тем же токеном должен вернуть сохраненный ответ, получили %d", replayed.Code)
+	}
+	if leadToken(replayed) != "" {
+		t.Fatalf("токен лида не должен отдаваться при повторе")
+	}
+
+	for _, e := range repo.Keys {
+		if strings.Contains(string(e.rec.Body), leadToken(first)) {
+			t.Fatalf("токен лида не должен сохраняться в ключах идемпотентности")
+		}
+	}
+}
+
+// Проверяем, что после паники хэндлера ключ освобождается и повтор выполняется заново, а не получает 409
+func TestIdempotency_ReleasesKeyOnPanic(t *testing.T) {
+	calls := 0
+	repo := NewMockIdempotencyRepo()
+	h := httpapi.NewIdempotency(repo, time.Hour).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("паника хэндлера должна пробрасываться дальше")
+			}
+		}()
+		doIdempotent(h, "k4", `{}`)
+	}()
+
+	if len(repo.Keys) != 0 {
+		t.Fatalf("после паники ключ должен быть освобожден, осталось %d", len(repo.Keys))
+	}
+	if rec := doIdempotent(h, "k4", `{}`); rec.Code != http.StatusCreated || calls != 2 {
+		t.Fatalf("повтор после паники должен выполниться заново, получили %d, вызовов %d", rec.Code, calls)
+	}
+}
+
+// Проверяем, что запрос с истекшей арендой не затирает ответ повтора, который перезанял ключ,
+// и не снимает его резерв
+func TestIdempotency_ExpiredLeaseDoesNotOverwrite(t *testing.T) {
+	calls := 0
+	repo := NewMockIdempotencyRepo()
+
+	var h http.Handler
+	h = httpapi.NewIdempotency(repo, time.Hour).Wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			// первый запрос завис дольше аренды: ключ истек, и его перезанял повтор
+			for k, e := range repo.Keys {
+				e.expiresAt = time.Now().Add(-time.Second)
+				repo.Keys[k] = e
+			}
+			if rec := doIdempotent(h, "k5", `{}`); rec.Code != http.StatusCreated {
+				t.Fatalf("повтор после истечения аренды должен выполниться, получили %d", rec.Code)
+			}
+		}
+		w.Header().Set("ETag", `"`+strconv.Itoa(calls)+`"`)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	doIdempotent(h, "k5", `{}`)
+
+	replayed := doIdempotent(h, "k5", `{}`)
+	if calls != 2 || replayed.Header().Get("ETag") != `"2"` {
+		t.Fatalf("ожидали сохраненный ответ повтора (ETag \"2\"), получили %q, вызовов %d", replayed.Header().Get("ETag"), calls)
+	}
+}
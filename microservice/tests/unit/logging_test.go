@@ -0,0 +1,45 @@
+package unit
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"review-manager/internal/config"
+)
+
+// Проверяем, что при log_level: warn ошибки фоновых задач по-прежнему пишутся, а info отфильтровывается
+func TestLogLevel_WarnStillLogsErrors(t *testing.T) {
+	level, err := config.ParseLogLevel("warn")
+	if err != nil {
+		t.Fatalf("ParseLogLevel вернул ошибку: %v", err)
+	}
+
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level})))
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	// первый прогон падает с ошибкой, после него Run сразу выходит: ctx уже отменен
+	job, repo, _ := newTestArchiveJob()
+	repo.FailOnCall = 1
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	job.Run(ctx, time.Hour)
+
+	out := buf.String()
+	if !strings.Contains(out, "level=ERROR") || !strings.Contains(out, errMockArchive.Error()) {
+		t.Fatalf("ожидали ошибку задачи в логе при log_level=warn, получили %q", out)
+	}
+
+	// успешный прогон пишет на уровне info, при warn его быть не должно
+	buf.Reset()
+	addMerged(repo, "old", 1, 48*time.Hour)
+	job.Run(ctx, time.Hour)
+	if buf.Len() != 0 {
+		t.Fatalf("info-сообщения не должны проходить при log_level=warn, получили %q", buf.String())
+	}
+}
@@ -0,0 +1,73 @@
+package unit
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"review-manager/internal/repository"
+)
+
+// in-memory реализация AbsenceRepo для тестов
+type MockAbsenceRepo struct {
+	Absences map[int]repository.Absence
+	nextID   int
+}
+
+func NewMockAbsenceRepo() *MockAbsenceRepo {
+	return &MockAbsenceRepo{
+		Absences: make(map[int]repository.Absence),
+	}
+}
+
+// Заполняем интерфейс
+func (m *MockAbsenceRepo) Add(_ context.Context, a repository.Absence) (repository.Absence, error) {
+	m.nextID++
+	a.ID = m.nextID
+	m.Absences[a.ID] = a
+	return a, nil
+}
+
+func (m *MockAbsenceRepo) Delete(_ context.Context, absenceID int) error {
+	if _, ok := m.Absences[absenceID]; !ok {
+		return repository.ErrAbsenceNotFound
+	}
+	delete(m.Absences, absenceID)
+	return nil
+}
+
+func (m *MockAbsenceRepo) ListUpcoming(_ context.Context, userID string, now time.Time) ([]repository.Absence, error) {
+	return m.filter(func(a repository.Absence) bool {
+		return a.UserID == userID && a.EndsAt.After(now)
+	}), nil
+}
+
+func (m *MockAbsenceRepo) ListStartedUnprocessed(_ context.Context, now time.Time) ([]repository.Absence, error) {
+	return m.filter(func(a repository.Absence) bool {
+		return !a.StartsAt.After(now) && a.EndsAt.After(now) && a.ReviewsReassignedAt == nil
+	}), nil
+}
+
+func (m *MockAbsenceRepo) MarkReviewsReassigned(_ context.Context, absenceID int, at time.Time) error {
+	a, ok := m.Absences[absenceID]
+	if !ok {
+		return repository.ErrAbsenceNotFound
+	}
+	a.ReviewsReassignedAt = &at
+	m.Absences[absenceID] = a
+	return nil
+}
+
+func (m *MockAbsenceRepo) filter(keep func(repository.Absence) bool) []repository.Absence {
+	var res []repository.Absence
+	for _, a := range m.Absences {
+		if keep(a) {
+			res = append(res, a)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].StartsAt.Before(res[j].StartsAt) })
+	return res
+}
+
+// compile-time проверка соответствия интерфейсу
+var _ repository.AbsenceRepo = (*MockAbsenceRepo)(nil)
@@ -0,0 +1,53 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"review-manager/internal/repository"
+)
+
+// in-memory реализация ArchiveRepo для тестов: Merged - время мержа еще не заархивированных PR
+type MockArchiveRepo struct {
+	Merged   map[string]time.Time
+	Archived []string
+
+	// сколько раз вызывали ArchiveMerged и на каком вызове вернуть ошибку (0 - никогда)
+	Calls      int
+	FailOnCall int
+}
+
+var errMockArchive = errors.New("archive failed")
+
+func NewMockArchiveRepo() *MockArchiveRepo {
+	return &MockArchiveRepo{Merged: make(map[string]time.Time)}
+}
+
+func (m *MockArchiveRepo) ArchiveMerged(_ context.Context, mergedBefore time.Time, limit int) ([]string, error) {
+	m.Calls++
+	if m.Calls == m.FailOnCall {
+		return nil, errMockArchive
+	}
+
+	var ids []string
+	for id, mergedAt := range m.Merged {
+		if mergedAt.Before(mergedBefore) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	for _, id := range ids {
+		delete(m.Merged, id)
+	}
+	m.Archived = append(m.Archived, ids...)
+	return ids, nil
+}
+
+// compile-time проверка соответствия интерфейсу
+var _ repository.ArchiveRepo = (*MockArchiveRepo)(nil)
@@ -0,0 +1,31 @@
+package unit
+
+import (
+	"context"
+
+	"review-manager/internal/repository"
+)
+
+// in-memory реализация CodeownersRepo для тестов
+type MockCodeownersRepo struct {
+	Rules map[int][]repository.CodeownersRule
+}
+
+func NewMockCodeownersRepo() *MockCodeownersRepo {
+	return &MockCodeownersRepo{
+		Rules: make(map[int][]repository.CodeownersRule),
+	}
+}
+
+// Заполняем интерфейс
+func (m *MockCodeownersRepo) ReplaceForTeam(_ context.Context, teamID int, rules []repository.CodeownersRule) error {
+	m.Rules[teamID] = append([]repository.CodeownersRule(nil), rules...)
+	return nil
+}
+
+func (m *MockCodeownersRepo) ListByTeam(_ context.Context, teamID int) ([]repository.CodeownersRule, error) {
+	return append([]repository.CodeownersRule(nil), m.Rules[teamID]...), nil
+}
+
+// compile-time проверка соответствия интерфейсу
+var _ repository.CodeownersRepo = (*MockCodeownersRepo)(nil)
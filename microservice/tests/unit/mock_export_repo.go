@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"context"
+
+	"review-manager/internal/repository"
+)
+
+// in-memory реализация ExportRepo для тестов, запоминает последний фильтр
+type MockExportRepo struct {
+	PRs         []repository.PullRequestExportRow
+	Members     []repository.TeamMemberExportRow
+	StatsRows   []repository.ReviewerStatRow
+	LastFilter  repository.PrFilter
+	LastTeamArg string
+}
+
+func NewMockExportRepo() *MockExportRepo {
+	return &MockExportRepo{}
+}
+
+// Заполняем интерфейс
+func (m *MockExportRepo) StreamPullRequests(
+	_ context.Context,
+	f repository.PrFilter,
+	fn func(repository.PullRequestExportRow) error,
+) error {
+	m.LastFilter = f
+	for _, row := range m.PRs {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockExportRepo) StreamTeamMembers(
+	_ context.Context,
+	teamName string,
+	fn func(repository.TeamMemberExportRow) error,
+) error {
+	m.LastTeamArg = teamName
+	for _, row := range m.Members {
+		if teamName != "" && row.TeamName != teamName {
+			continue
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MockExportRepo) StreamReviewerStats(
+	_ context.Context,
+	teamName string,
+	fn func(repository.ReviewerStatRow) error,
+) error {
+	m.LastTeamArg = teamName
+	for _, row := range m.StatsRows {
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compile-time проверка соответствия интерфейсу
+var _ repository.ExportRepo = (*MockExportRepo)(nil)
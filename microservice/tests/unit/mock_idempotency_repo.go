@@ -0,0 +1,80 @@
+package unit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"review-manager/internal/repository"
+)
+
+type mockIdempotencyEntry struct {
+	rec       repository.IdempotencyRecord
+	expiresAt time.Time
+}
+
+// in-memory реализация IdempotencyRepo для тестов
+type MockIdempotencyRepo struct {
+	Keys map[string]mockIdempotencyEntry
+
+	leases int
+}
+
+func NewMockIdempotencyRepo() *MockIdempotencyRepo {
+	return &MockIdempotencyRepo{Keys: make(map[string]mockIdempotencyEntry)}
+}
+
+func (m *MockIdempotencyRepo) Reserve(
+	_ context.Context,
+	key, requestHash string,
+	now, expiresAt time.Time,
+) (repository.IdempotencyRecord, bool, error) {
+	if e, ok := m.Keys[key]; ok && e.expiresAt.After(now) {
+		return e.rec, false, nil
+	}
+	m.leases++
+	rec := repository.IdempotencyRecord{Key: key, RequestHash: requestHash, LeaseID: strconv.Itoa(m.leases)}
+	m.Keys[key] = mockIdempotencyEntry{rec: rec, expiresAt: expiresAt}
+	return rec, true, nil
+}
+
+func (m *MockIdempotencyRepo) Complete(
+	_ context.Context,
+	key, leaseID string,
+	status int,
+	headers map[string]string,
+	body []byte,
+	expiresAt time.Time,
+) error {
+	e, ok := m.Keys[key]
+	if !ok || e.rec.LeaseID != leaseID || e.rec.StatusCode != nil {
+		return repository.ErrLeaseLost
+	}
+	e.rec.StatusCode = &status
+	e.rec.Headers = headers
+	e.rec.Body = append([]byte(nil), body...)
+	e.expiresAt = expiresAt
+	m.Keys[key] = e
+	return nil
+}
+
+func (m *MockIdempotencyRepo) Release(_ context.Context, key, leaseID string) error {
+	if e, ok := m.Keys[key]; ok && e.rec.LeaseID == leaseID && e.rec.StatusCode == nil {
+		delete(m.Keys, key)
+	}
+	return nil
+}
+
+func (m *MockIdempotencyRepo) DeleteExpired(_ context.Context, now time.Time) (int64, error) {
+	var n int64
+	for k, e := range m.Keys {
+		if !e.expiresAt.After(now) {
+			delete(m.Keys, k)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// compile-time проверка соответствия интерфейсу
+var _ repository.IdempotencyRepo = (*MockIdempotencyRepo)(nil)
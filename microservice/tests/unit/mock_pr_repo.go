@@ -2,6 +2,7 @@ package unit
 
 import (
 	"context"
+	"sort"
 	"time"
 
 	"review-manager/internal/repository"
@@ -11,12 +12,17 @@ import (
 type MockPrRepo struct {
 	PRs       map[string]repository.PullRequest
 	Reviewers map[string][]string
+	Stats     repository.PrStatsRows
+
+	// состояние ревьюверов для ListByAuthor, кого нет - считается активным
+	ReviewerStates map[string]repository.PrReviewerState
 }
 
 func NewMockPrRepo() *MockPrRepo {
 	return &MockPrRepo{
-		PRs:       make(map[string]repository.PullRequest),
-		Reviewers: make(map[string][]string),
+		PRs:            make(map[string]repository.PullRequest),
+		Reviewers:      make(map[string][]string),
+		ReviewerStates: make(map[string]repository.PrReviewerState),
 	}
 }
 
@@ -27,6 +33,9 @@ func (m *MockPrRepo) Exists(_ context.Context, prID string) (bool, error) {
 }
 
 func (m *MockPrRepo) Insert(_ context.Context, pr repository.PullRequest) error {
+	if _, ok := m.PRs[pr.ID]; ok {
+		return repository.ErrPRExists
+	}
 	m.PRs[pr.ID] = pr
 	return nil
 }
@@ -35,9 +44,20 @@ func (m *MockPrRepo) AddReviewers(_ context.Context, prID string, reviewerIDs []
 	if len(reviewerIDs) == 0 {
 		return nil
 	}
-	cp := make([]string, len(reviewerIDs))
-	copy(cp, reviewerIDs)
-	m.Reviewers[prID] = cp
+	revs := append([]string(nil), m.Reviewers[prID]...)
+	for _, id := range reviewerIDs {
+		dup := false
+		for _, existing := range revs {
+			if existing == id {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			revs = append(revs, id)
+		}
+	}
+	m.Reviewers[prID] = revs
 	return nil
 }
 
@@ -50,11 +70,18 @@ func (m *MockPrRepo) GetWithReviewers(_ context.Context, prID string) (repositor
 	return pr, revs, nil
 }
 
+func (m *MockPrRepo) GetForUpdate(ctx context.Context, prID string) (repository.PullRequest, []string, error) {
+	return m.GetWithReviewers(ctx, prID)
+}
+
 func (m *MockPrRepo) SetMerged(_ context.Context, prID string, mergedAt time.Time) (repository.PullRequest, error) {
 	pr, ok := m.PRs[prID]
 	if !ok {
 		return repository.PullRequest{}, repository.ErrPRNotFound
 	}
+	if pr.StatusID != repository.StatusMerged {
+		pr.Version++
+	}
 	pr.StatusID = repository.StatusMerged
 	if pr.MergedAt == nil {
 		pr.MergedAt = &mergedAt
@@ -80,5 +107,68 @@ func (m *MockPrRepo) ReplaceReviewer(_ context.Context, prID, oldReviewerID, new
 		return repository.ErrReviewerNotSet
 	}
 	m.Reviewers[prID] = revs
+	m.bumpVersion(prID)
+	return nil
+}
+
+func (m *MockPrRepo) RemoveReviewer(_ context.Context, prID, reviewerID string) error {
+	revs := m.Reviewers[prID]
+	for i, id := range revs {
+		if id == reviewerID {
+			m.Reviewers[prID] = append(revs[:i:i], revs[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrReviewerNotSet
+}
+
+func (m *MockPrRepo) RefreshNeedMoreReviewers(_ context.Context, prID string) error {
+	pr, ok := m.PRs[prID]
+	if !ok {
+		return repository.ErrPRNotFound
+	}
+	pr.NeedMoreReviewers = len(m.Reviewers[prID]) < repository.RequiredReviewers
+	pr.Version++
+	m.PRs[prID] = pr
 	return nil
 }
+
+func (m *MockPrRepo) bumpVersion(prID string) {
+	if pr, ok := m.PRs[prID]; ok {
+		pr.Version++
+		m.PRs[prID] = pr
+	}
+}
+
+func (m *MockPrRepo) GetPrStats(_ context.Context) (repository.PrStatsRows, error) {
+	return m.Stats, nil
+}
+
+func (m *MockPrRepo) ListByAuthor(_ context.Context, authorID string) ([]repository.AuthoredPr, error) {
+	var res []repository.AuthoredPr
+	for id, pr := range m.PRs {
+		if pr.AuthorID != authorID {
+			continue
+		}
+		row := repository.AuthoredPr{PR: pr}
+		for _, rid := range m.Reviewers[id] {
+			st, ok := m.ReviewerStates[rid]
+			if !ok {
+				st = repository.PrReviewerState{IsActive: true}
+			}
+			st.UserID = rid
+			row.Reviewers = append(row.Reviewers, st)
+		}
+		res = append(res, row)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if !res[i].PR.CreatedAt.Equal(res[j].PR.CreatedAt) {
+			return res[i].PR.CreatedAt.After(res[j].PR.CreatedAt)
+		}
+		return res[i].PR.ID < res[j].PR.ID
+	})
+	return res, nil
+}
+
+// compile-time проверка соответствия интерфейсу
+var _ repository.PrRepo = (*MockPrRepo)(nil)
@@ -0,0 +1,19 @@
+package unit
+
+import (
+	"context"
+
+	"review-manager/internal/repository"
+)
+
+// in-memory реализация ReconcileRepo: отдает заранее заданный список сломанных PR
+type MockReconcileRepo struct {
+	Broken []repository.BrokenPr
+}
+
+func (m *MockReconcileRepo) ListBroken(_ context.Context) ([]repository.BrokenPr, error) {
+	return append([]repository.BrokenPr(nil), m.Broken...), nil
+}
+
+// compile-time проверка соответствия интерфейсу
+var _ repository.ReconcileRepo = (*MockReconcileRepo)(nil)
@@ -0,0 +1,92 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"review-manager/internal/repository"
+)
+
+// in-memory реализация StaleReviewRepo для тестов: назначения задаются сразу с порогами команды
+type MockStaleReviewRepo struct {
+	Assignments []repository.StaleAssignment
+}
+
+func NewMockStaleReviewRepo() *MockStaleReviewRepo {
+	return &MockStaleReviewRepo{}
+}
+
+// Заполняем интерфейс
+func (m *MockStaleReviewRepo) ListStale(_ context.Context, now time.Time, _, _ time.Duration) ([]repository.StaleAssignment, error) {
+	var res []repository.StaleAssignment
+	for _, a := range m.Assignments {
+		age := now.Sub(a.AssignedAt)
+		if (a.RemindedAt == nil && age >= a.SLA) || (a.EscalatedAt == nil && age >= a.EscalateAfter) {
+			res = append(res, a)
+		}
+	}
+	return res, nil
+}
+
+func (m *MockStaleReviewRepo) MarkReminded(_ context.Context, prID, reviewerID string, at time.Time) error {
+	a := m.find(prID, reviewerID)
+	if a == nil {
+		return repository.ErrReviewerNotSet
+	}
+	a.RemindedAt = &at
+	return nil
+}
+
+func (m *MockStaleReviewRepo) MarkEscalated(_ context.Context, prID, reviewerID string, at time.Time) error {
+	a := m.find(prID, reviewerID)
+	if a == nil {
+		return repository.ErrReviewerNotSet
+	}
+	a.EscalatedAt = &at
+	if a.RemindedAt == nil {
+		a.RemindedAt = &at
+	}
+	return nil
+}
+
+func (m *MockStaleReviewRepo) find(prID, reviewerID string) *repository.StaleAssignment {
+	for i := range m.Assignments {
+		if m.Assignments[i].PullRequestID == prID && m.Assignments[i].ReviewerID == reviewerID {
+			return &m.Assignments[i]
+		}
+	}
+	return nil
+}
+
+// in-memory advisory-блокировки, общие для всех "реплик" в тесте
+type MockLocker struct {
+	mu     sync.Mutex
+	Locked map[int64]bool
+}
+
+func NewMockLocker() *MockLocker {
+	return &MockLocker{Locked: make(map[int64]bool)}
+}
+
+func (l *MockLocker) TryLock(_ context.Context, key int64) (func(), bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.Locked[key] {
+		return nil, false, nil
+	}
+	l.Locked[key] = true
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.Locked, key)
+	}, true, nil
+}
+
+// compile-time проверка соответствия интерфейсам
+var (
+	_ repository.StaleReviewRepo = (*MockStaleReviewRepo)(nil)
+	_ repository.Locker          = (*MockLocker)(nil)
+)
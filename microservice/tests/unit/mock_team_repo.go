@@ -2,6 +2,7 @@ package unit
 
 import (
 	"context"
+	"sort"
 
 	"review-manager/internal/repository"
 )
@@ -42,5 +43,85 @@ func (m *MockTeamRepo) GetByName(_ context.Context, teamName string) (repository
 	return t, nil
 }
 
+func (m *MockTeamRepo) SetDefaultMaxOpenReviews(_ context.Context, teamName string, limit *int) (repository.Team, error) {
+	t, ok := m.TeamsByName[teamName]
+	if !ok {
+		return repository.Team{}, repository.ErrTeamNotFound
+	}
+	t.DefaultMaxOpenReviews = limit
+	m.TeamsByName[teamName] = t
+	return t, nil
+}
+
+func (m *MockTeamRepo) SetReviewSla(_ context.Context, teamName string, slaHours, escalationHours *int) (repository.Team, error) {
+	t, ok := m.TeamsByName[teamName]
+	if !ok {
+		return repository.Team{}, repository.ErrTeamNotFound
+	}
+	t.ReviewSlaHours = slaHours
+	t.EscalationHours = escalationHours
+	m.TeamsByName[teamName] = t
+	return t, nil
+}
+
+func (m *MockTeamRepo) SetLead(_ context.Context, teamName string, leadUserID, tokenHash *string) (repository.Team, error) {
+	t, ok := m.TeamsByName[teamName]
+	if !ok {
+		return repository.Team{}, repository.ErrTeamNotFound
+	}
+	t.LeadUserID = leadUserID
+	t.LeadTokenHash = tokenHash
+	m.TeamsByName[teamName] = t
+	return t, nil
+}
+
+func (m *MockTeamRepo) SetParent(_ context.Context, teamName string, parentTeamID *int) (repository.Team, error) {
+	t, ok := m.TeamsByName[teamName]
+	if !ok {
+		return repository.Team{}, repository.ErrTeamNotFound
+	}
+	t.ParentTeamID = parentTeamID
+	t.ParentTeamName = nil
+	if parentTeamID != nil {
+		if p, ok := m.byID(*parentTeamID); ok {
+			t.ParentTeamName = &p.Name
+		}
+	}
+	m.TeamsByName[teamName] = t
+	return t, nil
+}
+
+func (m *MockTeamRepo) ListAncestors(_ context.Context, teamID int) ([]repository.Team, error) {
+	var res []repository.Team
+	t, ok := m.byID(teamID)
+	for ok && t.ParentTeamID != nil && len(res) < repository.MaxTeamDepth {
+		t, ok = m.byID(*t.ParentTeamID)
+		if ok {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+func (m *MockTeamRepo) ListChildren(_ context.Context, teamID int) ([]repository.Team, error) {
+	var res []repository.Team
+	for _, t := range m.TeamsByName {
+		if t.ParentTeamID != nil && *t.ParentTeamID == teamID {
+			res = append(res, t)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res, nil
+}
+
+func (m *MockTeamRepo) byID(id int) (repository.Team, bool) {
+	for _, t := range m.TeamsByName {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return repository.Team{}, false
+}
+
 // compile time проверка что мок реализует интерфейс TeamRepo
 var _ repository.TeamRepo = (*MockTeamRepo)(nil)
@@ -2,22 +2,36 @@ package unit
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
 
 	"review-manager/internal/repository"
 )
 
 // in-memory реализация UserRepo для тестов
 type MockUserRepo struct {
-	Users     map[string]repository.User
-	ReviewPRs map[string][]repository.PullRequestShort
-	StatsRows []repository.ReviewerStatRow
+	Users             map[string]repository.User
+	ReviewPRs         map[string][]repository.PullRequestShort
+	ArchivedReviewPRs map[string][]repository.PullRequestShort
+	StatsRows         []repository.ReviewerStatRow
+
+	// мягко удаленные пользователи, в остальных методах их не видно
+	Deleted map[string]repository.User
+
+	// пользователи, у которых сейчас идет период недоступности
+	Absent map[string]bool
 }
 
 func NewMockUserRepo() *MockUserRepo {
 	return &MockUserRepo{
-		Users:     make(map[string]repository.User),
-		ReviewPRs: make(map[string][]repository.PullRequestShort),
-		StatsRows: nil,
+		Users:             make(map[string]repository.User),
+		ReviewPRs:         make(map[string][]repository.PullRequestShort),
+		ArchivedReviewPRs: make(map[string][]repository.PullRequestShort),
+		StatsRows:         nil,
+		Deleted:           make(map[string]repository.User),
+		Absent:            make(map[string]bool),
 	}
 }
 
@@ -40,17 +54,48 @@ func (m *MockUserRepo) SetIsActive(_ context.Context, userID string, isActive bo
 	return u, nil
 }
 
-func (m *MockUserRepo) GetReviewPrs(_ context.Context, userID string) ([]repository.PullRequestShort, error) {
-	prs, ok := m.ReviewPRs[userID]
-	if !ok {
-		return nil, nil
+func (m *MockUserRepo) GetReviewPrs(_ context.Context, userID string, includeArchived bool) ([]repository.PullRequestShort, error) {
+	out := append([]repository.PullRequestShort(nil), m.ReviewPRs[userID]...)
+	if includeArchived {
+		out = append(out, m.ArchivedReviewPRs[userID]...)
 	}
-
-	out := make([]repository.PullRequestShort, len(prs))
-	copy(out, prs)
 	return out, nil
 }
 
+func (m *MockUserRepo) ListReviewPrs(_ context.Context, f repository.ReviewPrFilter) ([]repository.PullRequestShort, int, error) {
+	all := m.ReviewPRs[f.UserID]
+	if f.IncludeArchived {
+		all = append(append([]repository.PullRequestShort(nil), all...), m.ArchivedReviewPRs[f.UserID]...)
+	}
+
+	var matched []repository.PullRequestShort
+	for _, pr := range all {
+		if len(f.Statuses) == 0 || slices.Contains(f.Statuses, pr.StatusName) {
+			matched = append(matched, pr)
+		}
+	}
+
+	// как в SQL: от новых к старым, при равном времени - по id по убыванию
+	less := func(a repository.PullRequestShort, at time.Time, id string) bool {
+		return a.CreatedAt.Before(at) || (a.CreatedAt.Equal(at) && a.ID < id)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return less(matched[j], matched[i].CreatedAt, matched[i].ID)
+	})
+
+	var page []repository.PullRequestShort
+	for _, pr := range matched {
+		if f.AfterCreatedAt != nil && !less(pr, *f.AfterCreatedAt, f.AfterID) {
+			continue
+		}
+		if f.Limit > 0 && len(page) == f.Limit {
+			break
+		}
+		page = append(page, pr)
+	}
+	return page, len(matched), nil
+}
+
 func (m *MockUserRepo) FindActiveInTeamExcept(
 	_ context.Context,
 	teamID int,
@@ -68,6 +113,9 @@ func (m *MockUserRepo) FindActiveInTeamExcept(
 		if u.ID == excludeUserID {
 			continue
 		}
+		if m.Absent[u.ID] || atCapacity(u) {
+			continue
+		}
 		res = append(res, u)
 		if len(res) == limit {
 			break
@@ -76,8 +124,39 @@ func (m *MockUserRepo) FindActiveInTeamExcept(
 	return res, nil
 }
 
+func (m *MockUserRepo) FindAvailable(
+	_ context.Context,
+	userIDs []string,
+	teamNames []string,
+	excludeUserID string,
+) ([]repository.User, error) {
+	wantUser := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		wantUser[id] = true
+	}
+	wantTeam := make(map[string]bool, len(teamNames))
+	for _, name := range teamNames {
+		wantTeam[name] = true
+	}
+
+	var res []repository.User
+	for _, u := range m.Users {
+		if !wantUser[u.ID] && !wantTeam[u.TeamName] {
+			continue
+		}
+		if !u.IsActive || m.Absent[u.ID] || u.ID == excludeUserID || atCapacity(u) {
+			continue
+		}
+		res = append(res, u)
+	}
+	return res, nil
+}
+
 func (m *MockUserRepo) UpsertTeamMembers(_ context.Context, teamID int, members []repository.User) error {
 	for _, u := range members {
+		if _, ok := m.Deleted[u.ID]; ok {
+			return fmt.Errorf("%w: %s", repository.ErrUserDeleted, u.ID)
+		}
 		u.TeamID = teamID
 		m.Users[u.ID] = u
 	}
@@ -103,5 +182,59 @@ func (m *MockUserRepo) GetReviewerStats(_ context.Context) ([]repository.Reviewe
 	return out, nil
 }
 
+func (m *MockUserRepo) SetMaxOpenReviews(_ context.Context, userID string, limit *int) (repository.User, error) {
+	u, ok := m.Users[userID]
+	if !ok {
+		return repository.User{}, repository.ErrUserNotFound
+	}
+	u.MaxOpenReviews = limit
+	m.Users[userID] = u
+	return u, nil
+}
+
+func (m *MockUserRepo) CountAtCapacityInTeam(_ context.Context, teamID int, excludeUserID string) (int, error) {
+	n := 0
+	for _, u := range m.Users {
+		if u.TeamID == teamID && u.IsActive && !m.Absent[u.ID] && u.ID != excludeUserID && atCapacity(u) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (m *MockUserRepo) GetAvailability(_ context.Context, userID string) (bool, bool, error) {
+	u, ok := m.Users[userID]
+	if !ok {
+		return false, false, repository.ErrUserNotFound
+	}
+	return u.IsActive && !m.Absent[userID], !atCapacity(u), nil
+}
+
+// в моке загрузка берется из поля OpenReviews, лимит команды не учитывается
+func atCapacity(u repository.User) bool {
+	return u.MaxOpenReviews != nil && u.OpenReviews >= *u.MaxOpenReviews
+}
+
+func (m *MockUserRepo) SoftDelete(_ context.Context, userID string, _ time.Time) error {
+	u, ok := m.Users[userID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	u.IsActive = false
+	m.Deleted[userID] = u
+	delete(m.Users, userID)
+	return nil
+}
+
+func (m *MockUserRepo) Restore(_ context.Context, userID string) (repository.User, error) {
+	u, ok := m.Deleted[userID]
+	if !ok {
+		return repository.User{}, repository.ErrUserNotFound
+	}
+	delete(m.Deleted, userID)
+	m.Users[userID] = u
+	return u, nil
+}
+
 // compile-time проверка соответствия интерфейсу
 var _ repository.UserRepo = (*MockUserRepo)(nil)
@@ -0,0 +1,41 @@
+package unit
+
+import (
+	"context"
+
+	"review-manager/internal/repository"
+)
+
+// in-memory реализация WebhookRepo для тестов, ключ - название команды
+type MockWebhookRepo struct {
+	Hooks map[string]repository.TeamWebhook
+}
+
+func NewMockWebhookRepo() *MockWebhookRepo {
+	return &MockWebhookRepo{
+		Hooks: make(map[string]repository.TeamWebhook),
+	}
+}
+
+// Заполняем интерфейс
+func (m *MockWebhookRepo) Set(_ context.Context, teamName, url, kind string) (repository.TeamWebhook, error) {
+	w := repository.TeamWebhook{TeamName: teamName, URL: url, Kind: kind}
+	m.Hooks[teamName] = w
+	return w, nil
+}
+
+func (m *MockWebhookRepo) Delete(_ context.Context, teamName string) error {
+	delete(m.Hooks, teamName)
+	return nil
+}
+
+func (m *MockWebhookRepo) GetByTeamName(_ context.Context, teamName string) (repository.TeamWebhook, error) {
+	w, ok := m.Hooks[teamName]
+	if !ok {
+		return repository.TeamWebhook{}, repository.ErrWebhookNotFound
+	}
+	return w, nil
+}
+
+// compile-time проверка соответствия интерфейсу
+var _ repository.WebhookRepo = (*MockWebhookRepo)(nil)
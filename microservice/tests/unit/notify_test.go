@@ -0,0 +1,155 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/events"
+	"review-manager/internal/notify"
+	"review-manager/internal/repository"
+)
+
+// локальная заглушка webhook'а: складывает полученные JSON-тела в канал
+func newWebhookStub(t *testing.T) (*httptest.Server, chan map[string]any) {
+	t.Helper()
+
+	got := make(chan map[string]any, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload map[string]any
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("webhook получил невалидный JSON: %s", body)
+		}
+		got <- payload
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, got
+}
+
+func testPR(reviewers ...string) *dto.PullRequest {
+	return &dto.PullRequest{
+		PullRequestID:     "pr-1",
+		PullRequestName:   "Add search",
+		AuthorID:          "u1",
+		Status:            dto.PrStatusOpen,
+		AssignedReviewers: reviewers,
+		Version:           1,
+	}
+}
+
+// Проверяем, что назначение ревьювера уходит в Slack в формате block-kit
+func TestNotifier_SlackBlockKit(t *testing.T) {
+	srv, got := newWebhookStub(t)
+	hooks := NewMockWebhookRepo()
+	hooks.Hooks["backend"] = repository.TeamWebhook{TeamName: "backend", URL: srv.URL, Kind: dto.WebhookKindSlack}
+
+	n := notify.NewNotifier(events.NewBroker(10), hooks, srv.Client())
+	err := n.Handle(context.Background(), dto.Event{
+		Type:     dto.EventReviewerAssigned,
+		TeamName: "backend",
+		UserID:   "u2",
+		PR:       testPR("u2"),
+	})
+	if err != nil {
+		t.Fatalf("Handle вернул ошибку: %v", err)
+	}
+
+	payload := <-got
+	blocks, ok := payload["blocks"].([]any)
+	if !ok || len(blocks) != 3 {
+		t.Fatalf("ожидали 3 блока block-kit, получили %v", payload)
+	}
+	section := blocks[1].(map[string]any)["text"].(map[string]any)
+	if section["type"] != "mrkdwn" || !strings.Contains(section["text"].(string), "*Add search*") {
+		t.Fatalf("неверная секция сообщения: %v", section)
+	}
+}
+
+// Проверяем mattermost-вариант и уведомление о PR без ревьюверов
+func TestNotifier_MattermostStuckPR(t *testing.T) {
+	srv, got := newWebhookStub(t)
+	hooks := NewMockWebhookRepo()
+	hooks.Hooks["backend"] = repository.TeamWebhook{TeamName: "backend", URL: srv.URL, Kind: dto.WebhookKindMattermost}
+
+	n := notify.NewNotifier(events.NewBroker(10), hooks, srv.Client())
+	if err := n.Handle(context.Background(), dto.Event{Type: dto.EventPrCreated, TeamName: "backend", PR: testPR()}); err != nil {
+		t.Fatalf("Handle вернул ошибку: %v", err)
+	}
+
+	payload := <-got
+	text, _ := payload["text"].(string)
+	if _, hasBlocks := payload["blocks"]; hasBlocks || !strings.Contains(text, "no reviewers") || !strings.Contains(text, "**Add search**") {
+		t.Fatalf("ожидали markdown-сообщение mattermost о PR без ревьюверов, получили %v", payload)
+	}
+
+	// PR с ревьюверами при создании в чат не пишется, команда без webhook'а пропускается
+	_ = n.Handle(context.Background(), dto.Event{Type: dto.EventPrCreated, TeamName: "backend", PR: testPR("u2")})
+	_ = n.Handle(context.Background(), dto.Event{Type: dto.EventPrMerged, TeamName: "frontend", PR: testPR("u2")})
+	select {
+	case p := <-got:
+		t.Fatalf("лишнее уведомление: %v", p)
+	default:
+	}
+}
+
+// Проверяем, что Run получает события из брокера, опубликованные сервисом
+func TestNotifier_RunFedByBroker(t *testing.T) {
+	srv, got := newWebhookStub(t)
+	hooks := NewMockWebhookRepo()
+	hooks.Hooks["backend"] = repository.TeamWebhook{TeamName: "backend", URL: srv.URL, Kind: dto.WebhookKindSlack}
+
+	broker := events.NewBroker(10)
+	n := notify.NewNotifier(broker, hooks, srv.Client())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go n.Run(ctx)
+
+	// ждем, пока Run подпишется, и публикуем, пока уведомление не придет
+	deadline := time.After(2 * time.Second)
+	for {
+		broker.Publish(dto.Event{Type: dto.EventPrMerged, TeamName: "backend", PR: testPR("u2")})
+		select {
+		case payload := <-got:
+			if !strings.Contains(payload["text"].(string), "merged") {
+				t.Fatalf("ожидали уведомление о merge, получили %v", payload)
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("уведомление так и не пришло")
+		}
+	}
+}
+
+// Проверяем, что события команд без своего webhook'а уходят в запасной, пока он задан
+func TestNotifier_FallbackWebhook(t *testing.T) {
+	srv, got := newWebhookStub(t)
+	n := notify.NewNotifier(events.NewBroker(10), NewMockWebhookRepo(), srv.Client())
+	e := dto.Event{Type: dto.EventPrMerged, TeamName: "frontend", PR: testPR("u2")}
+
+	n.SetFallback(srv.URL, dto.WebhookKindMattermost)
+	if err := n.Handle(context.Background(), e); err != nil {
+		t.Fatalf("Handle вернул ошибку: %v", err)
+	}
+	if payload := <-got; payload["text"] == nil {
+		t.Fatalf("ожидали mattermost-сообщение в запасной webhook, получили %v", payload)
+	}
+
+	n.SetFallback("", "")
+	_ = n.Handle(context.Background(), e)
+	select {
+	case p := <-got:
+		t.Fatalf("после отключения запасного webhook'а уведомлений быть не должно: %v", p)
+	default:
+	}
+}
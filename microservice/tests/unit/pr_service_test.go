@@ -7,17 +7,24 @@ import (
 	"time"
 
 	"review-manager/internal/dto"
+	"review-manager/internal/events"
 	"review-manager/internal/repository"
 	"review-manager/internal/service"
 )
 
 func newTestPRService() (*service.PRService, *MockPrRepo, *MockUserRepo) {
+	svc, prRepo, userRepo, _ := newTestPRServiceWithOwners()
+	return svc, prRepo, userRepo
+}
+
+func newTestPRServiceWithOwners() (*service.PRService, *MockPrRepo, *MockUserRepo, *MockCodeownersRepo) {
 	prRepo := NewMockPrRepo()
 	userRepo := NewMockUserRepo()
+	ownersRepo := NewMockCodeownersRepo()
 	txMgr := &MockTxManager{}
 
-	svc := service.NewPRService(prRepo, userRepo, txMgr)
-	return svc, prRepo, userRepo
+	svc := service.NewPRService(prRepo, userRepo, NewMockTeamRepo(), ownersRepo, txMgr, events.NewBroker(100))
+	return svc, prRepo, userRepo, ownersRepo
 }
 
 // Проверяем, что при создании PR назначаются ревьюверы из команды автора,
@@ -314,3 +321,377 @@ func TestPRService_Reassign_ReviewerNotAssigned(t *testing.T) {
 		t.Fatalf("ожидали ErrReviewerNotSet, получили %v", err)
 	}
 }
+
+// Проверяем, что при создании PR с одним ревьювером выставляется need_more_reviewers
+func TestPRService_Create_MarksNeedMoreReviewers(t *testing.T) {
+	svc, prRepo, userRepo := newTestPRService()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Single", TeamID: 1, IsActive: true}
+
+	_, err := svc.Create(context.Background(), dto.CreatePrRequest{
+		PullRequestID:   "pr-one",
+		PullRequestName: "One reviewer",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Create вернул ошибку: %v", err)
+	}
+
+	pr := prRepo.PRs["pr-one"]
+	if !pr.NeedMoreReviewers {
+		t.Fatalf("ожидали need_more_reviewers=true для PR с одним ревьювером")
+	}
+	if pr.InitialReviewers != 1 {
+		t.Fatalf("ожидали initial_reviewers=1, получили %d", pr.InitialReviewers)
+	}
+}
+
+// Проверяем, что GetPrStats собирает DTO и считает долю PR без полного набора ревьюверов
+func TestPRService_GetPrStats_BuildsDTO(t *testing.T) {
+	svc, prRepo, _ := newTestPRService()
+
+	median, p90 := 3600.0, 7200.0
+	prRepo.Stats = repository.PrStatsRows{
+		ByTeam:            []repository.TeamPrStatRow{{TeamName: "backend", OpenCount: 2, MergedCount: 2}},
+		ByAuthor:          []repository.AuthorPrStatRow{{AuthorID: "u1", Username: "Alice", OpenCount: 2, MergedCount: 2}},
+		MergeMedianSec:    &median,
+		MergeP90Sec:       &p90,
+		Reassignments:     []repository.PrReassignStatRow{{PullRequestID: "pr-1", ReassignCount: 3}},
+		TotalPrs:          4,
+		UnderstaffedCount: 1,
+	}
+
+	resp, err := svc.GetPrStats(context.Background())
+	if err != nil {
+		t.Fatalf("GetPrStats вернул ошибку: %v", err)
+	}
+
+	if len(resp.ByTeam) != 1 || resp.ByTeam[0].OpenCount != 2 || resp.ByTeam[0].MergedCount != 2 {
+		t.Fatalf("статистика по командам собрана некорректно, получили %+v", resp.ByTeam)
+	}
+	if len(resp.ByAuthor) != 1 || resp.ByAuthor[0].AuthorID != "u1" {
+		t.Fatalf("статистика по авторам собрана некорректно, получили %+v", resp.ByAuthor)
+	}
+	if resp.MergeTime.MedianSeconds == nil || *resp.MergeTime.MedianSeconds != median {
+		t.Fatalf("ожидали медиану %v, получили %v", median, resp.MergeTime.MedianSeconds)
+	}
+	if len(resp.Reassignments) != 1 || resp.Reassignments[0].ReassignCount != 3 {
+		t.Fatalf("переназначения собраны некорректно, получили %+v", resp.Reassignments)
+	}
+	if resp.UnderstaffedShare != 0.25 {
+		t.Fatalf("ожидали долю 0.25, получили %v", resp.UnderstaffedShare)
+	}
+}
+
+// Проверяем, что Create пропускает кандидатов на лимите и сообщает, что назначение ограничено лимитами
+func TestPRService_Create_SkipsReviewersAtCapacity(t *testing.T) {
+	svc, _, userRepo := newTestPRService()
+
+	limit := 2
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Free", TeamID: 1, IsActive: true}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Busy", TeamID: 1, IsActive: true, MaxOpenReviews: &limit, OpenReviews: 2}
+
+	resp, err := svc.Create(context.Background(), dto.CreatePrRequest{
+		PullRequestID:   "pr-cap",
+		PullRequestName: "Capacity",
+		AuthorID:        "u1",
+	})
+	if err != nil {
+		t.Fatalf("Create вернул ошибку: %v", err)
+	}
+
+	if len(resp.PR.AssignedReviewers) != 1 || resp.PR.AssignedReviewers[0] != "u2" {
+		t.Fatalf("ожидали только ревьювера u2, получили %v", resp.PR.AssignedReviewers)
+	}
+	if !resp.LimitedByCapacity {
+		t.Fatalf("ожидали limited_by_capacity=true")
+	}
+}
+
+// Проверяем, что Reassign возвращает ErrAtCapacity, если все свободные кандидаты на лимите
+func TestPRService_Reassign_AllCandidatesAtCapacity(t *testing.T) {
+	svc, prRepo, userRepo := newTestPRService()
+
+	zero := 0
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 2, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Old", TeamID: 1, IsActive: true}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Junior", TeamID: 1, IsActive: true, MaxOpenReviews: &zero}
+
+	prRepo.PRs["pr-cap"] = repository.PullRequest{ID: "pr-cap", AuthorID: "u1", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-cap"] = []string{"u2"}
+
+	_, err := svc.Reassign(context.Background(), dto.ReassignPrRequest{PullRequestID: "pr-cap", OldUserID: "u2"})
+	if !errors.Is(err, repository.ErrAtCapacity) {
+		t.Fatalf("ожидали ErrAtCapacity, получили %v", err)
+	}
+	if !errors.Is(err, repository.ErrNoCandidate) {
+		t.Fatalf("ErrAtCapacity должна оставаться частным случаем ErrNoCandidate")
+	}
+}
+
+// Проверяем, что при наличии changed_files ревьюверами сначала становятся владельцы кода,
+// а недостающие добираются из команды автора
+func TestPRService_Create_PrefersCodeowners(t *testing.T) {
+	svc, _, userRepo, ownersRepo := newTestPRServiceWithOwners()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Teammate", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Teammate2", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["dba"] = repository.User{ID: "dba", Username: "DBA", TeamID: 2, TeamName: "dba", IsActive: true}
+
+	ownersRepo.Rules[1] = []repository.CodeownersRule{
+		{Line: 1, Pattern: "*", Owners: []string{"@u2"}},
+		{Line: 2, Pattern: "/migrations/", Owners: []string{"@team:dba"}},
+	}
+
+	resp, err := svc.Create(context.Background(), dto.CreatePrRequest{
+		PullRequestID:   "pr-owners",
+		PullRequestName: "Schema change",
+		AuthorID:        "u1",
+		ChangedFiles:    []string{"migrations/006_x.sql"},
+	})
+	if err != nil {
+		t.Fatalf("Create вернул ошибку: %v", err)
+	}
+
+	if len(resp.ReviewerReasons) != 2 {
+		t.Fatalf("ожидали 2 ревьювера, получили %+v", resp.ReviewerReasons)
+	}
+
+	first := resp.ReviewerReasons[0]
+	if first.UserID != "dba" || first.Source != dto.ReviewerSourceCodeowners || first.Rule == "" {
+		t.Fatalf("первым ожидали владельца dba по правилу строки 2, получили %+v", first)
+	}
+
+	second := resp.ReviewerReasons[1]
+	if second.Source != dto.ReviewerSourceTeam || second.UserID == "u1" || second.UserID == "dba" {
+		t.Fatalf("второго ревьювера ожидали случайно из команды автора, получили %+v", second)
+	}
+}
+
+// Проверяем, что AddReviewer добавляет ревьювера и пересчитывает need_more_reviewers
+func TestPRService_AddReviewer_RecomputesNeedMore(t *testing.T) {
+	svc, prRepo, userRepo := newTestPRService()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Alice", TeamID: 1, IsActive: true}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Extra", TeamID: 3, IsActive: true}
+
+	prRepo.PRs["pr-add"] = repository.PullRequest{ID: "pr-add", AuthorID: "u1", StatusID: repository.StatusOpen, NeedMoreReviewers: true}
+	prRepo.Reviewers["pr-add"] = []string{"u2"}
+
+	resp, err := svc.AddReviewer(context.Background(), dto.AddReviewerRequest{PullRequestID: "pr-add", UserID: "u3"})
+	if err != nil {
+		t.Fatalf("AddReviewer вернул ошибку: %v", err)
+	}
+
+	if len(resp.PR.AssignedReviewers) != 2 {
+		t.Fatalf("ожидали 2 ревьювера, получили %v", resp.PR.AssignedReviewers)
+	}
+	if resp.PR.NeedMoreReviewers {
+		t.Fatalf("ожидали need_more_reviewers=false после добавления второго ревьювера")
+	}
+}
+
+// Проверяем guard'ы AddReviewer: автор, уже назначенный, неактивный, отсутствующий пользователь и пользователь на лимите
+func TestPRService_AddReviewer_Guards(t *testing.T) {
+	svc, prRepo, userRepo := newTestPRService()
+
+	zero := 0
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Alice", TeamID: 1, IsActive: true}
+	userRepo.Users["u4"] = repository.User{ID: "u4", Username: "Sleeping", TeamID: 1, IsActive: false}
+	userRepo.Users["u5"] = repository.User{ID: "u5", Username: "Vacation", TeamID: 1, IsActive: true}
+	userRepo.Users["u6"] = repository.User{ID: "u6", Username: "Busy", TeamID: 1, IsActive: true, MaxOpenReviews: &zero}
+	userRepo.Absent["u5"] = true
+
+	prRepo.PRs["pr-g"] = repository.PullRequest{ID: "pr-g", AuthorID: "u1", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-g"] = []string{"u2"}
+
+	cases := map[string]error{
+		"u1": repository.ErrReviewerAuthor,
+		"u2": repository.ErrAlreadyReviewer,
+		"u4": repository.ErrUserInactive,
+		"u5": repository.ErrUserAbsent,
+		"u6": repository.ErrUserAtCapacity,
+		"u9": repository.ErrUserNotFound,
+	}
+	for userID, want := range cases {
+		_, err := svc.AddReviewer(context.Background(), dto.AddReviewerRequest{PullRequestID: "pr-g", UserID: userID})
+		if !errors.Is(err, want) {
+			t.Fatalf("для %s ожидали %v, получили %v", userID, want, err)
+		}
+	}
+}
+
+// Проверяем, что RemoveReviewer снимает ревьювера и выставляет need_more_reviewers
+func TestPRService_RemoveReviewer(t *testing.T) {
+	svc, prRepo, _ := newTestPRService()
+
+	prRepo.PRs["pr-rm"] = repository.PullRequest{ID: "pr-rm", AuthorID: "u1", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-rm"] = []string{"u2", "u3"}
+
+	resp, err := svc.RemoveReviewer(context.Background(), dto.RemoveReviewerRequest{PullRequestID: "pr-rm", UserID: "u2"})
+	if err != nil {
+		t.Fatalf("RemoveReviewer вернул ошибку: %v", err)
+	}
+	if len(resp.PR.AssignedReviewers) != 1 || resp.PR.AssignedReviewers[0] != "u3" {
+		t.Fatalf("ожидали оставшегося ревьювера u3, получили %v", resp.PR.AssignedReviewers)
+	}
+	if !resp.PR.NeedMoreReviewers {
+		t.Fatalf("ожидали need_more_reviewers=true после снятия ревьювера")
+	}
+
+	_, err = svc.RemoveReviewer(context.Background(), dto.RemoveReviewerRequest{PullRequestID: "pr-rm", UserID: "u2"})
+	if !errors.Is(err, repository.ErrReviewerNotSet) {
+		t.Fatalf("ожидали ErrReviewerNotSet при повторном снятии, получили %v", err)
+	}
+}
+
+// Проверяем, что Reassign с new_user_id назначает именно указанного пользователя
+func TestPRService_Reassign_Targeted(t *testing.T) {
+	svc, prRepo, userRepo := newTestPRService()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Old", TeamID: 1, IsActive: true}
+	userRepo.Users["u5"] = repository.User{ID: "u5", Username: "Chosen", TeamID: 5, IsActive: true}
+
+	prRepo.PRs["pr-t"] = repository.PullRequest{ID: "pr-t", AuthorID: "u1", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-t"] = []string{"u2"}
+
+	resp, err := svc.Reassign(context.Background(), dto.ReassignPrRequest{
+		PullRequestID: "pr-t",
+		OldUserID:     "u2",
+		NewUserID:     "u5",
+	})
+	if err != nil {
+		t.Fatalf("Reassign вернул ошибку: %v", err)
+	}
+	if resp.ReplacedBy != "u5" || prRepo.Reviewers["pr-t"][0] != "u5" {
+		t.Fatalf("ожидали замену на u5, получили replaced_by=%s reviewers=%v", resp.ReplacedBy, prRepo.Reviewers["pr-t"])
+	}
+}
+
+// Проверяем, что Reassign с new_user_id не обходит отсутствия и лимит открытых ревью
+func TestPRService_Reassign_TargetedRespectsAvailability(t *testing.T) {
+	svc, prRepo, userRepo := newTestPRService()
+
+	zero := 0
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Old", TeamID: 1, IsActive: true}
+	userRepo.Users["u5"] = repository.User{ID: "u5", Username: "Vacation", TeamID: 1, IsActive: true}
+	userRepo.Users["u6"] = repository.User{ID: "u6", Username: "Busy", TeamID: 1, IsActive: true, MaxOpenReviews: &zero}
+	userRepo.Absent["u5"] = true
+
+	prRepo.PRs["pr-a"] = repository.PullRequest{ID: "pr-a", AuthorID: "u1", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-a"] = []string{"u2"}
+
+	cases := map[string]error{
+		"u5": repository.ErrUserAbsent,
+		"u6": repository.ErrUserAtCapacity,
+	}
+	for userID, want := range cases {
+		_, err := svc.Reassign(context.Background(), dto.ReassignPrRequest{PullRequestID: "pr-a", OldUserID: "u2", NewUserID: userID})
+		if !errors.Is(err, want) {
+			t.Fatalf("для %s ожидали %v, получили %v", userID, want, err)
+		}
+	}
+	if prRepo.Reviewers["pr-a"][0] != "u2" {
+		t.Fatalf("ревьюверы не должны меняться, получили %v", prRepo.Reviewers["pr-a"])
+	}
+}
+
+// Проверяем, что Reassign с устаревшей версией из If-Match отдает ErrVersionConflict и ничего не меняет
+func TestPRService_Reassign_VersionConflict(t *testing.T) {
+	svc, prRepo, userRepo := newTestPRService()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Old", TeamID: 1, IsActive: true}
+	userRepo.Users["u5"] = repository.User{ID: "u5", Username: "New", TeamID: 1, IsActive: true}
+
+	prRepo.PRs["pr-v"] = repository.PullRequest{ID: "pr-v", AuthorID: "u1", StatusID: repository.StatusOpen, Version: 3}
+	prRepo.Reviewers["pr-v"] = []string{"u2"}
+
+	stale := int64(2)
+	_, err := svc.Reassign(context.Background(), dto.ReassignPrRequest{
+		PullRequestID:   "pr-v",
+		OldUserID:       "u2",
+		NewUserID:       "u5",
+		ExpectedVersion: &stale,
+	})
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		t.Fatalf("ожидали ErrVersionConflict, получили %v", err)
+	}
+	if prRepo.Reviewers["pr-v"][0] != "u2" {
+		t.Fatalf("при конфликте версий ревьюверы не должны меняться, получили %v", prRepo.Reviewers["pr-v"])
+	}
+
+	current := int64(3)
+	resp, err := svc.Reassign(context.Background(), dto.ReassignPrRequest{
+		PullRequestID:   "pr-v",
+		OldUserID:       "u2",
+		NewUserID:       "u5",
+		ExpectedVersion: &current,
+	})
+	if err != nil {
+		t.Fatalf("Reassign с актуальной версией вернул ошибку: %v", err)
+	}
+	if resp.PR.Version != 4 {
+		t.Fatalf("ожидали версию 4 после переназначения, получили %d", resp.PR.Version)
+	}
+}
+
+// Проверяем, что Merge увеличивает версию только при смене статуса
+func TestPRService_Merge_BumpsVersionOnce(t *testing.T) {
+	svc, prRepo, _ := newTestPRService()
+
+	prRepo.PRs["pr-m"] = repository.PullRequest{ID: "pr-m", AuthorID: "u1", StatusID: repository.StatusOpen, Version: 1}
+
+	resp, err := svc.Merge(context.Background(), dto.MergePrRequest{PullRequestID: "pr-m"})
+	if err != nil {
+		t.Fatalf("Merge вернул ошибку: %v", err)
+	}
+	if resp.PR.Version != 2 {
+		t.Fatalf("ожидали версию 2 после merge, получили %d", resp.PR.Version)
+	}
+
+	resp, err = svc.Merge(context.Background(), dto.MergePrRequest{PullRequestID: "pr-m"})
+	if err != nil {
+		t.Fatalf("повторный Merge вернул ошибку: %v", err)
+	}
+	if resp.PR.Version != 2 {
+		t.Fatalf("повторный merge не должен менять версию, получили %d", resp.PR.Version)
+	}
+}
+
+// Проверяем, что лид становится ревьювером, если в команде автора больше некого назначить
+func TestPRService_Create_FallsBackToTeamLead(t *testing.T) {
+	prRepo := NewMockPrRepo()
+	userRepo := NewMockUserRepo()
+	teamRepo := NewMockTeamRepo()
+	svc := service.NewPRService(prRepo, userRepo, teamRepo, NewMockCodeownersRepo(), &MockTxManager{}, events.NewBroker(100))
+	ctx := context.Background()
+
+	backend, _ := teamRepo.Create(ctx, "backend")
+	platform, _ := teamRepo.Create(ctx, "platform")
+	lead := "lead"
+	_, _ = teamRepo.SetLead(ctx, "platform", &lead, nil)
+	_, _ = teamRepo.SetParent(ctx, "backend", &platform.ID)
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: backend.ID, TeamName: "backend", IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Inactive", TeamID: backend.ID, TeamName: "backend", IsActive: false}
+	userRepo.Users["lead"] = repository.User{ID: "lead", Username: "Lead", TeamID: platform.ID, TeamName: "platform", IsActive: true}
+
+	resp, err := svc.Create(ctx, dto.CreatePrRequest{PullRequestID: "pr-1", PullRequestName: "Fix", AuthorID: "u1"})
+	if err != nil {
+		t.Fatalf("Create вернул ошибку: %v", err)
+	}
+	if len(resp.ReviewerReasons) != 1 || resp.ReviewerReasons[0].UserID != "lead" || resp.ReviewerReasons[0].Source != dto.ReviewerSourceLead {
+		t.Fatalf("ожидали лида родительской команды ревьювером, получили %+v", resp.ReviewerReasons)
+	}
+	if !resp.PR.NeedMoreReviewers {
+		t.Fatalf("с одним ревьювером need_more_reviewers должен быть true")
+	}
+}
@@ -0,0 +1,126 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/grpcapi/pb"
+	"review-manager/internal/httpapi"
+	"review-manager/internal/metrics"
+)
+
+func doLimited(h http.Handler, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/pullRequest/create", strings.NewReader(body))
+	req.RemoteAddr = "10.0.0.1:5000"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp dto.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("тело не dto.ErrorResponse: %q", rec.Body.String())
+	}
+	return resp.Error.Code
+}
+
+// Проверяем лимиты IP и токена: после burst приходит 429 с Retry-After, отказы видны в метриках
+func TestRateLimit_IPAndTokenBuckets(t *testing.T) {
+	reg := metrics.NewRegistry()
+	limiter := httpapi.NewRateLimit(httpapi.RateLimitConfig{IPRate: 1, IPBurst: 3, TokenRate: 1, TokenBurst: 1}, reg)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	h := limiter.Wrap(ok)
+
+	if rec := doLimited(h, "script", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("первый запрос должен пройти, получили %d", rec.Code)
+	}
+	rec := doLimited(h, "script", "")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("второй запрос с тем же токеном должен получить 429 с Retry-After, получили %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if code := errorCode(t, rec); code != dto.ErrorCodeRateLimited {
+		t.Fatalf("ожидали %s, получили %s", dto.ErrorCodeRateLimited, code)
+	}
+
+	// отказ по токену не тратит лимит IP: без токена с того же адреса проходят еще два запроса
+	for i := 0; i < 2; i++ {
+		if rec := doLimited(h, "", ""); rec.Code != http.StatusNoContent {
+			t.Fatalf("запрос %d без токена должен пройти, получили %d", i, rec.Code)
+		}
+	}
+	if rec := doLimited(h, "other", ""); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("лимит IP должен действовать и для других токенов, получили %d", rec.Code)
+	}
+
+	metricsRec := httptest.NewRecorder()
+	reg.ServeHTTP(metricsRec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`http_rate_limited_total{scope="ip"} 1`,
+		`http_rate_limited_total{scope="token"} 1`,
+		`http_rate_limit_burst{scope="ip"} 3`,
+	} {
+		if !strings.Contains(metricsRec.Body.String(), want) {
+			t.Fatalf("в метриках нет %q:\n%s", want, metricsRec.Body.String())
+		}
+	}
+}
+
+// Проверяем, что слишком большое тело отклоняется с 413 и кодом BODY_TOO_LARGE
+func TestRateLimit_MaxBodyBytes(t *testing.T) {
+	reg := metrics.NewRegistry()
+	limiter := httpapi.NewRateLimit(httpapi.RateLimitConfig{MaxBodyBytes: 64}, reg)
+	h := limiter.Wrap(httpapi.NewMux(httpapi.NewHandler(nil, nil, nil, nil, nil, nil, nil, nil, "secret")))
+
+	body := `{"pull_request_id":"` + strings.Repeat("x", 100) + `"}`
+	rec := doLimited(h, "", body)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("ожидали 413, получили %d", rec.Code)
+	}
+	if code := errorCode(t, rec); code != dto.ErrorCodeBodyTooLarge {
+		t.Fatalf("ожидали %s, получили %s", dto.ErrorCodeBodyTooLarge, code)
+	}
+
+	metricsRec := httptest.NewRecorder()
+	reg.ServeHTTP(metricsRec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(metricsRec.Body.String(), "http_request_body_too_large_total 1") {
+		t.Fatalf("отказ по размеру тела не попал в метрики:\n%s", metricsRec.Body.String())
+	}
+}
+
+// Проверяем, что gRPC берет токены из тех же корзин, что и HTTP, и отказывает с ResourceExhausted
+func TestRateLimit_GRPCSharesBuckets(t *testing.T) {
+	reg := metrics.NewRegistry()
+	limiter := httpapi.NewRateLimit(httpapi.RateLimitConfig{TokenRate: 1, TokenBurst: 1}, reg)
+
+	// корзину токена уже опустошил HTTP-запрос
+	if _, ok := limiter.Allow("10.0.0.1", "ci-token"); !ok {
+		t.Fatalf("первый запрос по токену должен пройти")
+	}
+
+	conn, _, _ := newTestGRPCClient(t, limiter)
+	client := pb.NewStatsServiceClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer ci-token")
+	_, err := client.ReviewerStats(ctx, &pb.ReviewerStatsRequest{})
+	if status.Code(err) != codes.ResourceExhausted || errorReason(err) != dto.ErrorCodeRateLimited {
+		t.Fatalf("ожидали ResourceExhausted с RATE_LIMITED, получили %v", err)
+	}
+
+	if _, err := client.ReviewerStats(context.Background(), &pb.ReviewerStatsRequest{}); err != nil {
+		t.Fatalf("без токена лимит токена не действует, получили %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package unit
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/events"
+	"review-manager/internal/repository"
+	"review-manager/internal/service"
+)
+
+// PR pr-1 с выключенным u2 и ушедшим во frontend u3, в backend свободны u4 и u5
+func newTestReconcileJob() (*service.ReconcileJob, *MockReconcileRepo, *MockLocker, *MockPrRepo) {
+	prRepo := NewMockPrRepo()
+	userRepo := NewMockUserRepo()
+	prSvc := service.NewPRService(prRepo, userRepo, NewMockTeamRepo(), NewMockCodeownersRepo(), &MockTxManager{}, events.NewBroker(100))
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Inactive", TeamID: 1, TeamName: "backend", IsActive: false}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Moved", TeamID: 2, TeamName: "frontend", IsActive: true}
+	userRepo.Users["u4"] = repository.User{ID: "u4", Username: "Free1", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["u5"] = repository.User{ID: "u5", Username: "Free2", TeamID: 1, TeamName: "backend", IsActive: true}
+
+	prRepo.PRs["pr-1"] = repository.PullRequest{ID: "pr-1", AuthorID: "u1", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-1"] = []string{"u2", "u3"}
+
+	repo := &MockReconcileRepo{Broken: []repository.BrokenPr{{
+		PullRequestID: "pr-1",
+		TeamName:      "backend",
+		Reviewers:     []string{"u2", "u3"},
+		Inactive:      []string{"u2"},
+		Moved:         []string{"u3"},
+	}}}
+	locker := NewMockLocker()
+
+	return service.NewReconcileJob(repo, locker, prSvc), repo, locker, prRepo
+}
+
+// Проверяем, что dry_run описывает ремонт, но ничего не меняет
+func TestReconcileJob_DryRunDoesNotChange(t *testing.T) {
+	job, _, _, prRepo := newTestReconcileJob()
+
+	report, err := job.RunOnce(context.Background(), true)
+	if err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+	if !report.DryRun || len(report.PullRequests) != 1 {
+		t.Fatalf("ожидали отчет dry_run по одному PR, получили %+v", report)
+	}
+
+	item := report.PullRequests[0]
+	if !slices.Equal(item.Issues, []string{dto.ReconcileIssueInactive, dto.ReconcileIssueMovedTeam}) {
+		t.Fatalf("неверный список проблем: %v", item.Issues)
+	}
+	want := []dto.ReconcileAction{
+		{Action: dto.ReconcileActionReassigned, ReviewerID: "u2"},
+		{Action: dto.ReconcileActionRemoved, ReviewerID: "u3"},
+		{Action: dto.ReconcileActionAdded},
+	}
+	if !slices.Equal(item.Actions, want) {
+		t.Fatalf("ожидали план %+v, получили %+v", want, item.Actions)
+	}
+	if !slices.Equal(prRepo.Reviewers["pr-1"], []string{"u2", "u3"}) {
+		t.Fatalf("dry_run не должен менять ревьюверов, получили %v", prRepo.Reviewers["pr-1"])
+	}
+}
+
+// Проверяем ремонт: выключенного переназначаем, ушедшего снимаем и добираем из команды автора
+func TestReconcileJob_RepairsPR(t *testing.T) {
+	job, _, _, prRepo := newTestReconcileJob()
+
+	report, err := job.RunOnce(context.Background(), false)
+	if err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+
+	item := report.PullRequests[0]
+	if len(item.Actions) != 3 || item.StillUnderstaffed {
+		t.Fatalf("ожидали три действия и полный состав, получили %+v", item)
+	}
+	if a := item.Actions[0]; a.Action != dto.ReconcileActionReassigned || a.ReviewerID != "u2" || a.ReplacedBy == "" {
+		t.Fatalf("ожидали переназначение u2, получили %+v", a)
+	}
+	if a := item.Actions[1]; a.Action != dto.ReconcileActionRemoved || a.ReviewerID != "u3" {
+		t.Fatalf("ожидали снятие u3, получили %+v", a)
+	}
+	if a := item.Actions[2]; a.Action != dto.ReconcileActionAdded || a.ReviewerID == "" {
+		t.Fatalf("ожидали добавление ревьювера, получили %+v", a)
+	}
+
+	got := slices.Clone(prRepo.Reviewers["pr-1"])
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"u4", "u5"}) {
+		t.Fatalf("ожидали ревьюверов u4 и u5, получили %v", got)
+	}
+	if prRepo.PRs["pr-1"].NeedMoreReviewers {
+		t.Fatalf("need_more_reviewers должен сброситься после ремонта")
+	}
+}
+
+// Проверяем, что без кандидатов PR остается с пометкой still_understaffed,
+// а прогон, пока блокировку держит другая реплика, пропускается
+func TestReconcileJob_NoCandidatesAndSkipped(t *testing.T) {
+	job, repo, locker, prRepo := newTestReconcileJob()
+
+	prRepo.PRs["pr-2"] = repository.PullRequest{ID: "pr-2", AuthorID: "u3", StatusID: repository.StatusOpen}
+	repo.Broken = []repository.BrokenPr{{PullRequestID: "pr-2", TeamName: "frontend"}}
+
+	report, err := job.RunOnce(context.Background(), false)
+	if err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+	item := report.PullRequests[0]
+	if !item.StillUnderstaffed || len(item.Actions) != 0 || !slices.Equal(item.Issues, []string{dto.ReconcileIssueUnderstaffed}) {
+		t.Fatalf("ожидали нехватку ревьюверов без действий, получили %+v", item)
+	}
+
+	locker.Locked[repository.LockKeyReconcile] = true
+	report, err = job.RunOnce(context.Background(), false)
+	if err != nil || !report.Skipped {
+		t.Fatalf("ожидали пропуск прогона, получили %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,139 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"review-manager/internal/repository"
+)
+
+// пул без подключения: pgxpool не соединяется, пока из него не читают
+func newLazyPool(t *testing.T, host string) *pgxpool.Pool {
+	t.Helper()
+	pool, err := pgxpool.New(context.Background(), "postgres://review@"+host+":5432/review")
+	if err != nil {
+		t.Fatalf("не получилось создать пул %s: %v", host, err)
+	}
+	t.Cleanup(pool.Close)
+	return pool
+}
+
+// роутер с основной БД и репликами r1, r2, r3; probes - что вернет замер по имени реплики
+func newTestReadRouter(
+	t *testing.T,
+	maxLag time.Duration,
+	probes map[string]repository.ReplicaProbe,
+	errs map[string]error,
+) (*repository.ReadRouter, *pgxpool.Pool, []*pgxpool.Pool) {
+	t.Helper()
+	primary := newLazyPool(t, "primary")
+	replicas := []*pgxpool.Pool{newLazyPool(t, "r1"), newLazyPool(t, "r2"), newLazyPool(t, "r3")}
+
+	r := repository.NewReadRouter(primary, replicas, maxLag)
+	r.Probe = func(_ context.Context, pool *pgxpool.Pool) (repository.ReplicaProbe, error) {
+		host := pool.Config().ConnConfig.Host
+		return probes[host], errs[host]
+	}
+	return r, primary, replicas
+}
+
+func streaming(lag time.Duration) repository.ReplicaProbe {
+	return repository.ReplicaProbe{InRecovery: true, Streaming: true, Lag: lag}
+}
+
+// Проверяем, что чтения идут по кругу только по здоровым репликам
+func TestReadRouter_RoundRobinSkipsUnhealthy(t *testing.T) {
+	r, primary, replicas := newTestReadRouter(t, time.Minute,
+		map[string]repository.ReplicaProbe{"r1": streaming(0), "r3": streaming(time.Second)},
+		map[string]error{"r2": errors.New("connection refused")},
+	)
+	r.CheckReplicas(context.Background())
+
+	seen := map[*pgxpool.Pool]int{}
+	for range 6 {
+		seen[r.ReadPool(context.Background(), primary)]++
+	}
+	if seen[replicas[0]] != 3 || seen[replicas[2]] != 3 || len(seen) != 2 {
+		t.Fatalf("ожидали по 3 чтения с r1 и r3, получили %v", seen)
+	}
+}
+
+// Проверяем, что без здоровых реплик, до первой проверки и с WithPrimary читаем с основной БД
+func TestReadRouter_FallsBackToPrimary(t *testing.T) {
+	r, primary, _ := newTestReadRouter(t, time.Minute,
+		map[string]repository.ReplicaProbe{"r1": streaming(0), "r2": streaming(0), "r3": streaming(0)}, nil,
+	)
+	ctx := context.Background()
+
+	if got := r.ReadPool(ctx, primary); got != primary {
+		t.Fatalf("до первой проверки реплики не должны быть в ротации")
+	}
+
+	r.CheckReplicas(ctx)
+	if got := r.ReadPool(ctx, primary); got == primary {
+		t.Fatalf("после проверки ожидали чтение с реплики")
+	}
+	if got := r.ReadPool(repository.WithPrimary(ctx), primary); got != primary {
+		t.Fatalf("с WithPrimary ожидали основную БД")
+	}
+
+	r.Probe = func(context.Context, *pgxpool.Pool) (repository.ReplicaProbe, error) {
+		return repository.ReplicaProbe{}, errors.New("timeout")
+	}
+	r.CheckReplicas(ctx)
+	if got := r.ReadPool(ctx, primary); got != primary {
+		t.Fatalf("когда все реплики упали, ожидали основную БД")
+	}
+
+	var nilRouter *repository.ReadRouter
+	if got := nilRouter.ReadPool(ctx, primary); got != primary {
+		t.Fatalf("нулевой роутер должен отдавать основную БД")
+	}
+}
+
+// Проверяем, как замер реплики переводится в ротацию: отставание, ошибка, отключенный WAL receiver
+func TestReadRouter_CheckReplicasHealth(t *testing.T) {
+	cases := []struct {
+		name    string
+		maxLag  time.Duration
+		probe   repository.ReplicaProbe
+		err     error
+		healthy bool
+		wantErr error
+	}{
+		{name: "отставание в пределах", maxLag: time.Minute, probe: streaming(30 * time.Second), healthy: true},
+		{name: "отставание больше предела", maxLag: time.Minute, probe: streaming(2 * time.Minute), healthy: false},
+		{name: "предел не задан", maxLag: 0, probe: streaming(time.Hour), healthy: true},
+		{name: "ошибка запроса", maxLag: time.Minute, err: errors.New("connection refused"), healthy: false},
+		{
+			name: "receiver отключен при нулевом отставании", maxLag: time.Minute,
+			probe: repository.ReplicaProbe{InRecovery: true}, wantErr: repository.ErrReplicaNotStreaming,
+		},
+		{
+			name: "receiver отключен без предела", maxLag: 0,
+			probe: repository.ReplicaProbe{InRecovery: true}, wantErr: repository.ErrReplicaNotStreaming,
+		},
+		{name: "не в recovery", maxLag: time.Minute, probe: repository.ReplicaProbe{}, healthy: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _, _ := newTestReadRouter(t, tc.maxLag,
+				map[string]repository.ReplicaProbe{"r1": tc.probe}, map[string]error{"r1": tc.err},
+			)
+			r.CheckReplicas(context.Background())
+
+			st := r.Replicas()[0]
+			if st.Healthy != tc.healthy {
+				t.Fatalf("ожидали healthy=%v, получили %+v", tc.healthy, st)
+			}
+			if tc.wantErr != nil && !errors.Is(st.Err, tc.wantErr) {
+				t.Fatalf("ожидали ошибку %v, получили %v", tc.wantErr, st.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,298 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/reviewctl"
+)
+
+// Проверяем выбор кода выхода: сначала по коду ошибки из тела, без кода - по HTTP-статусу
+func TestReviewctl_ExitCode(t *testing.T) {
+	cases := []struct {
+		status int
+		code   string
+		want   int
+	}{
+		{http.StatusBadRequest, dto.ErrorCodeValidationFailed, reviewctl.ExitUsage},
+		{http.StatusUnauthorized, dto.ErrorCodeUnauthorized, reviewctl.ExitAuth},
+		{http.StatusNotFound, dto.ErrorCodeNotFound, reviewctl.ExitNotFound},
+		{http.StatusConflict, dto.ErrorCodeTeamExists, reviewctl.ExitExists},
+		{http.StatusConflict, dto.ErrorCodePRExists, reviewctl.ExitExists},
+		{http.StatusConflict, dto.ErrorCodePRMerged, reviewctl.ExitPRMerged},
+		{http.StatusConflict, dto.ErrorCodeNotAssigned, reviewctl.ExitNotAssigned},
+		{http.StatusConflict, dto.ErrorCodeNoCandidate, reviewctl.ExitNoCandidate},
+		{http.StatusConflict, dto.ErrorCodeAtCapacity, reviewctl.ExitNoCandidate},
+		{http.StatusPreconditionFailed, dto.ErrorCodeVersionConflict, reviewctl.ExitConflict},
+		{http.StatusConflict, dto.ErrorCodeAlreadyAssigned, reviewctl.ExitCannotReview},
+		{http.StatusConflict, dto.ErrorCodeReviewerIsAuthor, reviewctl.ExitCannotReview},
+		{http.StatusConflict, dto.ErrorCodeUserInactive, reviewctl.ExitCannotReview},
+		{http.StatusConflict, dto.ErrorCodeUserAbsent, reviewctl.ExitCannotReview},
+		{http.StatusInternalServerError, dto.ErrorCodeInternal, reviewctl.ExitFailure},
+		{http.StatusBadRequest, "HTTP_ERROR", reviewctl.ExitUsage},
+		{http.StatusUnauthorized, "HTTP_ERROR", reviewctl.ExitAuth},
+		{http.StatusNotFound, "HTTP_ERROR", reviewctl.ExitNotFound},
+		{http.StatusBadGateway, "HTTP_ERROR", reviewctl.ExitFailure},
+	}
+
+	for _, c := range cases {
+		err := &reviewctl.APIError{Status: c.status, Code: c.code}
+		if got := reviewctl.ExitCodeFor(err); got != c.want {
+			t.Fatalf("%d %s: ожидали код выхода %d, получили %d", c.status, c.code, c.want, got)
+		}
+	}
+}
+
+// чистое окружение: без переменных reviewctl и с пустым каталогом конфигов вместо ~/.config
+func isolateReviewctlEnv(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	for _, name := range []string{"REVIEWCTL_CONFIG", "REVIEWCTL_URL", "REVIEWCTL_TOKEN", "REVIEWCTL_OUTPUT", "ADMIN_TOKEN"} {
+		t.Setenv(name, "")
+	}
+	return dir
+}
+
+func writeReviewctlConfig(t *testing.T, dir, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, "reviewctl.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("не удалось записать конфиг: %v", err)
+	}
+	return path
+}
+
+// Проверяем приоритет настроек в LoadConfig: переменные окружения поверх файла, файл поверх умолчаний
+func TestReviewctl_LoadConfigPrecedence(t *testing.T) {
+	cases := []struct {
+		name string
+		file string
+		env  map[string]string
+		want reviewctl.Config
+	}{
+		{
+			name: "умолчания",
+			want: reviewctl.Config{URL: reviewctl.DefaultURL, Output: reviewctl.OutputTable},
+		},
+		{
+			name: "файл",
+			file: "url: http://file\ntoken: file-token\noutput: json\n",
+			want: reviewctl.Config{URL: "http://file", Token: "file-token", Output: reviewctl.OutputJSON},
+		},
+		{
+			name: "окружение поверх файла",
+			file: "url: http://file\ntoken: file-token\n",
+			env:  map[string]string{"REVIEWCTL_URL": "http://env", "REVIEWCTL_TOKEN": "env-token", "REVIEWCTL_OUTPUT": "json"},
+			want: reviewctl.Config{URL: "http://env", Token: "env-token", Output: reviewctl.OutputJSON},
+		},
+		{
+			name: "ADMIN_TOKEN не перебивает токен из файла",
+			file: "token: file-token\n",
+			env:  map[string]string{"ADMIN_TOKEN": "admin"},
+			want: reviewctl.Config{URL: reviewctl.DefaultURL, Token: "file-token", Output: reviewctl.OutputTable},
+		},
+		{
+			name: "ADMIN_TOKEN, если токена больше нигде нет",
+			env:  map[string]string{"ADMIN_TOKEN": "admin"},
+			want: reviewctl.Config{URL: reviewctl.DefaultURL, Token: "admin", Output: reviewctl.OutputTable},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := isolateReviewctlEnv(t)
+			path := ""
+			if c.file != "" {
+				path = writeReviewctlConfig(t, dir, c.file)
+			}
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+
+			got, err := reviewctl.LoadConfig(path)
+			if err != nil {
+				t.Fatalf("LoadConfig вернул ошибку: %v", err)
+			}
+			if got != c.want {
+				t.Fatalf("ожидали %+v, получили %+v", c.want, got)
+			}
+		})
+	}
+
+	isolateReviewctlEnv(t)
+	if _, err := reviewctl.LoadConfig(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Fatalf("явно указанный, но отсутствующий конфиг должен давать ошибку")
+	}
+}
+
+// запрос, который дошел до тестового сервера
+type reviewctlRequest struct {
+	method  string
+	path    string
+	query   string
+	ifMatch string
+	auth    string
+	body    map[string]any
+}
+
+func newReviewctlServer(t *testing.T) (*httptest.Server, *[]reviewctlRequest) {
+	t.Helper()
+	var seen []reviewctlRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := reviewctlRequest{
+			method:  r.Method,
+			path:    r.URL.Path,
+			query:   r.URL.RawQuery,
+			ifMatch: r.Header.Get("If-Match"),
+			auth:    r.Header.Get("Authorization"),
+		}
+		if data, _ := io.ReadAll(r.Body); len(data) > 0 {
+			_ = json.Unmarshal(data, &req.body)
+		}
+		seen = append(seen, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &seen
+}
+
+// Проверяем приоритет флагов: -url и -token перебивают окружение и файл
+func TestReviewctl_FlagsOverrideEnvAndFile(t *testing.T) {
+	dir := isolateReviewctlEnv(t)
+	srv, seen := newReviewctlServer(t)
+
+	path := writeReviewctlConfig(t, dir, "url: http://127.0.0.1:1\ntoken: file-token\n")
+	t.Setenv("REVIEWCTL_TOKEN", "env-token")
+
+	var stdout, stderr bytes.Buffer
+	code := reviewctl.Run([]string{"-config", path, "-url", srv.URL, "-token", "flag-token", "-o", "json", "stats", "reviewers"}, &stdout, &stderr)
+	if code != reviewctl.ExitOK {
+		t.Fatalf("ожидали код 0, получили %d: %s", code, stderr.String())
+	}
+	if len(*seen) != 1 || (*seen)[0].auth != "Bearer flag-token" {
+		t.Fatalf("ожидали запрос с токеном из флага, получили %+v", *seen)
+	}
+
+	*seen = nil
+	code = reviewctl.Run([]string{"-config", path, "-url", srv.URL, "-o", "json", "stats", "reviewers"}, &stdout, &stderr)
+	if code != reviewctl.ExitOK || len(*seen) != 1 || (*seen)[0].auth != "Bearer env-token" {
+		t.Fatalf("без -token ожидали токен из окружения, получили код %d, %+v", code, *seen)
+	}
+}
+
+// Проверяем разбор аргументов каждой команды: какой запрос уходит на сервер, а при ошибке - код выхода 2 без запроса
+func TestReviewctl_Subcommands(t *testing.T) {
+	cases := []struct {
+		args []string
+		want *reviewctlRequest
+	}{
+		{
+			args: []string{"team", "add", "-name", "backend", "-member", "u1:Alice", "-member", "u2:Bob:inactive"},
+			want: &reviewctlRequest{method: http.MethodPost, path: "/team/add", body: map[string]any{
+				"team_name": "backend",
+				"members": []any{
+					map[string]any{"user_id": "u1", "username": "Alice", "is_active": true},
+					map[string]any{"user_id": "u2", "username": "Bob", "is_active": false},
+				},
+			}},
+		},
+		{args: []string{"team", "add", "-name", "backend", "-member", "u1"}},
+		{args: []string{"team", "add", "-member", "u1:Alice"}},
+		{
+			args: []string{"team", "get", "-name", "backend"},
+			want: &reviewctlRequest{method: http.MethodGet, path: "/team/get", query: "team_name=backend"},
+		},
+		{args: []string{"team", "get"}},
+		{
+			args: []string{"users", "set-active", "-id", "u1", "-active=false"},
+			want: &reviewctlRequest{method: http.MethodPost, path: "/users/setIsActive", body: map[string]any{"user_id": "u1", "is_active": false}},
+		},
+		{args: []string{"users", "set-active", "-active=false"}},
+		{
+			args: []string{"users", "reviews", "-id", "u1", "-status", "OPEN", "-limit", "10", "-cursor", "c1"},
+			want: &reviewctlRequest{method: http.MethodGet, path: "/users/getReview", query: "cursor=c1&limit=10&status=OPEN&user_id=u1"},
+		},
+		{args: []string{"users", "reviews", "-id", "u1", "-limit", "many"}},
+		{
+			args: []string{"pr", "create", "-id", "pr-1", "-name", "Add search", "-author", "u1", "-file", "a.go", "-file", "b.go"},
+			want: &reviewctlRequest{method: http.MethodPost, path: "/pullRequest/create", body: map[string]any{
+				"pull_request_id":   "pr-1",
+				"pull_request_name": "Add search",
+				"author_id":         "u1",
+				"changed_files":     []any{"a.go", "b.go"},
+			}},
+		},
+		{args: []string{"pr", "create", "-id", "pr-1", "-name", "Add search"}},
+		{
+			args: []string{"pr", "merge", "-id", "pr-1", "-version", "3"},
+			want: &reviewctlRequest{method: http.MethodPost, path: "/pullRequest/merge", ifMatch: `"3"`, body: map[string]any{"pull_request_id": "pr-1"}},
+		},
+		{args: []string{"pr", "merge", "-id", "pr-1", "extra"}},
+		{
+			args: []string{"pr", "reassign", "-id", "pr-1", "-old", "u2", "-new", "u3"},
+			want: &reviewctlRequest{method: http.MethodPost, path: "/pullRequest/reassign", body: map[string]any{
+				"pull_request_id": "pr-1",
+				"old_user_id":     "u2",
+				"new_user_id":     "u3",
+			}},
+		},
+		{args: []string{"pr", "reassign", "-id", "pr-1"}},
+		{
+			args: []string{"pr", "add-reviewer", "-id", "pr-1", "-user", "u3", "-version", "2"},
+			want: &reviewctlRequest{method: http.MethodPost, path: "/pullRequest/addReviewer", ifMatch: `"2"`, body: map[string]any{"pull_request_id": "pr-1", "user_id": "u3"}},
+		},
+		{
+			args: []string{"pr", "remove-reviewer", "-id", "pr-1", "-user", "u3"},
+			want: &reviewctlRequest{method: http.MethodPost, path: "/pullRequest/removeReviewer", body: map[string]any{"pull_request_id": "pr-1", "user_id": "u3"}},
+		},
+		{args: []string{"pr", "remove-reviewer", "-user", "u3"}},
+		{
+			args: []string{"stats", "reviewers"},
+			want: &reviewctlRequest{method: http.MethodGet, path: "/stats/reviewers"},
+		},
+		{
+			args: []string{"stats", "prs"},
+			want: &reviewctlRequest{method: http.MethodGet, path: "/stats/prs"},
+		},
+		{args: []string{"stats", "prs", "-verbose"}},
+		{args: []string{"stats", "unknown"}},
+		{args: []string{"stats"}},
+	}
+
+	isolateReviewctlEnv(t)
+	for _, c := range cases {
+		srv, seen := newReviewctlServer(t)
+
+		var stdout, stderr bytes.Buffer
+		code := reviewctl.Run(append([]string{"-url", srv.URL, "-o", "json"}, c.args...), &stdout, &stderr)
+
+		if c.want == nil {
+			if code != reviewctl.ExitUsage || len(*seen) != 0 {
+				t.Fatalf("%v: ожидали код %d без запроса, получили %d и %d запросов", c.args, reviewctl.ExitUsage, code, len(*seen))
+			}
+			continue
+		}
+
+		if code != reviewctl.ExitOK {
+			t.Fatalf("%v: ожидали код 0, получили %d: %s", c.args, code, stderr.String())
+		}
+		if len(*seen) != 1 {
+			t.Fatalf("%v: ожидали один запрос, получили %d", c.args, len(*seen))
+		}
+		if got := (*seen)[0]; !reflect.DeepEqual(got, *c.want) {
+			t.Fatalf("%v: ожидали запрос %+v, получили %+v", c.args, *c.want, got)
+		}
+	}
+}
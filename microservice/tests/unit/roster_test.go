@@ -0,0 +1,76 @@
+package unit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"review-manager/internal/roster"
+	"review-manager/internal/validation"
+)
+
+// Проверяем, что CSV разбирается с номерами строк, а is_active по умолчанию true
+func TestRoster_ParseCSV(t *testing.T) {
+	in := "team,user_id,username,is_active\n" +
+		"backend,u1,Misha,true\n" +
+		"backend,u2,Dima,\n"
+
+	entries, err := roster.Parse(strings.NewReader(in), roster.FormatCSV)
+	if err != nil {
+		t.Fatalf("Parse вернул ошибку: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ожидали 2 записи, получили %d", len(entries))
+	}
+	if entries[1].Line != 3 || !entries[1].IsActive {
+		t.Fatalf("вторая запись разобрана некорректно, получили %+v", entries[1])
+	}
+}
+
+// Проверяем, что YAML разбирается и номера строк указывают на начало записи
+func TestRoster_ParseYAML(t *testing.T) {
+	in := "- team: backend\n" +
+		"  user_id: u1\n" +
+		"  username: Misha\n" +
+		"- team: frontend\n" +
+		"  user_id: u2\n" +
+		"  username: Olya\n" +
+		"  is_active: false\n"
+
+	entries, err := roster.Parse(strings.NewReader(in), roster.FormatYAML)
+	if err != nil {
+		t.Fatalf("Parse вернул ошибку: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("ожидали 2 записи, получили %d", len(entries))
+	}
+	if entries[1].Line != 4 || entries[1].IsActive {
+		t.Fatalf("вторая запись разобрана некорректно, получили %+v", entries[1])
+	}
+}
+
+// Проверяем, что валидация возвращает все ошибки файла сразу с номерами строк
+func TestRoster_ValidateCollectsAllLineErrors(t *testing.T) {
+	in := "team,user_id,username,is_active\n" +
+		"backend,u1,Misha,true\n" +
+		",u2,Dima,true\n" +
+		"backend,u1,Copy,true\n"
+
+	entries, err := roster.Parse(strings.NewReader(in), roster.FormatCSV)
+	if err != nil {
+		t.Fatalf("Parse вернул ошибку: %v", err)
+	}
+
+	err = validation.ValidateRoster(entries)
+
+	var lineErrs validation.LineErrors
+	if !errors.As(err, &lineErrs) {
+		t.Fatalf("ожидали LineErrors, получили %v", err)
+	}
+	if len(lineErrs) != 2 {
+		t.Fatalf("ожидали 2 ошибки, получили %d: %v", len(lineErrs), err)
+	}
+	if lineErrs[0].Line != 3 || lineErrs[1].Line != 4 {
+		t.Fatalf("ожидали ошибки в строках 3 и 4, получили %v", err)
+	}
+}
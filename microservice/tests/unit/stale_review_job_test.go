@@ -0,0 +1,141 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"review-manager/internal/dto"
+	"review-manager/internal/events"
+	"review-manager/internal/repository"
+	"review-manager/internal/service"
+)
+
+func newTestStaleReviewJob() (*service.StaleReviewJob, *MockStaleReviewRepo, *MockLocker, *MockPrRepo, *MockUserRepo, *events.Broker) {
+	prRepo := NewMockPrRepo()
+	userRepo := NewMockUserRepo()
+	broker := events.NewBroker(100)
+	prSvc := service.NewPRService(prRepo, userRepo, NewMockTeamRepo(), NewMockCodeownersRepo(), &MockTxManager{}, broker)
+
+	staleRepo := NewMockStaleReviewRepo()
+	locker := NewMockLocker()
+	job := service.NewStaleReviewJob(staleRepo, locker, prSvc, 48*time.Hour, 96*time.Hour)
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Slow", TeamID: 1, TeamName: "backend", IsActive: true}
+
+	return job, staleRepo, locker, prRepo, userRepo, broker
+}
+
+func addStaleAssignment(staleRepo *MockStaleReviewRepo, prRepo *MockPrRepo, prID string, age time.Duration) {
+	now := time.Now().UTC()
+	prRepo.PRs[prID] = repository.PullRequest{ID: prID, AuthorID: "u1", StatusID: repository.StatusOpen, CreatedAt: now.Add(-age)}
+	prRepo.Reviewers[prID] = []string{"u2"}
+	staleRepo.Assignments = append(staleRepo.Assignments, repository.StaleAssignment{
+		PullRequestID: prID,
+		ReviewerID:    "u2",
+		TeamName:      "backend",
+		AssignedAt:    now.Add(-age),
+		SLA:           48 * time.Hour,
+		EscalateAfter: 96 * time.Hour,
+	})
+}
+
+// Проверяем, что после SLA ревьюверу напоминают ровно один раз
+func TestStaleReviewJob_RemindsOnce(t *testing.T) {
+	job, staleRepo, _, prRepo, _, broker := newTestStaleReviewJob()
+	addStaleAssignment(staleRepo, prRepo, "pr-1", 50*time.Hour)
+
+	_, sub := broker.Subscribe(0, events.Filter{})
+	defer sub.Close()
+
+	report, err := job.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+	if len(report.Actions) != 1 || report.Actions[0].Action != dto.StaleActionReminded {
+		t.Fatalf("ожидали одно напоминание, получили %+v", report.Actions)
+	}
+
+	e := <-sub.C
+	if e.Type != dto.EventReviewReminder || e.UserID != "u2" || e.PR == nil || e.TeamName != "backend" {
+		t.Fatalf("ожидали событие напоминания для u2, получили %+v", e)
+	}
+
+	report, _ = job.RunOnce(context.Background())
+	if len(report.Actions) != 0 {
+		t.Fatalf("повторный прогон не должен напоминать снова, получили %+v", report.Actions)
+	}
+}
+
+// Проверяем, что после порога эскалации ревью переназначается на свободного участника команды
+func TestStaleReviewJob_ReassignsAfterEscalationThreshold(t *testing.T) {
+	job, staleRepo, _, prRepo, userRepo, _ := newTestStaleReviewJob()
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Backup", TeamID: 1, TeamName: "backend", IsActive: true}
+	addStaleAssignment(staleRepo, prRepo, "pr-1", 100*time.Hour)
+
+	report, err := job.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+	if len(report.Actions) != 1 || report.Actions[0].Action != dto.StaleActionReassigned || report.Actions[0].ReplacedBy != "u3" {
+		t.Fatalf("ожидали переназначение на u3, получили %+v", report.Actions)
+	}
+	if got := prRepo.Reviewers["pr-1"]; len(got) != 1 || got[0] != "u3" {
+		t.Fatalf("ожидали ревьювера u3, получили %v", got)
+	}
+}
+
+// Проверяем, что без кандидатов ревью эскалируется один раз, а ревьювер остается на PR
+func TestStaleReviewJob_EscalatesWhenNoCandidate(t *testing.T) {
+	job, staleRepo, _, prRepo, _, broker := newTestStaleReviewJob()
+	addStaleAssignment(staleRepo, prRepo, "pr-1", 100*time.Hour)
+
+	_, sub := broker.Subscribe(0, events.Filter{})
+	defer sub.Close()
+
+	report, err := job.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce вернул ошибку: %v", err)
+	}
+	if len(report.Actions) != 1 || report.Actions[0].Action != dto.StaleActionEscalated {
+		t.Fatalf("ожидали эскалацию, получили %+v", report.Actions)
+	}
+	if e := <-sub.C; e.Type != dto.EventReviewEscalated {
+		t.Fatalf("ожидали событие эскалации, получили %+v", e)
+	}
+	if got := prRepo.Reviewers["pr-1"]; len(got) != 1 || got[0] != "u2" {
+		t.Fatalf("ревьювер не должен меняться, получили %v", got)
+	}
+
+	// после эскалации отдельное напоминание уже не шлем
+	report, _ = job.RunOnce(context.Background())
+	if len(report.Actions) != 0 {
+		t.Fatalf("повторный прогон ничего не должен делать, получили %+v", report.Actions)
+	}
+}
+
+// Проверяем, что пока блокировку держит другая реплика, прогон пропускается
+func TestStaleReviewJob_SkipsWhenLockedByAnotherReplica(t *testing.T) {
+	job, staleRepo, locker, prRepo, _, _ := newTestStaleReviewJob()
+	addStaleAssignment(staleRepo, prRepo, "pr-1", 50*time.Hour)
+
+	release, ok, _ := locker.TryLock(context.Background(), repository.LockKeyStaleReviews)
+	if !ok {
+		t.Fatalf("не удалось взять блокировку")
+	}
+
+	report, err := job.RunOnce(context.Background())
+	if err != nil || !report.Skipped || len(report.Actions) != 0 {
+		t.Fatalf("ожидали пропуск прогона, получили %+v, err=%v", report, err)
+	}
+
+	release()
+	report, _ = job.RunOnce(context.Background())
+	if report.Skipped || len(report.Actions) != 1 {
+		t.Fatalf("после снятия блокировки прогон должен выполниться, получили %+v", report)
+	}
+	if locker.Locked[repository.LockKeyStaleReviews] {
+		t.Fatalf("после прогона блокировка должна быть снята")
+	}
+}
@@ -2,6 +2,7 @@ package unit
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"review-manager/internal/dto"
@@ -14,7 +15,7 @@ func newTestTeamService() (*service.TeamService, *MockTeamRepo, *MockUserRepo) {
 	userRepo := NewMockUserRepo()
 	txMgr := &MockTxManager{}
 
-	svc := service.NewTeamService(teamRepo, userRepo, txMgr)
+	svc := service.NewTeamService(teamRepo, userRepo, NewMockCodeownersRepo(), NewMockWebhookRepo(), txMgr)
 	return svc, teamRepo, userRepo
 }
 
@@ -86,3 +87,203 @@ func TestTeamService_TeamGet_ReturnsTeamWithMembers(t *testing.T) {
 		t.Fatalf("ожидали что в команде будут u1 и u2, получили %v", ids)
 	}
 }
+
+// Проверяем, что dry-run импорта только считает изменения и ничего не пишет
+func TestTeamService_ImportRoster_DryRunDoesNotWrite(t *testing.T) {
+	svc, teamRepo, userRepo := newTestTeamService()
+
+	teamRepo.TeamsByName["backend"] = repository.Team{ID: 1, Name: "backend"}
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Misha", TeamID: 1, IsActive: true}
+
+	entries := []dto.RosterEntry{
+		{Line: 2, TeamName: "backend", UserID: "u1", Username: "Misha", IsActive: true},
+		{Line: 3, TeamName: "backend", UserID: "u2", Username: "Dima", IsActive: true},
+		{Line: 4, TeamName: "frontend", UserID: "u3", Username: "Olya", IsActive: false},
+	}
+
+	resp, err := svc.ImportRoster(context.Background(), entries, true)
+	if err != nil {
+		t.Fatalf("ImportRoster вернул ошибку: %v", err)
+	}
+
+	if !resp.DryRun {
+		t.Fatalf("ожидали dry_run=true в ответе")
+	}
+	if len(resp.TeamsCreated) != 1 || resp.TeamsCreated[0] != "frontend" {
+		t.Fatalf("ожидали создание только команды frontend, получили %v", resp.TeamsCreated)
+	}
+	if len(resp.UsersCreated) != 2 || resp.UsersUnchanged != 1 {
+		t.Fatalf("ожидали 2 новых и 1 неизмененного пользователя, получили %+v", resp)
+	}
+
+	if _, ok := teamRepo.TeamsByName["frontend"]; ok {
+		t.Fatalf("dry-run не должен создавать команды")
+	}
+	if _, ok := userRepo.Users["u2"]; ok {
+		t.Fatalf("dry-run не должен создавать пользователей")
+	}
+}
+
+// Проверяем, что импорт создает новые команды, а существующие пополняет участниками
+func TestTeamService_ImportRoster_AppliesChanges(t *testing.T) {
+	svc, teamRepo, userRepo := newTestTeamService()
+
+	teamRepo.TeamsByName["backend"] = repository.Team{ID: 7, Name: "backend"}
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Misha", TeamID: 7, IsActive: true}
+
+	entries := []dto.RosterEntry{
+		{Line: 2, TeamName: "backend", UserID: "u1", Username: "Misha", IsActive: false},
+		{Line: 3, TeamName: "frontend", UserID: "u3", Username: "Olya", IsActive: true},
+	}
+
+	resp, err := svc.ImportRoster(context.Background(), entries, false)
+	if err != nil {
+		t.Fatalf("ImportRoster вернул ошибку: %v", err)
+	}
+
+	if len(resp.UsersUpdated) != 1 || resp.UsersUpdated[0] != "u1" {
+		t.Fatalf("ожидали обновление u1, получили %v", resp.UsersUpdated)
+	}
+
+	frontend, ok := teamRepo.TeamsByName["frontend"]
+	if !ok {
+		t.Fatalf("ожидали, что команда frontend будет создана")
+	}
+	if userRepo.Users["u3"].TeamID != frontend.ID {
+		t.Fatalf("ожидали u3 в команде frontend, получили team_id=%d", userRepo.Users["u3"].TeamID)
+	}
+	if userRepo.Users["u1"].IsActive {
+		t.Fatalf("ожидали, что u1 станет неактивным после импорта")
+	}
+}
+
+// Проверяем, что TeamAdd сохраняет лимит команды и участников, а TeamGet показывает загрузку
+func TestTeamService_TeamAdd_StoresCapacity(t *testing.T) {
+	svc, teamRepo, userRepo := newTestTeamService()
+
+	teamLimit, userLimit := 3, 10
+	_, err := svc.TeamAdd(context.Background(), dto.TeamAddRequest{
+		TeamName:              "platform",
+		DefaultMaxOpenReviews: &teamLimit,
+		Members: []dto.TeamMember{
+			{UserID: "s1", Username: "Senior", IsActive: true, MaxOpenReviews: &userLimit},
+			{UserID: "j1", Username: "Junior", IsActive: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("TeamAdd вернул ошибку: %v", err)
+	}
+
+	if l := teamRepo.TeamsByName["platform"].DefaultMaxOpenReviews; l == nil || *l != 3 {
+		t.Fatalf("ожидали лимит команды 3, получили %v", l)
+	}
+	if l := userRepo.Users["s1"].MaxOpenReviews; l == nil || *l != 10 {
+		t.Fatalf("ожидали лимит s1 = 10, получили %v", l)
+	}
+
+	team, err := svc.TeamGet(context.Background(), "platform")
+	if err != nil {
+		t.Fatalf("TeamGet вернул ошибку: %v", err)
+	}
+	for _, m := range team.Members {
+		if m.OpenReviews == nil {
+			t.Fatalf("ожидали open_reviews у участника %s", m.UserID)
+		}
+	}
+}
+
+// Проверяем, что лид получает токен, который действует и для дочерних команд, но не для соседних
+func TestTeamService_SetLead_TokenCoversChildTeams(t *testing.T) {
+	svc, _, _ := newTestTeamService()
+	ctx := context.Background()
+
+	for _, name := range []string{"platform", "backend", "frontend"} {
+		if _, err := svc.TeamAdd(ctx, dto.TeamAddRequest{
+			TeamName: name,
+			Members:  []dto.TeamMember{{UserID: name + "-1", Username: name, IsActive: true}},
+		}); err != nil {
+			t.Fatalf("TeamAdd(%s) вернул ошибку: %v", name, err)
+		}
+	}
+	if _, err := svc.SetParent(ctx, dto.SetTeamParentRequest{TeamName: "backend", ParentTeam: "platform"}); err != nil {
+		t.Fatalf("SetParent вернул ошибку: %v", err)
+	}
+
+	resp, err := svc.SetLead(ctx, dto.SetTeamLeadRequest{TeamName: "platform", UserID: "platform-1"})
+	if err != nil {
+		t.Fatalf("SetLead вернул ошибку: %v", err)
+	}
+	if resp.LeadToken == "" || resp.Team.LeadUserID != "platform-1" {
+		t.Fatalf("ожидали лида platform-1 и выданный токен, получили %+v", resp)
+	}
+
+	for team, want := range map[string]bool{"platform": true, "backend": true, "frontend": false} {
+		ok, err := svc.IsLeadToken(ctx, team, resp.LeadToken)
+		if err != nil || ok != want {
+			t.Fatalf("IsLeadToken(%s) = %v, %v, ожидали %v", team, ok, err, want)
+		}
+	}
+	if ok, _ := svc.IsLeadToken(ctx, "backend", "wrong"); ok {
+		t.Fatalf("чужой токен не должен подходить")
+	}
+
+	// повторное назначение выпускает новый токен, старый перестает действовать
+	again, _ := svc.SetLead(ctx, dto.SetTeamLeadRequest{TeamName: "platform", UserID: "platform-1"})
+	if ok, _ := svc.IsLeadToken(ctx, "platform", resp.LeadToken); ok || again.LeadToken == resp.LeadToken {
+		t.Fatalf("старый токен лида должен перестать действовать")
+	}
+}
+
+// Проверяем, что TeamGet показывает иерархию, а цикл в ней создать нельзя
+func TestTeamService_SetParent_HierarchyAndCycle(t *testing.T) {
+	svc, _, _ := newTestTeamService()
+	ctx := context.Background()
+
+	for _, name := range []string{"org", "platform", "backend"} {
+		if _, err := svc.TeamAdd(ctx, dto.TeamAddRequest{
+			TeamName: name,
+			Members:  []dto.TeamMember{{UserID: name + "-1", Username: name, IsActive: true}},
+		}); err != nil {
+			t.Fatalf("TeamAdd(%s) вернул ошибку: %v", name, err)
+		}
+	}
+	_, _ = svc.SetParent(ctx, dto.SetTeamParentRequest{TeamName: "platform", ParentTeam: "org"})
+	_, _ = svc.SetParent(ctx, dto.SetTeamParentRequest{TeamName: "backend", ParentTeam: "platform"})
+
+	team, err := svc.TeamGet(ctx, "platform")
+	if err != nil {
+		t.Fatalf("TeamGet вернул ошибку: %v", err)
+	}
+	if team.ParentTeam != "org" || len(team.Ancestors) != 1 || len(team.ChildTeams) != 1 || team.ChildTeams[0] != "backend" {
+		t.Fatalf("неверная иерархия platform: %+v", team)
+	}
+
+	backend, _ := svc.TeamGet(ctx, "backend")
+	if len(backend.Ancestors) != 2 || backend.Ancestors[0] != "platform" || backend.Ancestors[1] != "org" {
+		t.Fatalf("ожидали предков [platform org], получили %v", backend.Ancestors)
+	}
+
+	if _, err := svc.SetParent(ctx, dto.SetTeamParentRequest{TeamName: "org", ParentTeam: "backend"}); !errors.Is(err, repository.ErrTeamCycle) {
+		t.Fatalf("ожидали ErrTeamCycle, получили %v", err)
+	}
+}
+
+// Проверяем, что TeamAdd не возвращает удаленного пользователя, а отказывает с ErrUserDeleted
+func TestTeamService_TeamAdd_RejectsDeletedMember(t *testing.T) {
+	svc, _, userRepo := newTestTeamService()
+	userRepo.Deleted["u2"] = repository.User{ID: "u2", Username: "Gone", TeamID: 7}
+
+	_, err := svc.TeamAdd(context.Background(), dto.TeamAddRequest{
+		TeamName: "backend",
+		Members: []dto.TeamMember{
+			{UserID: "u1", Username: "Misha", IsActive: true},
+			{UserID: "u2", Username: "Gone", IsActive: true},
+		},
+	})
+	if !errors.Is(err, repository.ErrUserDeleted) {
+		t.Fatalf("ожидали ErrUserDeleted, получили %v", err)
+	}
+	if _, ok := userRepo.Users["u2"]; ok {
+		t.Fatalf("удаленный пользователь не должен вернуться")
+	}
+}
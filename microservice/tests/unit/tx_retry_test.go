@@ -0,0 +1,37 @@
+package unit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"review-manager/internal/repository"
+)
+
+// Проверяем, какие ошибки транзакции считаются временными и повторяются
+func TestIsTransientError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"deadlock", fmt.Errorf("update pr: %w", &pgconn.PgError{Code: "40P01"}), true},
+		{"connection reset", fmt.Errorf("query: %w", syscall.ECONNRESET), true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"statement timeout", &pgconn.PgError{Code: "57014"}, false},
+		{"domain error", repository.ErrPRMerged, false},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, c := range cases {
+		if got := repository.IsTransientError(c.err); got != c.want {
+			t.Fatalf("%s: ожидали %v, получили %v", c.name, c.want, got)
+		}
+	}
+}
@@ -4,19 +4,35 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"review-manager/internal/dto"
+	"review-manager/internal/events"
 	"review-manager/internal/repository"
 	"review-manager/internal/service"
 )
 
 // newTestUserService собирает UserService с моковым репозиторием
 func newTestUserService() (*service.UserService, *MockUserRepo) {
-	userRepo := NewMockUserRepo()
-	svc := service.NewUserService(userRepo)
+	svc, userRepo, _ := newTestUserServiceWithAbsences()
 	return svc, userRepo
 }
 
+func newTestUserServiceWithAbsences() (*service.UserService, *MockUserRepo, *MockAbsenceRepo) {
+	userRepo := NewMockUserRepo()
+	absenceRepo := NewMockAbsenceRepo()
+	svc, _ := newTestUserServiceWithPRs(userRepo, absenceRepo)
+	return svc, userRepo, absenceRepo
+}
+
+// UserService и PRService поверх общего репозитория пользователей
+func newTestUserServiceWithPRs(userRepo *MockUserRepo, absenceRepo *MockAbsenceRepo) (*service.UserService, *MockPrRepo) {
+	broker := events.NewBroker(100)
+	prRepo := NewMockPrRepo()
+	prSvc := service.NewPRService(prRepo, userRepo, NewMockTeamRepo(), NewMockCodeownersRepo(), &MockTxManager{}, broker)
+	return service.NewUserService(userRepo, absenceRepo, prSvc, broker), prRepo
+}
+
 // Проверяем, что SetIsActive меняет флаг активности пользователя и возвращает корректный dto
 func TestUserService_SetIsActive_UpdatesFlag(t *testing.T) {
 	svc, userRepo := newTestUserService()
@@ -76,11 +92,11 @@ func TestUserService_GetReviewPRs_MapsStatuses(t *testing.T) {
 	svc, repo := newTestUserService()
 
 	repo.ReviewPRs["u1"] = []repository.PullRequestShort{
-		{ID: "pr1", Name: "Otkrytyj PR", AuthorID: "a1", StatusName: "OPEN"},
-		{ID: "pr2", Name: "Smerzhennyj PR", AuthorID: "a2", StatusName: "MERGED"},
+		{ID: "pr1", Name: "Otkrytyj PR", AuthorID: "a1", StatusName: "OPEN", CreatedAt: time.Unix(200, 0)},
+		{ID: "pr2", Name: "Smerzhennyj PR", AuthorID: "a2", StatusName: "MERGED", CreatedAt: time.Unix(100, 0)},
 	}
 
-	resp, err := svc.GetReviewPRs(context.Background(), "u1")
+	resp, err := svc.GetReviewPRs(context.Background(), dto.UserGetReviewRequest{UserID: "u1"})
 	if err != nil {
 		t.Fatalf("GetReviewPRs вернул ошибку: %v", err)
 	}
@@ -129,3 +145,242 @@ func TestUserService_GetReviewerStats_BuildsDTO(t *testing.T) {
 		t.Fatalf("вторая запись статистики собрана некорректно, получили %+v", resp.Stats[1])
 	}
 }
+
+// Проверяем, что GetReviewPRs показывает текущие и будущие отсутствия, но не прошедшие
+func TestUserService_GetReviewPRs_ShowsUpcomingAbsences(t *testing.T) {
+	svc, userRepo, absenceRepo := newTestUserServiceWithAbsences()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Petya", TeamID: 1, IsActive: true}
+
+	now := time.Now().UTC()
+	ctx := context.Background()
+
+	_, _ = absenceRepo.Add(ctx, repository.Absence{UserID: "u1", StartsAt: now.Add(-72 * time.Hour), EndsAt: now.Add(-48 * time.Hour), Reason: "past"})
+	_, _ = absenceRepo.Add(ctx, repository.Absence{UserID: "u1", StartsAt: now.Add(24 * time.Hour), EndsAt: now.Add(96 * time.Hour), Reason: "vacation"})
+
+	resp, err := svc.GetReviewPRs(ctx, dto.UserGetReviewRequest{UserID: "u1"})
+	if err != nil {
+		t.Fatalf("GetReviewPRs вернул ошибку: %v", err)
+	}
+
+	if len(resp.UpcomingAbsences) != 1 || resp.UpcomingAbsences[0].Reason != "vacation" {
+		t.Fatalf("ожидали только будущий отпуск, получили %+v", resp.UpcomingAbsences)
+	}
+}
+
+// Проверяем, что AddAbsence не создает отсутствие для неизвестного пользователя
+func TestUserService_AddAbsence_UserNotFound(t *testing.T) {
+	svc, _, absenceRepo := newTestUserServiceWithAbsences()
+
+	now := time.Now().UTC()
+	_, err := svc.AddAbsence(context.Background(), dto.AddAbsenceRequest{
+		UserID:   "ghost",
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+	})
+	if !errors.Is(err, repository.ErrUserNotFound) {
+		t.Fatalf("ожидали ErrUserNotFound, получили %v", err)
+	}
+	if len(absenceRepo.Absences) != 0 {
+		t.Fatalf("отсутствие не должно было сохраниться")
+	}
+}
+
+// Проверяем, что деактивация пользователя публикует user.deactivated с его командой
+func TestUserService_SetIsActive_PublishesDeactivated(t *testing.T) {
+	svc, userRepo := newTestUserService()
+	_, sub := svc.Events.(*events.Broker).Subscribe(0, events.Filter{TeamName: "backend"})
+	defer sub.Close()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Alice", TeamName: "backend", IsActive: true}
+
+	if _, err := svc.SetIsActive(context.Background(), dto.SetUserIsActiveRequest{UserID: "u1", IsActive: false}); err != nil {
+		t.Fatalf("SetIsActive вернул ошибку: %v", err)
+	}
+
+	select {
+	case e := <-sub.C:
+		if e.Type != dto.EventUserDeactivated || e.UserID != "u1" {
+			t.Fatalf("ожидали user.deactivated для u1, получили %+v", e)
+		}
+	default:
+		t.Fatalf("событие user.deactivated не опубликовано")
+	}
+}
+
+// Проверяем мягкое удаление: открытые ревью переназначаются или снимаются,
+// пользователь пропадает из выборок, а его PR в истории остаются
+func TestUserService_Delete_ReassignsAndHides(t *testing.T) {
+	userRepo := NewMockUserRepo()
+	svc, prRepo := newTestUserServiceWithPRs(userRepo, NewMockAbsenceRepo())
+	ctx := context.Background()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["u2"] = repository.User{ID: "u2", Username: "Leaving", TeamID: 1, TeamName: "backend", IsActive: true}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Backup", TeamID: 1, TeamName: "backend", IsActive: true}
+
+	prRepo.PRs["pr-1"] = repository.PullRequest{ID: "pr-1", AuthorID: "u1", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-1"] = []string{"u2"}
+	// единственный кандидат u2 на PR от u3 - заменить некем
+	prRepo.PRs["pr-2"] = repository.PullRequest{ID: "pr-2", AuthorID: "u3", StatusID: repository.StatusOpen}
+	prRepo.Reviewers["pr-2"] = []string{"u1", "u2"}
+	prRepo.PRs["pr-3"] = repository.PullRequest{ID: "pr-3", AuthorID: "u1", StatusID: repository.StatusMerged}
+	prRepo.Reviewers["pr-3"] = []string{"u2"}
+	userRepo.ReviewPRs["u2"] = []repository.PullRequestShort{
+		{ID: "pr-1", AuthorID: "u1", StatusName: "OPEN"},
+		{ID: "pr-2", AuthorID: "u3", StatusName: "OPEN"},
+		{ID: "pr-3", AuthorID: "u1", StatusName: "MERGED"},
+	}
+
+	resp, err := svc.Delete(ctx, dto.DeleteUserRequest{UserID: "u2"})
+	if err != nil {
+		t.Fatalf("Delete вернул ошибку: %v", err)
+	}
+	if len(resp.ReassignedPrs) != 1 || resp.ReassignedPrs[0] != "pr-1" || len(resp.RemovedFromPrs) != 1 || resp.RemovedFromPrs[0] != "pr-2" {
+		t.Fatalf("ожидали переназначение pr-1 и снятие с pr-2, получили %+v", resp)
+	}
+	if got := prRepo.Reviewers["pr-1"]; len(got) != 1 || got[0] != "u3" {
+		t.Fatalf("ожидали ревьювера u3 на pr-1, получили %v", got)
+	}
+	if got := prRepo.Reviewers["pr-3"]; len(got) != 1 || got[0] != "u2" {
+		t.Fatalf("смерженный PR не должен меняться, получили %v", got)
+	}
+
+	if _, err := userRepo.GetByID(ctx, "u2"); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Fatalf("удаленный пользователь должен быть скрыт, получили %v", err)
+	}
+	if _, err := svc.Delete(ctx, dto.DeleteUserRequest{UserID: "u2"}); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Fatalf("повторное удаление должно вернуть ErrUserNotFound, получили %v", err)
+	}
+}
+
+// Проверяем, что архивные PR в /users/getReview попадают только по запросу
+func TestUserService_GetReviewPRs_IncludeArchived(t *testing.T) {
+	svc, userRepo := newTestUserService()
+	ctx := context.Background()
+
+	userRepo.ReviewPRs["u1"] = []repository.PullRequestShort{{ID: "pr-new", AuthorID: "u2", StatusName: "OPEN", CreatedAt: time.Unix(200, 0)}}
+	userRepo.ArchivedReviewPRs["u1"] = []repository.PullRequestShort{{ID: "pr-old", AuthorID: "u2", StatusName: "MERGED", CreatedAt: time.Unix(100, 0)}}
+
+	live, _ := svc.GetReviewPRs(ctx, dto.UserGetReviewRequest{UserID: "u1"})
+	all, _ := svc.GetReviewPRs(ctx, dto.UserGetReviewRequest{UserID: "u1", IncludeArchived: true})
+	if len(live.PullRequests) != 1 || len(all.PullRequests) != 2 || all.PullRequests[1].PullRequestID != "pr-old" {
+		t.Fatalf("ожидали 1 PR без архива и 2 с архивом, получили %+v и %+v", live.PullRequests, all.PullRequests)
+	}
+}
+
+// Проверяем постраничную выдачу: фильтр по статусу, total, курсор и соседей-ревьюверов
+func TestUserService_GetReviewPRs_PaginatesByCursor(t *testing.T) {
+	svc, userRepo := newTestUserService()
+	ctx := context.Background()
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	userRepo.ReviewPRs["u1"] = []repository.PullRequestShort{
+		{ID: "pr-a", StatusName: "OPEN", CreatedAt: base, OtherReviewers: []string{"u2"}},
+		// одинаковое время - порядок решает id
+		{ID: "pr-b", StatusName: "OPEN", CreatedAt: base.Add(time.Hour)},
+		{ID: "pr-c", StatusName: "OPEN", CreatedAt: base.Add(time.Hour)},
+		{ID: "pr-m", StatusName: "MERGED", CreatedAt: base.Add(2 * time.Hour)},
+	}
+
+	req := dto.UserGetReviewRequest{UserID: "u1", Statuses: []dto.PullRequestStatus{dto.PrStatusOpen}, Limit: 2}
+	first, err := svc.GetReviewPRs(ctx, req)
+	if err != nil {
+		t.Fatalf("GetReviewPRs вернул ошибку: %v", err)
+	}
+	if first.Total != 3 || len(first.PullRequests) != 2 || first.PullRequests[0].PullRequestID != "pr-c" || first.PullRequests[1].PullRequestID != "pr-b" {
+		t.Fatalf("первая страница некорректна: %+v", first)
+	}
+	if first.NextCursor == "" {
+		t.Fatalf("ожидали курсор на следующую страницу")
+	}
+
+	req.Cursor = first.NextCursor
+	second, err := svc.GetReviewPRs(ctx, req)
+	if err != nil {
+		t.Fatalf("GetReviewPRs вернул ошибку: %v", err)
+	}
+	if len(second.PullRequests) != 1 || second.PullRequests[0].PullRequestID != "pr-a" || second.NextCursor != "" {
+		t.Fatalf("вторая страница некорректна: %+v", second)
+	}
+	pr := second.PullRequests[0]
+	if pr.CreatedAt == nil || !pr.CreatedAt.Equal(base) || len(pr.OtherReviewers) != 1 || pr.OtherReviewers[0] != "u2" {
+		t.Fatalf("ожидали created_at и остальных ревьюверов, получили %+v", pr)
+	}
+
+	req.Cursor = "not-a-cursor"
+	if _, err := svc.GetReviewPRs(ctx, req); !errors.Is(err, service.ErrBadCursor) {
+		t.Fatalf("ожидали ErrBadCursor, получили %v", err)
+	}
+}
+
+// Проверяем дашборд автора: выключенный или сменивший команду ревьювер открытого PR
+// помечает его на переназначение, смерженные PR не помечаются
+func TestUserService_GetAuthoredPRs_FlagsStaleReviewers(t *testing.T) {
+	userRepo := NewMockUserRepo()
+	svc, prRepo := newTestUserServiceWithPRs(userRepo, NewMockAbsenceRepo())
+	ctx := context.Background()
+
+	userRepo.Users["u1"] = repository.User{ID: "u1", Username: "Author", TeamID: 1, TeamName: "backend", IsActive: true}
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	prRepo.PRs["pr-ok"] = repository.PullRequest{ID: "pr-ok", AuthorID: "u1", StatusID: repository.StatusOpen, CreatedAt: base}
+	prRepo.Reviewers["pr-ok"] = []string{"u2", "u3"}
+	prRepo.PRs["pr-moved"] = repository.PullRequest{ID: "pr-moved", AuthorID: "u1", StatusID: repository.StatusOpen, CreatedAt: base.Add(time.Hour)}
+	prRepo.Reviewers["pr-moved"] = []string{"u2", "u4"}
+	prRepo.PRs["pr-merged"] = repository.PullRequest{ID: "pr-merged", AuthorID: "u1", StatusID: repository.StatusMerged, CreatedAt: base.Add(2 * time.Hour)}
+	prRepo.Reviewers["pr-merged"] = []string{"u5"}
+	prRepo.PRs["pr-other"] = repository.PullRequest{ID: "pr-other", AuthorID: "u9", StatusID: repository.StatusOpen, CreatedAt: base}
+
+	prRepo.ReviewerStates["u4"] = repository.PrReviewerState{IsActive: true, MovedTeam: true, TeamName: "frontend"}
+	prRepo.ReviewerStates["u5"] = repository.PrReviewerState{IsActive: false}
+
+	resp, err := svc.GetAuthoredPRs(ctx, "u1")
+	if err != nil {
+		t.Fatalf("GetAuthoredPRs вернул ошибку: %v", err)
+	}
+	if len(resp.PullRequests) != 3 || resp.NeedsReassignCount != 1 {
+		t.Fatalf("ожидали 3 PR и 1 на переназначение, получили %+v", resp)
+	}
+
+	got := map[string]bool{}
+	for _, pr := range resp.PullRequests {
+		got[pr.PR.PullRequestID] = pr.NeedsReassign
+	}
+	if !got["pr-moved"] || got["pr-ok"] || got["pr-merged"] {
+		t.Fatalf("неверные флаги needs_reassign: %v", got)
+	}
+	if first := resp.PullRequests[0]; first.PR.PullRequestID != "pr-merged" || len(first.Reviewers) != 1 || first.Reviewers[0].IsActive {
+		t.Fatalf("ожидали первым самый новый PR с выключенным ревьювером, получили %+v", first)
+	}
+
+	if _, err := svc.GetAuthoredPRs(ctx, "ghost"); !errors.Is(err, repository.ErrUserNotFound) {
+		t.Fatalf("ожидали ErrUserNotFound, получили %v", err)
+	}
+}
+
+// Проверяем восстановление: пользователь возвращается выключенным, а живого восстановить нельзя
+func TestUserService_Restore_ReturnsDeletedUserInactive(t *testing.T) {
+	svc, userRepo := newTestUserService()
+	ctx := context.Background()
+
+	userRepo.Deleted["u2"] = repository.User{ID: "u2", Username: "Gone", TeamID: 1, TeamName: "backend", IsActive: false}
+	userRepo.Users["u3"] = repository.User{ID: "u3", Username: "Alive", TeamID: 1, TeamName: "backend", IsActive: true}
+
+	user, err := svc.Restore(ctx, dto.RestoreUserRequest{UserID: "u2"})
+	if err != nil {
+		t.Fatalf("Restore вернул ошибку: %v", err)
+	}
+	if user.UserID != "u2" || user.TeamName != "backend" || user.IsActive {
+		t.Fatalf("ожидали выключенного u2 из backend, получили %+v", user)
+	}
+	if _, err := userRepo.GetByID(ctx, "u2"); err != nil {
+		t.Fatalf("восстановленный пользователь должен быть виден, получили %v", err)
+	}
+
+	for _, id := range []string{"u2", "u3", "unknown"} {
+		if _, err := svc.Restore(ctx, dto.RestoreUserRequest{UserID: id}); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Fatalf("%s: ожидали ErrUserNotFound, получили %v", id, err)
+		}
+	}
+}